@@ -0,0 +1,187 @@
+package pgocomp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// DependentApplier is an Applier that must only be applied after its dependencies
+type DependentApplier interface {
+	Applier
+	//Dependencies returns the appliers that must be applied before this one
+	Dependencies() []Applier
+}
+
+type dependentApplier struct {
+	Applier
+	dependencies []Applier
+}
+
+// DependsOn returns an Applier that applies all the dependencies before applying the received applier.
+// ApplyAllConcurrently uses the dependencies to decide which appliers can run side by side, and does not apply again the
+// dependencies of its list
+func DependsOn(applier Applier, dependencies ...Applier) DependentApplier {
+	return &dependentApplier{Applier: applier, dependencies: dependencies}
+}
+
+// Apply applies the dependencies and then the internal applier
+func (d *dependentApplier) Apply(ctx *pulumi.Context) error {
+	for _, dep := range d.dependencies {
+		if err := dep.Apply(ctx); err != nil {
+			return err
+		}
+	}
+	return d.Applier.Apply(ctx)
+}
+
+// Dependencies returns the appliers that must be applied before this one
+func (d *dependentApplier) Dependencies() []Applier {
+	return d.dependencies
+}
+
+// ApplierFunc turns a function into an Applier
+type ApplierFunc func(ctx *pulumi.Context) error
+
+// Apply calls the function
+func (f ApplierFunc) Apply(ctx *pulumi.Context) error {
+	return f(ctx)
+}
+
+// Export is a goroutine safe version of ctx.Export that can be used by components applied concurrently
+func Export(ctx *pulumi.Context, name string, value pulumi.Input) {
+	trackerOf(ctx).export(ctx, name, value)
+}
+
+// ApplyAllConcurrently applies the appliers in parallel using at most the given number of workers (no limit when workers <= 0).
+// An applier created with DependsOn only starts after the appliers of the list it depends on succeeded, and is skipped with an error
+// if one of them failed.
+// Components shared by many appliers are still created only once, the other appliers wait for them.
+// When appliers run side by side, the components they apply are recorded as children of the component that called ApplyAllConcurrently.
+// Unlike ApplyAll, it does not stop at the first error: every failure is returned in a joined error
+func ApplyAllConcurrently(ctx *pulumi.Context, workers int, appliers ...Applier) error {
+	deps, err := dependencyIndexes(appliers)
+	if err != nil {
+		return err
+	}
+	if workers <= 0 {
+		workers = len(appliers)
+	}
+	if workers > 1 && len(appliers) > 1 {
+		defer trackerOf(ctx).concurrently()()
+	}
+	var (
+		slots  = make(chan struct{}, workers)
+		done   = make([]chan struct{}, len(appliers))
+		failed = make([]bool, len(appliers))
+		errs   = make([]error, len(appliers))
+		wg     sync.WaitGroup
+	)
+	for i := range appliers {
+		done[i] = make(chan struct{})
+	}
+	for i, applier := range appliers {
+		wg.Add(1)
+		go func(i int, applier Applier) {
+			defer wg.Done()
+			defer close(done[i])
+			for _, d := range deps[i] {
+				<-done[d]
+				if failed[d] {
+					failed[i] = true
+					errs[i] = fmt.Errorf("pgocomp: applier at position %d skipped: dependency %d failed", i, d)
+					return
+				}
+			}
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if errs[i] = applyScheduled(ctx, applier, appliers, deps[i]); errs[i] != nil {
+				failed[i] = true
+			}
+		}(i, applier)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// applyScheduled applies an applier of ApplyAllConcurrently once the appliers of the list it depends on were applied.
+// An applier created with DependsOn only applies its other dependencies, so non idempotent appliers are not applied twice
+func applyScheduled(ctx *pulumi.Context, applier Applier, appliers []Applier, deps []int) error {
+	dependent, ok := applier.(*dependentApplier)
+	if !ok {
+		return applier.Apply(ctx)
+	}
+	for _, dep := range dependent.dependencies {
+		scheduled := false
+		for _, d := range deps {
+			scheduled = scheduled || sameApplier(dep, appliers[d])
+		}
+		if scheduled {
+			continue
+		}
+		if err := dep.Apply(ctx); err != nil {
+			return err
+		}
+	}
+	return dependent.Applier.Apply(ctx)
+}
+
+// dependencyIndexes returns, for each applier, the positions of the appliers of the list it depends on
+func dependencyIndexes(appliers []Applier) ([][]int, error) {
+	deps := make([][]int, len(appliers))
+	for i, applier := range appliers {
+		dependent, ok := applier.(DependentApplier)
+		if !ok {
+			continue
+		}
+		for _, dep := range dependent.Dependencies() {
+			for j, other := range appliers {
+				if i != j && sameApplier(dep, other) {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+	// 0: not visited, 1: visiting, 2: visited
+	state := make([]int, len(appliers))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("pgocomp: appliers at position %d depend on each other in a cycle", i)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		for _, d := range deps[i] {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		return nil
+	}
+	for i := range appliers {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return deps, nil
+}
+
+// sameApplier tells if two appliers are the same, looking through the appliers created by DependsOn
+func sameApplier(a, b Applier) bool {
+	if d, ok := a.(*dependentApplier); ok {
+		a = d.Applier
+	}
+	if d, ok := b.(*dependentApplier); ok {
+		b = d.Applier
+	}
+	if a == nil || b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
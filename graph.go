@@ -0,0 +1,129 @@
+package pgocomp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// GraphNode is a component that was requested during a pulumi program
+type GraphNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// GraphEdge links a component (Parent) to a component requested inside its apply function (Child)
+type GraphEdge struct {
+	Parent string `json:"parent"`
+	Child  string `json:"child"`
+}
+
+// Graph is the dependency graph of the components requested on a pulumi context
+type Graph struct {
+	lock  sync.Mutex
+	nodes []*GraphNode
+	byKey map[any]*GraphNode
+	edges []GraphEdge
+	seen  map[GraphEdge]bool
+}
+
+func newGraph() *Graph {
+	return &Graph{byKey: make(map[any]*GraphNode), seen: make(map[GraphEdge]bool)}
+}
+
+// GraphOf returns the dependency graph recorded so far on a pulumi context.
+// Every Get, GetAndThen or Apply made inside the apply function of another component is an edge of the graph
+func GraphOf(ctx *pulumi.Context) *Graph {
+	return trackerOf(ctx).graph
+}
+
+func (g *Graph) node(key any, name, typ string) *GraphNode {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	n, ok := g.byKey[key]
+	if !ok {
+		n = &GraphNode{ID: fmt.Sprintf("n%d", len(g.nodes)), Name: name, Type: typ}
+		g.byKey[key] = n
+		g.nodes = append(g.nodes, n)
+	}
+	return n
+}
+
+func (g *Graph) edge(parent, child *GraphNode) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	e := GraphEdge{Parent: parent.ID, Child: child.ID}
+	if !g.seen[e] {
+		g.seen[e] = true
+		g.edges = append(g.edges, e)
+	}
+}
+
+// Nodes returns the recorded components in the order they were first requested
+func (g *Graph) Nodes() []GraphNode {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	nodes := make([]GraphNode, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, *n)
+	}
+	return nodes
+}
+
+// Edges returns the recorded parent to child edges in the order they were first requested
+func (g *Graph) Edges() []GraphEdge {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	edges := make([]GraphEdge, len(g.edges))
+	copy(edges, g.edges)
+	return edges
+}
+
+// DOT renders the graph in the Graphviz DOT language
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph pgocomp {\n")
+	for _, n := range g.Nodes() {
+		fmt.Fprintf(&b, "  %s [label=%s];\n", n.ID, dotQuote(n.Name+"\n"+n.Type))
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "  %s -> %s;\n", e.Parent, e.Child)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+	for _, n := range g.Nodes() {
+		fmt.Fprintf(&b, "  %s[\"%s<br/>%s\"]\n", n.ID, mermaidEscape(n.Name), mermaidEscape(n.Type))
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "  %s --> %s\n", e.Parent, e.Child)
+	}
+	return b.String()
+}
+
+// MarshalJSON renders the graph as a json object with its nodes and edges
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Nodes []GraphNode `json:"nodes"`
+		Edges []GraphEdge `json:"edges"`
+	}{Nodes: g.Nodes(), Edges: g.Edges()})
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func mermaidEscape(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	return r.Replace(s)
+}
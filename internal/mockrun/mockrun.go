@@ -0,0 +1,175 @@
+// Package mockrun runs pgocomp components under Pulumi mocks and records every registered resource.
+// It is shared by pgotest and pgoplan, so programs that plan their components do not depend on the testing package
+package mockrun
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fpco-internal/pgocomp"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	//Project is the name of the pulumi project of the runs
+	Project = "pgotest"
+	//Stack is the name of the pulumi stack of the runs
+	Stack = "test"
+)
+
+// Resource is a resource registered while running a component under mocks
+type Resource struct {
+	URN                 string         `json:"urn"`
+	Type                string         `json:"type"`
+	Name                string         `json:"name"`
+	Custom              bool           `json:"custom"`
+	Parent              string         `json:"parent,omitempty"`
+	Provider            string         `json:"provider,omitempty"`
+	Protect             bool           `json:"protect,omitempty"`
+	DeleteBeforeReplace bool           `json:"deleteBeforeReplace,omitempty"`
+	Dependencies        []string       `json:"dependencies,omitempty"`
+	Inputs              map[string]any `json:"inputs"`
+}
+
+// CallFunc answers an invoke made under mocks
+type CallFunc func(args pulumi.MockCallArgs) (resource.PropertyMap, error)
+
+// Mocks is a pulumi.MockResourceMonitor that records the registered resources
+type Mocks struct {
+	//Calls answers invokes by their token, like aws:index/getAvailabilityZones:getAvailabilityZones. Unknown invokes return their arguments
+	Calls map[string]CallFunc
+	//Outputs adds outputs to the state of a resource, which otherwise are its inputs plus an id and an arn
+	Outputs func(args pulumi.MockResourceArgs) resource.PropertyMap
+
+	lock      sync.Mutex
+	resources []Resource
+}
+
+// NewMocks returns mocks that answer the AWS invokes used by awscinfra: availability zones and hosted zone lookups
+func NewMocks() *Mocks {
+	return &Mocks{
+		Calls: map[string]CallFunc{
+			"aws:index/getAvailabilityZones:getAvailabilityZones": func(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+				return resource.NewPropertyMapFromMap(map[string]any{
+					"names":   []any{"us-east-1a", "us-east-1b", "us-east-1c"},
+					"zoneIds": []any{"use1-az1", "use1-az2", "use1-az4"},
+					"id":      "us-east-1",
+					"state":   "available",
+				}), nil
+			},
+			"aws:route53/getZone:getZone": func(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+				name := args.Args["name"].StringValue()
+				return resource.NewPropertyMapFromMap(map[string]any{
+					"name":        name,
+					"zoneId":      "Z" + strings.ToUpper(strings.ReplaceAll(name, ".", "")),
+					"privateZone": false,
+				}), nil
+			},
+		},
+	}
+}
+
+// NewResource records the resource and returns its inputs as its state
+func (m *Mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	r := Resource{
+		URN:      urnOf(args.RegisterRPC.GetParent(), args.TypeToken, args.Name),
+		Type:     args.TypeToken,
+		Name:     args.Name,
+		Custom:   args.Custom,
+		Parent:   args.RegisterRPC.GetParent(),
+		Provider: args.Provider,
+		Inputs:   plain(args.Inputs),
+	}
+	if rpc := args.RegisterRPC; rpc != nil {
+		r.Protect = rpc.GetProtect()
+		r.DeleteBeforeReplace = rpc.GetDeleteBeforeReplace()
+		r.Dependencies = append([]string(nil), rpc.GetDependencies()...)
+		sort.Strings(r.Dependencies)
+	}
+	m.lock.Lock()
+	m.resources = append(m.resources, r)
+	m.lock.Unlock()
+
+	id := args.ID
+	if id == "" {
+		id = args.Name + "_id"
+	}
+	state := args.Inputs.Copy()
+	state["arn"] = resource.NewStringProperty(fmt.Sprintf("arn:aws:mock:::%s/%s", args.TypeToken, args.Name))
+	if m.Outputs != nil {
+		for k, v := range m.Outputs(args) {
+			state[k] = v
+		}
+	}
+	return id, state, nil
+}
+
+// Call answers an invoke with Calls, or returns its arguments
+func (m *Mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	if call, ok := m.Calls[args.Token]; ok {
+		return call(args)
+	}
+	return args.Args, nil
+}
+
+// Resources returns the recorded resources sorted by URN
+func (m *Mocks) Resources() []Resource {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	resources := append([]Resource(nil), m.resources...)
+	sort.Slice(resources, func(i, j int) bool { return resources[i].URN < resources[j].URN })
+	return resources
+}
+
+// Run applies the component under the mocks and returns every registered resource and the dependency graph of the components.
+// The run is released once it is over
+func Run(mocks *Mocks, applier pgocomp.Applier) ([]Resource, *pgocomp.Graph, error) {
+	var run *pulumi.Context
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		run = ctx
+		return applier.Apply(ctx)
+	}, pulumi.WithMocks(Project, Stack, mocks))
+	var graph *pgocomp.Graph
+	if run != nil {
+		graph = pgocomp.GraphOf(run)
+		pgocomp.Release(run)
+	}
+	return mocks.Resources(), graph, err
+}
+
+// urnOf builds the urn of a resource the same way the pulumi mocks do
+func urnOf(parent, typ, name string) string {
+	parentType := tokens.Type("")
+	if parentURN := resource.URN(parent); parentURN != "" && parentURN.Type() != resource.RootStackType {
+		parentType = parentURN.QualifiedType()
+	}
+	return string(resource.NewURN(tokens.QName(Stack), tokens.PackageName(Project), parentType, tokens.Type(typ), tokens.QName(name)))
+}
+
+// plain turns a property map into plain values that can be compared and written as json
+func plain(props resource.PropertyMap) map[string]any {
+	return props.MapRepl(nil, func(v resource.PropertyValue) (any, bool) {
+		switch {
+		case v.IsComputed():
+			return "[unknown]", true
+		case v.IsSecret():
+			return "[secret]", true
+		case v.IsResourceReference():
+			return string(v.ResourceReferenceValue().URN), true
+		case v.IsOutput():
+			if !v.OutputValue().Known {
+				return "[unknown]", true
+			}
+			if v.OutputValue().Secret {
+				return "[secret]", true
+			}
+			return plain(resource.PropertyMap{"v": v.OutputValue().Element})["v"], true
+		}
+		return nil, false
+	})
+}
@@ -0,0 +1,126 @@
+package pgocomp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// NamingConvention builds the full name of a component from its name prefix, the names of its parents and its own name
+type NamingConvention interface {
+	Name(prefix string, path []string, name string) (string, error)
+}
+
+// DefaultNaming joins the name prefix and the name with a dash. It ignores the parents, so adding a parent
+// does not rename, and replace, existing resources. Use PathNaming to opt in to names with the parents
+type DefaultNaming struct{}
+
+// Name returns prefix-name, or name when there is no prefix
+func (DefaultNaming) Name(prefix string, _ []string, name string) (string, error) {
+	return joinNames("-", prefix, name), nil
+}
+
+// PathNaming prefixes the name with the names of all its parents, like myinfra-myvpc-public-subnet1
+type PathNaming struct {
+	//Separator defaults to a dash
+	Separator string
+}
+
+// Name returns prefix-parent1-parent2-name
+func (p PathNaming) Name(prefix string, path []string, name string) (string, error) {
+	return joinNames(valueOrDefault(p.Separator, "-"), append(append([]string{prefix}, path...), name)...), nil
+}
+
+// EnvAppResource names components as env-app-resource, like prod-shop-http
+type EnvAppResource struct {
+	Env string
+	App string
+}
+
+// Name returns env-app-prefix-name
+func (e EnvAppResource) Name(prefix string, _ []string, name string) (string, error) {
+	if e.Env == "" || e.App == "" {
+		return "", fmt.Errorf("pgocomp: env and app of the naming convention are required to name %s", name)
+	}
+	return joinNames("-", e.Env, e.App, prefix, name), nil
+}
+
+// HashLimited limits the length of the names of another convention, like the 32 characters of AWS target groups.
+// Names that are too long are truncated and end with a hash of the whole name, so they stay unique
+type HashLimited struct {
+	//Convention defaults to DefaultNaming
+	Convention NamingConvention
+	//MaxLength is the number of characters of the longest name. Zero or less means no limit
+	MaxLength int
+}
+
+// hashLength is the number of hexadecimal characters of the hash appended by HashLimited
+const hashLength = 8
+
+// Name returns the name of the internal convention, truncated and hashed when it is longer than MaxLength
+func (h HashLimited) Name(prefix string, path []string, name string) (string, error) {
+	var convention NamingConvention = DefaultNaming{}
+	if h.Convention != nil {
+		convention = h.Convention
+	}
+	full, err := convention.Name(prefix, path, name)
+	if err != nil || h.MaxLength <= 0 || utf8.RuneCountInString(full) <= h.MaxLength {
+		return full, err
+	}
+	if h.MaxLength < hashLength+2 {
+		return "", fmt.Errorf("pgocomp: cannot shorten %s to %d characters", full, h.MaxLength)
+	}
+	sum := sha256.Sum256([]byte(full))
+	//Truncates on a rune boundary, so multi-byte characters are not split
+	kept := []rune(full)[:h.MaxLength-hashLength-1]
+	return strings.TrimRight(string(kept), "-") + "-" + hex.EncodeToString(sum[:])[:hashLength], nil
+}
+
+// Child returns the meta of a component that belongs to this one, like its security group.
+// The child is named name-suffix and keeps the naming, the path, the tags, the protection, the error policy
+// and the component registration of the meta. The path and the tags are copied, so changing them on the child
+// does not change the parent
+func (m *Meta) Child(suffix string) Meta {
+	var tags map[string]string
+	if m.Tags != nil {
+		tags = make(map[string]string, len(m.Tags))
+		for k, v := range m.Tags {
+			tags[k] = v
+		}
+	}
+	return Meta{
+		Name:              joinNames("-", m.Name, suffix),
+		NamePrefix:        m.NamePrefix,
+		Naming:            m.Naming,
+		Path:              append([]string(nil), m.Path...),
+		Tags:              tags,
+		Protect:           m.Protect,
+		ErrorPolicy:       m.ErrorPolicy,
+		RegisterComponent: m.RegisterComponent,
+	}
+}
+
+// FullName is a composition of the NamePrefix, the names of the parents and the Name, following the naming convention of the meta
+func (m *Meta) FullName() (string, error) {
+	if m.Name == "" {
+		return "", errors.New("pgocomp: the name of the component is blank")
+	}
+	var naming NamingConvention = DefaultNaming{}
+	if m.Naming != nil {
+		naming = m.Naming
+	}
+	return naming.Name(m.NamePrefix, m.Path, m.Name)
+}
+
+func joinNames(separator string, names ...string) string {
+	var parts []string
+	for _, n := range names {
+		if n != "" {
+			parts = append(parts, n)
+		}
+	}
+	return strings.Join(parts, separator)
+}
@@ -12,23 +12,58 @@ type Component[T any] struct {
 	lock           *sync.Mutex
 	isInstantiated bool
 	element        T
+	err            error
+	policy         ErrorPolicy
 	apply          func(ctx *pulumi.Context, name string) (T, error)
+	//visit is the Get that applies the component, while its apply function runs
+	visit *visit
 }
 
 // Meta Provides more information to the component
 type Meta struct {
-	Inactive bool
-	Name     string
-	Tags     map[string]string
-	Protect  bool
+	Inactive    bool
+	Name        string
+	NamePrefix  string
+	Naming      NamingConvention
+	Path        []string
+	Tags        map[string]string
+	Protect     bool
+	ErrorPolicy ErrorPolicy
+	//RegisterComponent registers the component, and the components inside it, as pulumi ComponentResources
+	RegisterComponent bool
+	//ComponentType is the type token of the registered ComponentResource. Defaults to pgocomp:<package>:<type of the component>
+	ComponentType string
 }
 
-// FullName is a composition of the NamePrefix and the Name
-func (m *Meta) FullName() string {
-	if m.Name == "" {
-		panic("name and name prefix are blank")
+// Inherit returns a copy of the meta that belongs to the parent: the parent is added to its Path,
+// and it also carries the tags of its parent. Tags of the meta override the tags of the parent.
+// NamePrefix and Naming are taken from the parent when they are not set, and RegisterComponent when the parent sets it
+func (m *Meta) Inherit(parent *Meta) Meta {
+	child := *m
+	child.Tags = MergeTags(parent.Tags, m.Tags)
+	child.Path = append(append([]string(nil), parent.Path...), parent.Name)
+	if child.NamePrefix == "" {
+		child.NamePrefix = parent.NamePrefix
 	}
-	return m.Name
+	if child.Naming == nil {
+		child.Naming = parent.Naming
+	}
+	child.RegisterComponent = child.RegisterComponent || parent.RegisterComponent
+	return child
+}
+
+// MergeTags merges many tag maps into a new one. When a key repeats, the last map wins. Returns nil if there are no tags
+func MergeTags(tags ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, t := range tags {
+		for k, v := range t {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[k] = v
+		}
+	}
+	return merged
 }
 
 // ComponentWithMeta is a component created using a meta struct
@@ -44,24 +79,48 @@ func NewPulumiComponentWithMeta[R pulumi.Resource, A any, O pulumi.ResourceOptio
 	args A,
 	opts ...O,
 ) *ComponentWithMeta[R] {
-	return NewComponentWithMeta[R](meta, func(ctx *pulumi.Context, name string) (R, error) {
-		r, err := fn(ctx, name, args, opts...)
+	var comp *ComponentWithMeta[R]
+	comp = newComponentWithMeta[R](meta, func(ctx *pulumi.Context, name string) (R, error) {
+		if err := trackerOf(ctx).claim(comp.Component, typeName[R](), name); err != nil {
+			var r R
+			return r, err
+		}
+		r, err := fn(ctx, name, args, withParent(ctx, comp.visit, opts)...)
 		if err == nil {
-			ctx.Export(name+"-urn", r.URN())
+			Export(ctx, name+"-urn", r.URN())
 		}
 		return r, err
 	})
+	return comp
 }
 
-// NewComponentWithMeta is a generic function that takes a name and an apply function and returns a Component
+// NewComponentWithMeta is a generic function that takes a name and an apply function and returns a Component.
+// The component is named after the full name of the meta. When the full name cannot be built, Get returns the error.
+// When meta.RegisterComponent is set, the component is also registered as a pulumi ComponentResource
 func NewComponentWithMeta[T any](meta Meta, apply func(ctx *pulumi.Context, name string) (T, error)) *ComponentWithMeta[T] {
+	if !meta.RegisterComponent {
+		return newComponentWithMeta(meta, apply)
+	}
+	var comp *ComponentWithMeta[T]
+	comp = newComponentWithMeta(meta, registerComponent(meta, func() *visit { return comp.visit }, apply))
+	return comp
+}
+
+func newComponentWithMeta[T any](meta Meta, apply func(ctx *pulumi.Context, name string) (T, error)) *ComponentWithMeta[T] {
 	return &ComponentWithMeta[T]{
 		Meta: &meta,
 		Component: func() *Component[T] {
 			if meta.Inactive {
-				return NewInactiveComponent[T](meta.FullName())
+				return NewInactiveComponent[T](meta.Name)
 			}
-			return NewComponent(meta.FullName(), apply)
+			name, err := meta.FullName()
+			if err != nil {
+				return NewComponent(meta.Name, func(ctx *pulumi.Context, name string) (T, error) {
+					var t T
+					return t, err
+				})
+			}
+			return NewComponent(name, apply).WithErrorPolicy(meta.ErrorPolicy)
 		}(),
 	}
 }
@@ -103,9 +162,15 @@ func NewPulumiComponent[R any, A any, O pulumi.ResourceOption](
 	args A,
 	opts ...O,
 ) *Component[R] {
-	return NewComponent(name, func(ctx *pulumi.Context, name string) (R, error) {
-		return fn(ctx, name, args, opts...)
+	var comp *Component[R]
+	comp = NewComponent(name, func(ctx *pulumi.Context, name string) (R, error) {
+		if err := trackerOf(ctx).claim(comp, typeName[R](), name); err != nil {
+			var r R
+			return r, err
+		}
+		return fn(ctx, name, args, withParent(ctx, comp.visit, opts)...)
 	})
+	return comp
 }
 
 // GetComponentResponse collect the name and the object of created infrastructure components
@@ -133,6 +198,12 @@ func NewInactiveComponent[T any](name string) *Component[T] {
 	}, lock: &sync.Mutex{}, isInstantiated: false}
 }
 
+// WithErrorPolicy sets what the component does when its apply function fails and returns the component
+func (c *Component[T]) WithErrorPolicy(policy ErrorPolicy) *Component[T] {
+	c.policy = policy
+	return c
+}
+
 // GetAndThen takes a pulumi context and a function that takes a generic item,
 // gets the internal component and
 // apply the received function with its internal component
@@ -153,13 +224,33 @@ func (c *Component[T]) Apply(ctx *pulumi.Context) error {
 }
 
 // Get takes a pulumi context, gets the internal component and return error if any. The internal component is discarded.
-// Can be called multiple times, because it is cached and the component is created only once
+// Can be called multiple times, because it is cached and the component is created only once.
+// When the apply function fails, the error policy of the component decides what the next calls do
 func (c *Component[T]) Get(ctx *pulumi.Context) (*GetComponentResponse[T], error) {
+	t := trackerOf(ctx)
+	v, err := t.enter(c, c.name, typeName[T]())
+	if err != nil {
+		return &GetComponentResponse[T]{Name: c.name}, err
+	}
+	defer t.leave(v)
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	t.locked(v)
+	if c.err != nil {
+		return &GetComponentResponse[T]{Name: c.name, Component: c.element}, c.err
+	}
 	if !c.isInstantiated {
-		var err error
-		if c.element, err = c.apply(ctx, c.name); err != nil {
+		if err = t.failedFast(); err != nil {
+			return &GetComponentResponse[T]{Name: c.name}, err
+		}
+		c.visit = v
+		c.element, err = c.applyWithPolicy(ctx)
+		c.visit = nil
+		if err != nil {
+			c.err = err
+			if c.policy.Mode == FailFast {
+				t.failFast(c.name)
+			}
 			return &GetComponentResponse[T]{Name: c.name, Component: c.element}, err
 		}
 		c.isInstantiated = true
@@ -185,12 +276,12 @@ func ApplyAll(ctx *pulumi.Context, appliers ...Applier) error {
 
 // ExportURN exports the URN of pulumi Resource
 func ExportURN[T pulumi.Resource](ctx *pulumi.Context, r *GetComponentResponse[T]) *GetComponentResponse[T] {
-	ctx.Export(r.Name+"-id", r.Component.URN())
+	Export(ctx, r.Name+"-id", r.Component.URN())
 	return r
 }
 
 // ExportURNWithMeta exports the URN of pulumi Resource
 func ExportURNWithMeta[T pulumi.Resource](ctx *pulumi.Context, name string, r T) {
-	ctx.Export(name, r.URN())
+	Export(ctx, name, r.URN())
 
 }
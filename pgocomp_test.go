@@ -0,0 +1,603 @@
+package pgocomp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// mocks answers the resources and invokes of the runs of the tests with their own inputs
+type mocks struct{}
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+// thing is the resource registered by the components of the tests
+type thing struct {
+	pulumi.CustomResourceState
+}
+
+func newThing(ctx *pulumi.Context, name string, _ struct{}, opts ...pulumi.ResourceOption) (*thing, error) {
+	var r thing
+	return &r, ctx.RegisterResource("pgocomp:test:Thing", name, nil, &r, opts...)
+}
+
+// run applies body in a new run under mocks, releases the run and returns its context and its dependency graph
+func run(body func(ctx *pulumi.Context) error) (*pulumi.Context, *Graph, error) {
+	var ctx *pulumi.Context
+	err := pulumi.RunErr(func(pctx *pulumi.Context) error {
+		ctx = pctx
+		return body(ctx)
+	}, pulumi.WithMocks("pgocomp", "test", mocks{}))
+	graph := GraphOf(ctx)
+	Release(ctx)
+	return ctx, graph, err
+}
+
+// tracked tells if pgocomp still holds what it recorded during the run of the context
+func tracked(ctx *pulumi.Context) bool {
+	trackers.lock.Lock()
+	defer trackers.lock.Unlock()
+	_, ok := trackers.byCtx[ctx]
+	return ok
+}
+
+func TestRunsShareNothing(t *testing.T) {
+	first, _, err := run(func(ctx *pulumi.Context) error {
+		failing := NewComponentWithMeta(Meta{Name: "failing", ErrorPolicy: ErrorPolicy{Mode: FailFast}}, func(ctx *pulumi.Context, name string) (int, error) {
+			return 0, errors.New("boom")
+		})
+		return errors.Join(NewPulumiComponentWithMeta(newThing, Meta{Name: "a"}, struct{}{}).Apply(ctx), failing.Apply(ctx))
+	})
+	if err == nil {
+		t.Fatal("expected the failing component of the first run to fail")
+	}
+	//Another component creates a thing named a, which would collide if the runs shared their names,
+	//and the failure of the first run would stop it if they shared their fail fast state
+	second, graph, err := run(func(ctx *pulumi.Context) error {
+		return NewPulumiComponentWithMeta(newThing, Meta{Name: "a"}, struct{}{}).Apply(ctx)
+	})
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if nodes := graph.Nodes(); len(nodes) != 1 || nodes[0].Name != "a" {
+		t.Fatalf("the graph of the second run has the nodes %v", nodes)
+	}
+	if tracked(first) || tracked(second) {
+		t.Fatal("the runs were not released")
+	}
+}
+
+// component returns a component whose apply function calls body
+func component(name string, body func(ctx *pulumi.Context) error) *ComponentWithMeta[int] {
+	return NewComponentWithMeta(Meta{Name: name}, func(ctx *pulumi.Context, name string) (int, error) {
+		return 0, body(ctx)
+	})
+}
+
+func TestGraphRendering(t *testing.T) {
+	var vpc, subnet *ComponentWithMeta[int]
+	vpc = component("vpc", func(ctx *pulumi.Context) error { return subnet.Apply(ctx) })
+	subnet = component(`sub"net`, func(ctx *pulumi.Context) error { return nil })
+	_, graph, err := run(func(ctx *pulumi.Context) error { return vpc.Apply(ctx) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	json, err := graph.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+	}{{
+		name:     "dot",
+		actual:   graph.DOT(),
+		expected: "digraph pgocomp {\n  n0 [label=\"vpc\\nint\"];\n  n1 [label=\"sub\\\"net\\nint\"];\n  n0 -> n1;\n}\n",
+	}, {
+		name:     "mermaid",
+		actual:   graph.Mermaid(),
+		expected: "graph TD\n  n0[\"vpc<br/>int\"]\n  n1[\"sub#quot;net<br/>int\"]\n  n0 --> n1\n",
+	}, {
+		name:     "json",
+		actual:   string(json),
+		expected: `{"nodes":[{"id":"n0","name":"vpc","type":"int"},{"id":"n1","name":"sub\"net","type":"int"}],"edges":[{"parent":"n0","child":"n1"}]}`,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Fatalf("expected\n%s\ngot\n%s", test.expected, test.actual)
+			}
+		})
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() Applier
+		cycle []string
+	}{{
+		name: "itself",
+		build: func() Applier {
+			var a *ComponentWithMeta[int]
+			a = component("a", func(ctx *pulumi.Context) error { return a.Apply(ctx) })
+			return a
+		},
+		cycle: []string{"a", "a"},
+	}, {
+		name: "through another component",
+		build: func() Applier {
+			var a, b *ComponentWithMeta[int]
+			a = component("a", func(ctx *pulumi.Context) error { return b.Apply(ctx) })
+			b = component("b", func(ctx *pulumi.Context) error { return a.Apply(ctx) })
+			return a
+		},
+		cycle: []string{"a", "b", "a"},
+	}, {
+		name: "through a GetAndThen callback",
+		build: func() Applier {
+			var a, b, c *ComponentWithMeta[int]
+			a = component("a", func(ctx *pulumi.Context) error { return b.Apply(ctx) })
+			b = component("b", func(ctx *pulumi.Context) error {
+				return c.GetAndThen(ctx, func(*GetComponentWithMetaResponse[int]) error { return a.Apply(ctx) })
+			})
+			c = component("c", func(ctx *pulumi.Context) error { return nil })
+			return a
+		},
+		cycle: []string{"a", "b", "a"},
+	}, {
+		name: "through workers",
+		build: func() Applier {
+			var a, b, c *ComponentWithMeta[int]
+			a = component("a", func(ctx *pulumi.Context) error { return ApplyAllConcurrently(ctx, 2, b, c) })
+			b = component("b", func(ctx *pulumi.Context) error { return a.Apply(ctx) })
+			c = component("c", func(ctx *pulumi.Context) error { return nil })
+			return a
+		},
+		cycle: []string{"a", "a"},
+	}, {
+		name: "no cycle when a component is shared",
+		build: func() Applier {
+			var a, b, c *ComponentWithMeta[int]
+			a = component("a", func(ctx *pulumi.Context) error { return errors.Join(b.Apply(ctx), c.Apply(ctx)) })
+			b = component("b", func(ctx *pulumi.Context) error { return c.Apply(ctx) })
+			c = component("c", func(ctx *pulumi.Context) error { return nil })
+			return a
+		},
+	}, {
+		name: "no cycle when workers share a component",
+		build: func() Applier {
+			var a, b, c, shared *ComponentWithMeta[int]
+			a = component("a", func(ctx *pulumi.Context) error { return ApplyAllConcurrently(ctx, 2, b, c) })
+			b = component("b", func(ctx *pulumi.Context) error { return shared.Apply(ctx) })
+			c = component("c", func(ctx *pulumi.Context) error { return shared.Apply(ctx) })
+			shared = component("shared", func(ctx *pulumi.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+			return a
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := run(func(ctx *pulumi.Context) error { return test.build().Apply(ctx) })
+			var cycle *CycleError
+			switch {
+			case test.cycle == nil && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case test.cycle == nil:
+			case !errors.As(err, &cycle):
+				t.Fatalf("expected a cycle error, got %v", err)
+			case strings.Join(cycle.Components, " → ") != strings.Join(test.cycle, " → "):
+				t.Fatalf("expected the cycle %v, got %v", test.cycle, cycle.Components)
+			}
+		})
+	}
+}
+
+func TestGraphEdges(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		edges   []string
+	}{
+		{name: "one after another", workers: 1, edges: []string{"parent → child", "parent → other", "child → grandchild", "other → grandchild", "other → callback"}},
+		//The appliers that run side by side cannot be told apart, so everything they apply belongs to the caller
+		{name: "side by side", workers: 2, edges: []string{"parent → child", "parent → other", "parent → grandchild", "parent → callback"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var parent, child, grandchild, other, callback *ComponentWithMeta[int]
+			parent = component("parent", func(ctx *pulumi.Context) error {
+				return ApplyAllConcurrently(ctx, test.workers, child, other)
+			})
+			child = component("child", func(ctx *pulumi.Context) error { return grandchild.Apply(ctx) })
+			grandchild = component("grandchild", func(ctx *pulumi.Context) error { return nil })
+			other = component("other", func(ctx *pulumi.Context) error {
+				return grandchild.GetAndThen(ctx, func(*GetComponentWithMetaResponse[int]) error { return callback.Apply(ctx) })
+			})
+			callback = component("callback", func(ctx *pulumi.Context) error { return nil })
+			_, graph, err := run(func(ctx *pulumi.Context) error { return parent.Apply(ctx) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make(map[string]string)
+			for _, n := range graph.Nodes() {
+				names[n.ID] = n.Name
+			}
+			var edges []string
+			for _, e := range graph.Edges() {
+				edges = append(edges, names[e.Parent]+" → "+names[e.Child])
+			}
+			sort.Strings(edges)
+			expected := append([]string(nil), test.edges...)
+			sort.Strings(expected)
+			if strings.Join(edges, ", ") != strings.Join(expected, ", ") {
+				t.Fatalf("expected the edges %v, got %v", expected, edges)
+			}
+		})
+	}
+}
+
+func TestApplyAllConcurrentlyWorkers(t *testing.T) {
+	tests := []struct {
+		workers int
+		//max is the most appliers expected to run at the same time
+		max int
+	}{{workers: 1, max: 1}, {workers: 2, max: 2}, {workers: 0, max: 4}}
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.workers), func(t *testing.T) {
+			var lock sync.Mutex
+			var running, max int
+			var appliers []Applier
+			for i := 0; i < 4; i++ {
+				appliers = append(appliers, component(fmt.Sprint("c", i), func(ctx *pulumi.Context) error {
+					lock.Lock()
+					running++
+					if running > max {
+						max = running
+					}
+					lock.Unlock()
+					time.Sleep(20 * time.Millisecond)
+					lock.Lock()
+					running--
+					lock.Unlock()
+					return nil
+				}))
+			}
+			if _, _, err := run(func(ctx *pulumi.Context) error { return ApplyAllConcurrently(ctx, test.workers, appliers...) }); err != nil {
+				t.Fatal(err)
+			}
+			if max > test.max {
+				t.Fatalf("%d appliers ran at the same time, expected at most %d", max, test.max)
+			}
+			if test.max > 1 && max < 2 {
+				t.Fatalf("the appliers did not run side by side")
+			}
+		})
+	}
+}
+
+func TestApplyAllConcurrentlyDependencies(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name string
+		//failing are the components whose apply function fails
+		failing map[string]bool
+		build   func(a, b, c Applier) []Applier
+		applied []string
+		errs    []string
+	}{{
+		name:    "dependencies first",
+		build:   func(a, b, c Applier) []Applier { return []Applier{DependsOn(c, b), DependsOn(b, a), a} },
+		applied: []string{"a", "b", "c"},
+	}, {
+		name:    "dependents of a failure are skipped",
+		failing: map[string]bool{"a": true},
+		build:   func(a, b, c Applier) []Applier { return []Applier{a, DependsOn(b, a), c} },
+		applied: []string{"a", "c"},
+		errs:    []string{"a", "applier at position 1 skipped: dependency 0 failed"},
+	}, {
+		name:    "every failure is joined",
+		failing: map[string]bool{"a": true, "c": true},
+		build:   func(a, b, c Applier) []Applier { return []Applier{a, b, c} },
+		applied: []string{"a", "b", "c"},
+		errs:    []string{"a", "c"},
+	}, {
+		name:  "cycles are rejected",
+		build: func(a, b, c Applier) []Applier { return []Applier{DependsOn(a, b), DependsOn(b, a), c} },
+		errs:  []string{"cycle"},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var lock sync.Mutex
+			var applied []string
+			named := func(name string) Applier {
+				return component(name, func(ctx *pulumi.Context) error {
+					lock.Lock()
+					applied = append(applied, name)
+					lock.Unlock()
+					if test.failing[name] {
+						return boom
+					}
+					return nil
+				})
+			}
+			a, b, c := named("a"), named("b"), named("c")
+			_, _, err := run(func(ctx *pulumi.Context) error { return ApplyAllConcurrently(ctx, 1, test.build(a, b, c)...) })
+			if test.name == "dependencies first" {
+				if strings.Join(applied, ",") != strings.Join(test.applied, ",") {
+					t.Fatalf("expected the order %v, got %v", test.applied, applied)
+				}
+			} else {
+				sort.Strings(applied)
+				if strings.Join(applied, ",") != strings.Join(test.applied, ",") {
+					t.Fatalf("expected the appliers %v to be applied, got %v", test.applied, applied)
+				}
+			}
+			if len(test.errs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, e := range test.errs {
+				if err == nil || !strings.Contains(err.Error(), e) {
+					t.Fatalf("expected an error about %s, got %v", e, err)
+				}
+			}
+			if len(test.errs) > 0 && test.failing != nil && !errors.Is(err, boom) {
+				t.Fatalf("the error does not wrap the failures: %v", err)
+			}
+			if joined, ok := err.(interface{ Unwrap() []error }); ok && len(test.failing) > 0 && len(joined.Unwrap()) != len(test.errs) {
+				t.Fatalf("expected %d joined errors, got %d", len(test.errs), len(joined.Unwrap()))
+			}
+		})
+	}
+}
+
+func TestApplyAllConcurrentlyAppliesOnce(t *testing.T) {
+	var lock sync.Mutex
+	counts := make(map[string]int)
+	//Functions are not comparable, so the appliers are pointers to ApplierFuncs
+	counted := func(name string) *ApplierFunc {
+		f := ApplierFunc(func(ctx *pulumi.Context) error {
+			lock.Lock()
+			counts[name]++
+			lock.Unlock()
+			return nil
+		})
+		return &f
+	}
+	a, b, c, outside := counted("a"), counted("b"), counted("c"), counted("outside")
+	_, _, err := run(func(ctx *pulumi.Context) error {
+		return ApplyAllConcurrently(ctx, 2, a, DependsOn(b, a), DependsOn(c, a, b, outside))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//The dependencies that are not in the list are still applied by their dependent
+	for _, name := range []string{"a", "b", "c", "outside"} {
+		if counts[name] != 1 {
+			t.Errorf("expected %s to be applied once, got %d", name, counts[name])
+		}
+	}
+}
+
+func TestErrorPolicies(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name   string
+		policy ErrorPolicy
+		//failures is the number of attempts that fail before the apply function succeeds
+		failures int
+		calls    int
+		err      string
+		//later is the error of a component applied after the failing one, empty when it succeeds
+		later string
+	}{{
+		name:     "cache error",
+		policy:   ErrorPolicy{Mode: CacheError},
+		failures: 10,
+		calls:    1,
+		err:      "component flaky failed (policy cache-error, 1 attempt(s)): boom",
+	}, {
+		name:     "retry until success",
+		policy:   ErrorPolicy{Mode: Retry, Attempts: 3, Backoff: time.Millisecond},
+		failures: 2,
+		calls:    3,
+	}, {
+		name:     "retry until the attempts are over",
+		policy:   ErrorPolicy{Mode: Retry, Attempts: 2, Backoff: time.Millisecond},
+		failures: 10,
+		calls:    2,
+		err:      "component flaky failed (policy retry(attempts=2, backoff=1ms), 2 attempt(s)): boom",
+	}, {
+		name:     "fail fast",
+		policy:   ErrorPolicy{Mode: FailFast},
+		failures: 10,
+		calls:    1,
+		err:      "component flaky failed (policy fail-fast, 1 attempt(s)): boom",
+		later:    "pgocomp: not applied because component flaky failed (policy fail-fast)",
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			flaky := NewComponentWithMeta(Meta{Name: "flaky", ErrorPolicy: test.policy}, func(ctx *pulumi.Context, name string) (int, error) {
+				calls++
+				if calls <= test.failures {
+					return 0, boom
+				}
+				return calls, nil
+			})
+			var errs []error
+			var later error
+			_, _, err := run(func(ctx *pulumi.Context) error {
+				//The second call returns the cached result
+				errs = append(errs, flaky.Apply(ctx), flaky.Apply(ctx))
+				later = component("later", func(ctx *pulumi.Context) error { return nil }).Apply(ctx)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls != test.calls {
+				t.Fatalf("expected %d calls, got %d", test.calls, calls)
+			}
+			for _, err := range errs {
+				if test.err == "" && err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if test.err != "" && (err == nil || err.Error() != test.err || !errors.Is(err, boom)) {
+					t.Fatalf("expected the error %q, got %v", test.err, err)
+				}
+			}
+			if (later == nil && test.later != "") || (later != nil && later.Error() != test.later) {
+				t.Fatalf("expected the later component to return %q, got %v", test.later, later)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		policy  ErrorPolicy
+		attempt int
+		wait    time.Duration
+	}{
+		{ErrorPolicy{Mode: Retry}, 1, time.Second},
+		{ErrorPolicy{Mode: Retry}, 3, 4 * time.Second},
+		{ErrorPolicy{Mode: Retry}, 5, 16 * time.Second},
+		{ErrorPolicy{Mode: Retry}, 6, 30 * time.Second},
+		{ErrorPolicy{Mode: Retry}, 100, 30 * time.Second},
+		{ErrorPolicy{Mode: Retry, Backoff: time.Millisecond}, 2, 2 * time.Millisecond},
+		{ErrorPolicy{Mode: Retry, Backoff: time.Second, MaxBackoff: 3 * time.Second}, 3, 3 * time.Second},
+	}
+	for _, test := range tests {
+		if wait := test.policy.backoff(test.attempt); wait != test.wait {
+			t.Errorf("%s: expected a wait of %s after attempt %d, got %s", test.policy, test.wait, test.attempt, wait)
+		}
+	}
+}
+
+func TestNameCollisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		second    Meta
+		collision bool
+	}{
+		{name: "same name", second: Meta{Name: "a"}, collision: true},
+		{name: "same full name", second: Meta{Name: "a", Naming: PathNaming{}}, collision: true},
+		{name: "other name", second: Meta{Name: "b"}},
+		{name: "other prefix", second: Meta{Name: "a", NamePrefix: "x"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first := NewPulumiComponentWithMeta(newThing, Meta{Name: "a"}, struct{}{})
+			second := NewPulumiComponentWithMeta(newThing, test.second, struct{}{})
+			var errs []error
+			_, _, err := run(func(ctx *pulumi.Context) error {
+				//Applying the same component again is not a collision
+				errs = append(errs, first.Apply(ctx), first.Apply(ctx), second.Apply(ctx))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if errs[0] != nil || errs[1] != nil {
+				t.Fatalf("unexpected error: %v", errors.Join(errs[:2]...))
+			}
+			if !test.collision && errs[2] != nil {
+				t.Fatalf("unexpected error: %v", errs[2])
+			}
+			if test.collision && (errs[2] == nil || !strings.Contains(errs[2].Error(), "name collision")) {
+				t.Fatalf("expected a name collision, got %v", errs[2])
+			}
+		})
+	}
+}
+
+func TestHashLimited(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLength int
+		expected  string
+		err       bool
+	}{
+		{name: "short", maxLength: 32, expected: "short"},
+		{name: "no-limit-for-a-very-long-name-of-a-target-group", maxLength: 0, expected: "no-limit-for-a-very-long-name-of-a-target-group"},
+		{name: "negative-limit-for-a-very-long-name", maxLength: -1, expected: "negative-limit-for-a-very-long-name"},
+		{name: "a-very-long-name-of-a-target-group", maxLength: 20, expected: "a-very-long-612b3069"},
+		{name: "ñññññññññññññññññññññññ", maxLength: 20, expected: "ñññññññññññ-f0cd3717"},
+		{name: "ñññññ", maxLength: 5, expected: "ñññññ"},
+		{name: "too-short-a-limit", maxLength: 9, err: true},
+	}
+	for _, test := range tests {
+		name, err := HashLimited{MaxLength: test.maxLength}.Name("", nil, test.name)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.name, name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if name != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, name)
+		}
+		if !utf8.ValidString(name) {
+			t.Errorf("%s: %q is not valid UTF-8", test.name, name)
+		}
+	}
+}
+
+func TestMetaChild(t *testing.T) {
+	parent := Meta{
+		Name:              "svc",
+		NamePrefix:        "prod",
+		Naming:            PathNaming{},
+		Path:              []string{"vpc"},
+		Tags:              map[string]string{"team": "web"},
+		Protect:           true,
+		ErrorPolicy:       ErrorPolicy{Mode: Retry, Attempts: 5},
+		RegisterComponent: true,
+		ComponentType:     "pgocomp:test:Service",
+	}
+	child := parent.Child("role")
+	expected := parent
+	expected.Name = "svc-role"
+	expected.ComponentType = ""
+	if fmt.Sprint(child) != fmt.Sprint(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, child)
+	}
+	child.Tags["team"] = "api"
+	child.Path[0] = "other"
+	if parent.Tags["team"] != "web" || parent.Path[0] != "vpc" {
+		t.Errorf("changing the child changed the parent: %+v", parent)
+	}
+}
+
+func TestDefaultNamingIgnoresPath(t *testing.T) {
+	meta := Meta{Name: "subnet1", NamePrefix: "dev", Path: []string{"myvpc", "public"}}
+	if name, _ := meta.FullName(); name != "dev-subnet1" {
+		t.Errorf("expected dev-subnet1, got %s", name)
+	}
+	meta.Naming = PathNaming{}
+	if name, _ := meta.FullName(); name != "dev-myvpc-public-subnet1" {
+		t.Errorf("expected dev-myvpc-public-subnet1, got %s", name)
+	}
+}
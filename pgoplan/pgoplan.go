@@ -0,0 +1,183 @@
+// Package pgoplan builds an offline plan of the resources a component would create, without the Pulumi engine or cloud credentials
+package pgoplan
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/internal/mockrun"
+)
+
+// maxArgLength is the number of characters after which an argument is cut in the table
+const maxArgLength = 60
+
+// Step is a resource that would be created
+type Step struct {
+	//Key identifies the step in the plan, it is made of the type and the name of the resource
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	//Args are the scalar arguments of the resource, like its cidr block, port or protocol
+	Args map[string]any `json:"args,omitempty"`
+	//DependsOn are the keys of the steps that must be created before this one
+	DependsOn []string `json:"dependsOn,omitempty"`
+	//Parent is the key of the component that contains the resource, if any
+	Parent string `json:"parent,omitempty"`
+}
+
+// Plan lists every resource a component would create, sorted by key
+type Plan struct {
+	Steps []Step `json:"steps"`
+}
+
+// New applies the component under pulumi mocks and returns the resources it would create
+func New(applier pgocomp.Applier) (*Plan, error) {
+	resources, _, err := mockrun.Run(mockrun.NewMocks(), applier)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]string, len(resources))
+	for _, res := range resources {
+		keys[res.URN] = keyOf(res.Type, res.Name)
+	}
+	plan := &Plan{Steps: []Step{}}
+	for _, res := range resources {
+		step := Step{
+			Key:    keys[res.URN],
+			Name:   res.Name,
+			Type:   res.Type,
+			Args:   make(map[string]any),
+			Parent: keys[res.Parent],
+		}
+		for k, v := range res.Inputs {
+			if isScalar(v) {
+				step.Args[k] = v
+			}
+		}
+		for _, dep := range res.Dependencies {
+			if key, ok := keys[dep]; ok {
+				step.DependsOn = append(step.DependsOn, key)
+			}
+		}
+		sort.Strings(step.DependsOn)
+		plan.Steps = append(plan.Steps, step)
+	}
+	sort.Slice(plan.Steps, func(i, j int) bool { return plan.Steps[i].Key < plan.Steps[j].Key })
+	return plan, nil
+}
+
+// Find returns the step of a key
+func (p *Plan) Find(key string) (Step, bool) {
+	for _, s := range p.Steps {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Step{}, false
+}
+
+// WriteTable writes the plan as a table with the type, the name, the arguments and the dependencies of every resource
+func (p *Plan) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tNAME\tARGS\tDEPENDS ON")
+	for _, s := range p.Steps {
+		var deps []string
+		for _, d := range s.DependsOn {
+			deps = append(deps, shortKey(d))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Type, s.Name, formatArgs(s.Args), strings.Join(deps, ", "))
+	}
+	return tw.Flush()
+}
+
+// String returns the plan as a table
+func (p *Plan) String() string {
+	var b strings.Builder
+	_ = p.WriteTable(&b)
+	return b.String()
+}
+
+// Diff is the difference between two plans
+type Diff struct {
+	Added   []Step `json:"added,omitempty"`
+	Removed []Step `json:"removed,omitempty"`
+	//Changed are the steps of the new plan whose arguments or dependencies changed
+	Changed []Step `json:"changed,omitempty"`
+}
+
+// Compare returns the steps added, removed and changed from the before plan to the after plan
+func Compare(before, after *Plan) Diff {
+	var diff Diff
+	for _, s := range after.Steps {
+		old, ok := before.Find(s.Key)
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, s)
+		case !reflect.DeepEqual(old.Args, s.Args) || !reflect.DeepEqual(old.DependsOn, s.DependsOn):
+			diff.Changed = append(diff.Changed, s)
+		}
+	}
+	for _, s := range before.Steps {
+		if _, ok := after.Find(s.Key); !ok {
+			diff.Removed = append(diff.Removed, s)
+		}
+	}
+	return diff
+}
+
+// WriteTable writes the difference as a table, prefixing added steps with +, removed steps with - and changed steps with ~
+func (d Diff) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tTYPE\tNAME\tARGS")
+	for _, group := range []struct {
+		sign  string
+		steps []Step
+	}{{"+", d.Added}, {"-", d.Removed}, {"~", d.Changed}} {
+		for _, s := range group.steps {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", group.sign, s.Type, s.Name, formatArgs(s.Args))
+		}
+	}
+	return tw.Flush()
+}
+
+func keyOf(typ, name string) string {
+	return typ + "::" + name
+}
+
+// shortKey returns the short type and the name of a key, like Listener/http
+func shortKey(key string) string {
+	typ, name, _ := strings.Cut(key, "::")
+	return typ[strings.LastIndex(typ, ":")+1:] + "/" + name
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool, float64:
+		return true
+	}
+	return false
+}
+
+func formatArgs(args map[string]any) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		v := fmt.Sprint(args[k])
+		//Cuts on a rune boundary, so multi-byte characters are not split
+		if utf8.RuneCountInString(v) > maxArgLength {
+			v = string([]rune(v)[:maxArgLength]) + "..."
+		}
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,166 @@
+package pgoplan
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// sample registers a component resource with a tagged Vpc and a subnet of the Vpc
+func sample(cidr string) pgocomp.Applier {
+	return pgocomp.NewComponentWithMeta(pgocomp.Meta{Name: "sample", RegisterComponent: true}, func(ctx *pulumi.Context, name string) (*ec2.Subnet, error) {
+		vpc, err := awsc.NewVpc(pgocomp.Meta{Name: "vpc"}, &ec2.VpcArgs{CidrBlock: pulumi.String("10.0.0.0/16"), EnableDnsSupport: pulumi.Bool(true), Tags: pulumi.StringMap{"env": pulumi.String("dev")}}).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		subnet, err := awsc.NewSubnet(pgocomp.Meta{Name: "subnet"}, &ec2.SubnetArgs{VpcId: vpc.Component.ID(), CidrBlock: pulumi.String(cidr)}).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return subnet.Component, nil
+	})
+}
+
+func TestNew(t *testing.T) {
+	plan, err := New(sample("10.0.1.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, step := range plan.Steps {
+		keys = append(keys, step.Key)
+	}
+	expected := []string{"aws:ec2/subnet:Subnet::subnet", "aws:ec2/vpc:Vpc::vpc", "pgocomp:ec2:Subnet::sample"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected the steps %v, got %v", expected, keys)
+	}
+	subnet, _ := plan.Find("aws:ec2/subnet:Subnet::subnet")
+	if !reflect.DeepEqual(subnet.DependsOn, []string{"aws:ec2/vpc:Vpc::vpc"}) || subnet.Parent != "pgocomp:ec2:Subnet::sample" {
+		t.Errorf("unexpected subnet step %+v", subnet)
+	}
+	//The tags are not scalar, so they are not among the arguments
+	vpc, _ := plan.Find("aws:ec2/vpc:Vpc::vpc")
+	if expected := map[string]any{"cidrBlock": "10.0.0.0/16", "enableDnsSupport": true}; !reflect.DeepEqual(vpc.Args, expected) {
+		t.Errorf("expected the arguments %v, got %v", expected, vpc.Args)
+	}
+	if _, ok := plan.Find("aws:ec2/vpc:Vpc::other"); ok {
+		t.Error("unexpected step aws:ec2/vpc:Vpc::other")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	plan, err := New(sample("10.0.1.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string][]map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	subnet := decoded["steps"][0]
+	for _, field := range []string{"key", "name", "type", "args", "dependsOn", "parent"} {
+		if _, ok := subnet[field]; !ok {
+			t.Errorf("expected the field %s in %s", field, data)
+		}
+	}
+	//Empty fields are left out
+	for _, field := range []string{"args", "dependsOn", "parent"} {
+		if _, ok := decoded["steps"][2][field]; ok {
+			t.Errorf("unexpected field %s in the step of the component: %s", field, data)
+		}
+	}
+	var roundTrip Plan
+	if err := json.Unmarshal(data, &roundTrip); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(roundTrip.Steps[0], plan.Steps[0]) {
+		t.Errorf("expected %+v, got %+v", plan.Steps[0], roundTrip.Steps[0])
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	plan, err := New(sample("10.0.1.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"TYPE                   NAME    ARGS                                         DEPENDS ON",
+		"aws:ec2/subnet:Subnet  subnet  cidrBlock=10.0.1.0/24 vpcId=vpc_id           Vpc/vpc",
+		"aws:ec2/vpc:Vpc        vpc     cidrBlock=10.0.0.0/16 enableDnsSupport=true  ",
+		"pgocomp:ec2:Subnet     sample                                               ",
+		"",
+	}, "\n")
+	if plan.String() != expected {
+		t.Errorf("expected the table\n%s\ngot\n%s", expected, plan.String())
+	}
+}
+
+func TestFormatArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected string
+	}{
+		{"empty", nil, ""},
+		{"sorted", map[string]any{"port": 443.0, "protocol": "HTTPS", "enabled": true}, "enabled=true port=443 protocol=HTTPS"},
+		{"at the limit", map[string]any{"a": strings.Repeat("x", maxArgLength)}, "a=" + strings.Repeat("x", maxArgLength)},
+		{"cut", map[string]any{"a": strings.Repeat("x", maxArgLength+1)}, "a=" + strings.Repeat("x", maxArgLength) + "..."},
+		{"multi-byte characters", map[string]any{"a": strings.Repeat("é", maxArgLength+1)}, "a=" + strings.Repeat("é", maxArgLength) + "..."},
+	}
+	for _, test := range tests {
+		formatted := formatArgs(test.args)
+		if formatted != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, formatted)
+		}
+		if !utf8.ValidString(formatted) {
+			t.Errorf("%s: %q is not valid utf-8", test.name, formatted)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before, err := New(sample("10.0.1.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := New(sample("10.0.2.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//A removed Vpc and an added one
+	after.Steps[1].Key, after.Steps[1].Name = "aws:ec2/vpc:Vpc::main", "main"
+	diff := Compare(before, after)
+	if len(diff.Added) != 1 || diff.Added[0].Name != "main" {
+		t.Errorf("expected the vpc main to be added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "vpc" {
+		t.Errorf("expected the vpc vpc to be removed, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Args["cidrBlock"] != "10.0.2.0/24" {
+		t.Errorf("expected the subnet to be changed, got %+v", diff.Changed)
+	}
+	var b strings.Builder
+	if err := diff.WriteTable(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"+  aws:ec2/vpc:Vpc", "-  aws:ec2/vpc:Vpc", "~  aws:ec2/subnet:Subnet"} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("expected the line %q in\n%s", line, b.String())
+		}
+	}
+	if diff := Compare(before, before); len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+		t.Errorf("expected no difference between a plan and itself, got %+v", diff)
+	}
+}
@@ -0,0 +1,185 @@
+package pgotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write the golden files instead of comparing them
+const UpdateGoldenEnv = "PGOTEST_UPDATE_GOLDEN"
+
+// Untaggable are the resource types that AssertAllTagged skips because AWS does not tag them
+var Untaggable = map[string]bool{
+	"aws:ec2/internetGatewayAttachment:InternetGatewayAttachment": true,
+	"aws:ec2/route:Route": true,
+	"aws:ec2/routeTableAssociation:RouteTableAssociation":       true,
+	"aws:ec2/securityGroupRule:SecurityGroupRule":               true,
+	"aws:lb/listenerCertificate:ListenerCertificate":            true,
+	"aws:lb/targetGroupAttachment:TargetGroupAttachment":        true,
+	"aws:acm/certificateValidation:CertificateValidation":       true,
+	"aws:route53/record:Record":                                 true,
+	"aws:iam/rolePolicy:RolePolicy":                             true,
+	"aws:iam/rolePolicyAttachment:RolePolicyAttachment":         true,
+	"aws:secretsmanager/secretVersion:SecretVersion":            true,
+	"aws:appautoscaling/policy:Policy":                          true,
+	"aws:appautoscaling/scheduledAction:ScheduledAction":        true,
+	"aws:ecs/clusterCapacityProviders:ClusterCapacityProviders": true,
+}
+
+// Props are the expected inputs of a resource. Only the listed inputs are compared,
+// nested Props match nested objects and numbers can be of any Go numeric type
+type Props map[string]any
+
+// Match returns the resources of a type whose inputs match the props
+func (r *Result) Match(typ string, props Props) (resources []Resource) {
+	for _, res := range r.OfType(typ) {
+		if matches(map[string]any(props), res.Inputs) {
+			resources = append(resources, res)
+		}
+	}
+	return
+}
+
+// AssertExists fails the test unless a resource of the type has inputs matching the props, like
+// AssertExists(t, "aws:lb/listener:Listener", Props{"port": 443})
+func (r *Result) AssertExists(t testing.TB, typ string, props Props) {
+	t.Helper()
+	if len(r.Match(typ, props)) == 0 {
+		t.Errorf("pgotest: no %s with %v among %d resources of this type", typ, props, len(r.OfType(typ)))
+	}
+}
+
+// AssertNotExists fails the test if a resource of the type has inputs matching the props
+func (r *Result) AssertNotExists(t testing.TB, typ string, props Props) {
+	t.Helper()
+	if found := r.Match(typ, props); len(found) > 0 {
+		t.Errorf("pgotest: unexpected %s with %v: %s", typ, props, found[0].Name)
+	}
+}
+
+// AssertCount fails the test unless there are exactly count resources of the type
+func (r *Result) AssertCount(t testing.TB, typ string, count int) {
+	t.Helper()
+	if found := len(r.OfType(typ)); found != count {
+		t.Errorf("pgotest: expected %d %s, found %d", count, typ, found)
+	}
+}
+
+// AssertAllTagged fails the test unless every taggable resource carries the tag, directly or through the default tags of its provider
+func (r *Result) AssertAllTagged(t testing.TB, key, value string) {
+	t.Helper()
+	for _, res := range r.Resources {
+		if !res.Custom || Untaggable[res.Type] || strings.HasPrefix(res.Type, "pulumi:providers:") {
+			continue
+		}
+		if got, ok := r.Tags(res)[key]; !ok || got != value {
+			t.Errorf("pgotest: %s %s is not tagged %s=%s", res.Type, res.Name, key, value)
+		}
+	}
+}
+
+// AssertGolden compares the registered resources with a golden json file.
+// When the environment variable PGOTEST_UPDATE_GOLDEN is set, the file is written instead
+func (r *Result) AssertGolden(t testing.TB, path string) {
+	t.Helper()
+	actual, err := json.MarshalIndent(r.Resources, "", "  ")
+	if err != nil {
+		t.Fatalf("pgotest: cannot marshal the resources: %v", err)
+	}
+	actual = append(actual, '\n')
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("pgotest: %v", err)
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("pgotest: %v", err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("pgotest: %v (set %s=1 to create it)", err, UpdateGoldenEnv)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("pgotest: resources differ from %s (set %s=1 to update it)\n%s", path, UpdateGoldenEnv, diff(string(expected), string(actual)))
+	}
+}
+
+// matches tells if the actual value contains the expected value
+func matches(expected, actual any) bool {
+	switch e := expected.(type) {
+	case Props:
+		return matches(map[string]any(e), actual)
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range e {
+			if !matches(v, a[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !matches(e[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if n, ok := number(expected); ok {
+		m, ok := number(actual)
+		return ok && n == m
+	}
+	if v := reflect.ValueOf(expected); v.Kind() == reflect.Slice {
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).Interface()
+		}
+		return matches(items, actual)
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+func number(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// diff returns the first lines that differ between two texts
+func diff(expected, actual string) string {
+	e, a := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	for i := 0; i < len(e) || i < len(a); i++ {
+		var el, al string
+		if i < len(e) {
+			el = e[i]
+		}
+		if i < len(a) {
+			al = a[i]
+		}
+		if el != al {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, el, al)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+// Package pgotest runs pgocomp components under Pulumi mocks, records every registered resource
+// and offers assertions and golden files to test them without an AWS account
+package pgotest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/internal/mockrun"
+)
+
+const (
+	//Project is the name of the pulumi project used by Run
+	Project = mockrun.Project
+	//Stack is the name of the pulumi stack used by Run
+	Stack = mockrun.Stack
+)
+
+// Resource is a resource registered while running a component under mocks
+type Resource = mockrun.Resource
+
+// CallFunc answers an invoke made under mocks
+type CallFunc = mockrun.CallFunc
+
+// Mocks is a pulumi.MockResourceMonitor that records the registered resources
+type Mocks = mockrun.Mocks
+
+// NewMocks returns mocks that answer the AWS invokes used by awscinfra: availability zones and hosted zone lookups
+func NewMocks() *Mocks {
+	return mockrun.NewMocks()
+}
+
+// Result is what was registered by a component run under mocks
+type Result struct {
+	Resources []Resource
+	Graph     *pgocomp.Graph
+}
+
+// Run applies the component under the default mocks and returns every registered resource
+func Run(applier pgocomp.Applier) (*Result, error) {
+	return RunWithMocks(NewMocks(), applier)
+}
+
+// RunWithMocks applies the component under the given mocks and returns every registered resource
+func RunWithMocks(mocks *Mocks, applier pgocomp.Applier) (*Result, error) {
+	resources, graph, err := mockrun.Run(mocks, applier)
+	return &Result{Resources: resources, Graph: graph}, err
+}
+
+// OfType returns the resources of a type token, like aws:lb/listener:Listener
+func (r *Result) OfType(typ string) (resources []Resource) {
+	for _, res := range r.Resources {
+		if res.Type == typ {
+			resources = append(resources, res)
+		}
+	}
+	return
+}
+
+// Find returns the resource of a type with a name
+func (r *Result) Find(typ, name string) (Resource, bool) {
+	for _, res := range r.Resources {
+		if res.Type == typ && res.Name == name {
+			return res, true
+		}
+	}
+	return Resource{}, false
+}
+
+// ByURN returns the resource with an URN
+func (r *Result) ByURN(urn string) (Resource, bool) {
+	for _, res := range r.Resources {
+		if res.URN == urn {
+			return res, true
+		}
+	}
+	return Resource{}, false
+}
+
+// Tags returns the tags of a resource merged with the default tags of its provider
+func (r *Result) Tags(res Resource) map[string]string {
+	var defaults, tags map[string]string
+	if provider, ok := r.ByURN(providerURN(res.Provider)); ok {
+		if defaultTags, ok := provider.Inputs["defaultTags"].(map[string]any); ok {
+			defaults = stringMap(defaultTags["tags"])
+		}
+	}
+	tags = stringMap(res.Inputs["tags"])
+	return pgocomp.MergeTags(defaults, tags)
+}
+
+// providerURN removes the id from a provider reference (urn::id)
+func providerURN(reference string) string {
+	if i := strings.LastIndex(reference, "::"); i >= 0 {
+		return reference[:i]
+	}
+	return reference
+}
+
+func stringMap(value any) map[string]string {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	tags := make(map[string]string, len(m))
+	for k, v := range m {
+		tags[k] = fmt.Sprint(v)
+	}
+	return tags
+}
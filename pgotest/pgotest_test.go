@@ -0,0 +1,198 @@
+package pgotest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fpco-internal/pgocomp"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// recorder is a testing.TB that records the failures of the assertions instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// sample creates a provider with default tags, a Vpc tagged by its args and a subnet without tags
+func sample() pgocomp.Applier {
+	return pgocomp.NewComponentWithMeta(pgocomp.Meta{Name: "sample"}, func(ctx *pulumi.Context, name string) (*ec2.Subnet, error) {
+		provider, err := aws.NewProvider(ctx, "aws", &aws.ProviderArgs{
+			Region:      pulumi.String("us-east-1"),
+			DefaultTags: aws.ProviderDefaultTagsArgs{Tags: pulumi.StringMap{"team": pulumi.String("infra"), "env": pulumi.String("dev")}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		vpc, err := ec2.NewVpc(ctx, "vpc", &ec2.VpcArgs{
+			CidrBlock: pulumi.String("10.0.0.0/16"),
+			Tags:      pulumi.StringMap{"env": pulumi.String("prod")},
+		}, pulumi.Provider(provider))
+		if err != nil {
+			return nil, err
+		}
+		return ec2.NewSubnet(ctx, "subnet", &ec2.SubnetArgs{
+			VpcId:     vpc.ID(),
+			CidrBlock: pulumi.String("10.0.1.0/24"),
+		}, pulumi.Provider(provider))
+	})
+}
+
+func TestRun(t *testing.T) {
+	result, err := Run(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpc, ok := result.Find("aws:ec2/vpc:Vpc", "vpc")
+	if !ok {
+		t.Fatal("vpc not found")
+	}
+	if !vpc.Custom || vpc.Inputs["cidrBlock"] != "10.0.0.0/16" {
+		t.Errorf("unexpected vpc %+v", vpc)
+	}
+	if found, ok := result.ByURN(vpc.URN); !ok || found.Name != "vpc" {
+		t.Errorf("vpc not found by its urn %s", vpc.URN)
+	}
+	if _, ok := result.Find("aws:ec2/vpc:Vpc", "subnet"); ok {
+		t.Error("unexpected vpc named subnet")
+	}
+	if subnets := result.OfType("aws:ec2/subnet:Subnet"); len(subnets) != 1 || subnets[0].Inputs["vpcId"] != "vpc_id" {
+		t.Errorf("unexpected subnets %+v", subnets)
+	}
+	if !strings.HasSuffix(providerURN(vpc.Provider), "::aws") {
+		t.Errorf("unexpected provider %s", vpc.Provider)
+	}
+}
+
+func TestTags(t *testing.T) {
+	result, err := Run(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		typ, name string
+		expected  map[string]string
+	}{
+		{"aws:ec2/vpc:Vpc", "vpc", map[string]string{"team": "infra", "env": "prod"}},
+		{"aws:ec2/subnet:Subnet", "subnet", map[string]string{"team": "infra", "env": "dev"}},
+	}
+	for _, test := range tests {
+		res, _ := result.Find(test.typ, test.name)
+		if tags := result.Tags(res); !reflect.DeepEqual(tags, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, tags)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	actual := map[string]any{
+		"port":     float64(443),
+		"protocol": "HTTPS",
+		"tags":     map[string]any{"env": "prod", "team": "infra"},
+		"actions":  []any{map[string]any{"type": "forward", "order": float64(1)}},
+	}
+	tests := []struct {
+		name     string
+		expected Props
+		matches  bool
+	}{
+		{"empty", Props{}, true},
+		{"int and float", Props{"port": 443}, true},
+		{"int64", Props{"port": int64(443)}, true},
+		{"other number", Props{"port": 80}, false},
+		{"string", Props{"protocol": "HTTPS"}, true},
+		{"missing", Props{"certificateArn": "arn"}, false},
+		{"nested subset", Props{"tags": Props{"env": "prod"}}, true},
+		{"nested map", Props{"tags": map[string]any{"env": "dev"}}, false},
+		{"list", Props{"actions": []Props{{"type": "forward"}}}, true},
+		{"list length", Props{"actions": []Props{{"type": "forward"}, {"type": "redirect"}}}, false},
+		{"not a list", Props{"protocol": []string{"HTTPS"}}, false},
+	}
+	for _, test := range tests {
+		if matches(test.expected, actual) != test.matches {
+			t.Errorf("%s: expected the match to be %v", test.name, test.matches)
+		}
+	}
+}
+
+func TestAsserts(t *testing.T) {
+	result, err := Run(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		assert func(t testing.TB)
+		errors int
+	}{
+		{"exists", func(t testing.TB) { result.AssertExists(t, "aws:ec2/vpc:Vpc", Props{"cidrBlock": "10.0.0.0/16"}) }, 0},
+		{"does not exist", func(t testing.TB) { result.AssertExists(t, "aws:ec2/vpc:Vpc", Props{"cidrBlock": "10.1.0.0/16"}) }, 1},
+		{"not exists", func(t testing.TB) { result.AssertNotExists(t, "aws:ec2/vpc:Vpc", Props{"cidrBlock": "10.1.0.0/16"}) }, 0},
+		{"unexpectedly exists", func(t testing.TB) { result.AssertNotExists(t, "aws:ec2/subnet:Subnet", Props{}) }, 1},
+		{"count", func(t testing.TB) { result.AssertCount(t, "aws:ec2/subnet:Subnet", 1) }, 0},
+		{"wrong count", func(t testing.TB) { result.AssertCount(t, "aws:ec2/subnet:Subnet", 2) }, 1},
+		{"tagged by the provider", func(t testing.TB) { result.AssertAllTagged(t, "team", "infra") }, 0},
+		{"tagged with another value", func(t testing.TB) { result.AssertAllTagged(t, "env", "prod") }, 1},
+		{"not tagged", func(t testing.TB) { result.AssertAllTagged(t, "app", "web") }, 2},
+	}
+	for _, test := range tests {
+		r := &recorder{TB: t}
+		test.assert(r)
+		if len(r.errors) != test.errors {
+			t.Errorf("%s: expected %d error(s), got %v", test.name, test.errors, r.errors)
+		}
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	result, err := Run(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "testdata", "sample.golden")
+
+	t.Setenv(UpdateGoldenEnv, "1")
+	result.AssertGolden(t, path)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("the golden file was not written: %v", err)
+	}
+
+	t.Setenv(UpdateGoldenEnv, "")
+	r := &recorder{TB: t}
+	result.AssertGolden(r, path)
+	if len(r.errors) > 0 {
+		t.Errorf("expected the resources to match the golden file, got %v", r.errors)
+	}
+
+	result.Resources[0].Name = "renamed"
+	r = &recorder{TB: t}
+	result.AssertGolden(r, path)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], `+     "name": "renamed",`) {
+		t.Errorf("expected a diff of the name, got %v", r.errors)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if d := diff("a\nb\nc", "a\nb\nc"); d != "" {
+		t.Errorf("expected no diff, got %q", d)
+	}
+	if d := diff("a\nb", "a\nc\nd"); d != "line 2:\n- b\n+ c" {
+		t.Errorf("unexpected diff %q", d)
+	}
+	if d := diff("a", "a\nb"); d != "line 2:\n- \n+ b" {
+		t.Errorf("unexpected diff %q", d)
+	}
+}
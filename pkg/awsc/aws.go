@@ -1,14 +1,25 @@
 package awsc
 
 import (
+	"sort"
+
 	"github.com/fpco-internal/pgocomp"
 
 	ecsn "github.com/pulumi/pulumi-aws-native/sdk/go/aws/ecs"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/acm"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/appautoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/autoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/codedeploy"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/route53"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/servicediscovery"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ssm"
 	ecsx "github.com/pulumi/pulumi-awsx/sdk/go/awsx/ecs"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -23,33 +34,93 @@ func ToIDStringArray[T pulumi.CustomResourceState](comps ...*pulumi.CustomResour
 	return ids
 }
 
-// NewProvider is a wrapper to the aws.NewProvider function
+// withTags merges the tags of the meta with the tags already set in the args. The tags of the args win when a key repeats
+func withTags(tags pulumi.StringMapInput, meta pgocomp.Meta) pulumi.StringMapInput {
+	if len(meta.Tags) == 0 {
+		return tags
+	}
+	if tags == nil {
+		return pulumi.ToStringMap(meta.Tags)
+	}
+	if plain, ok := tags.(pulumi.StringMap); ok {
+		merged := pulumi.ToStringMap(meta.Tags)
+		for k, v := range plain {
+			merged[k] = v
+		}
+		return merged
+	}
+	return tags.ToStringMapOutput().ApplyT(func(explicit map[string]string) map[string]string {
+		return pgocomp.MergeTags(meta.Tags, explicit)
+	}).(pulumi.StringMapOutput)
+}
+
+// missingTagKeys returns the sorted keys of the tags of the meta that are not already set
+func missingTagKeys(meta pgocomp.Meta, set map[string]bool) []string {
+	var keys []string
+	for key := range meta.Tags {
+		if !set[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orEmpty returns a copy of the args, or empty args when they are nil, so the wrappers never change the args of the caller
+func orEmpty[A any](args *A) *A {
+	if args == nil {
+		return new(A)
+	}
+	copied := *args
+	return &copied
+}
+
+// NewProvider is a wrapper to the aws.NewProvider function. The tags of the meta become the default tags of the provider
 func NewProvider(meta pgocomp.Meta, args *aws.ProviderArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*aws.Provider] {
+	args = orEmpty(args)
+	if args.DefaultTags == nil && len(meta.Tags) > 0 {
+		args.DefaultTags = aws.ProviderDefaultTagsArgs{Tags: pulumi.ToStringMap(meta.Tags)}
+	}
 	return pgocomp.NewPulumiComponentWithMeta(aws.NewProvider, meta, args, opts...)
 }
 
 // NewVpc is a wrapper to the ec2.NewVpc function
 func NewVpc(meta pgocomp.Meta, args *ec2.VpcArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.Vpc] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ec2.NewVpc, meta, args, opts...)
 }
 
 // NewListener is a wrapper to the lb.NewListener
 func NewListener(meta pgocomp.Meta, args *lb.ListenerArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*lb.Listener] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(lb.NewListener, meta, args, opts...)
 }
 
+// NewListenerCertificate is a wrapper to the lb.NewListenerCertificate
+func NewListenerCertificate(meta pgocomp.Meta, args *lb.ListenerCertificateArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*lb.ListenerCertificate] {
+	return pgocomp.NewPulumiComponentWithMeta(lb.NewListenerCertificate, meta, args, opts...)
+}
+
 // NewLoadBalancer is a wrapper to the lb.NewLoadBalancer
 func NewLoadBalancer(meta pgocomp.Meta, args *lb.LoadBalancerArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*lb.LoadBalancer] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(lb.NewLoadBalancer, meta, args, opts...)
 }
 
 // NewTargetGroup is a wrapper to the lb.NewTargetGroup
 func NewTargetGroup(meta pgocomp.Meta, args *lb.TargetGroupArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*lb.TargetGroup] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(lb.NewTargetGroup, meta, args, opts...)
 }
 
 // NewSecurityGroup is a wrapper to the ec2.NewSecurityGroup
 func NewSecurityGroup(meta pgocomp.Meta, args *ec2.SecurityGroupArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.SecurityGroup] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ec2.NewSecurityGroup, meta, args, opts...)
 }
 
@@ -60,11 +131,15 @@ func NewSecurityGroupRule(meta pgocomp.Meta, args *ec2.SecurityGroupRuleArgs, op
 
 // NewSubnet is a wrapper to the ec2.NewSubnet function
 func NewSubnet(meta pgocomp.Meta, args *ec2.SubnetArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.Subnet] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ec2.NewSubnet, meta, args, opts...)
 }
 
 // NewInternetGateway is a wrapper to the ec2.NewInternetGateway function
 func NewInternetGateway(meta pgocomp.Meta, args *ec2.InternetGatewayArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.InternetGateway] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ec2.NewInternetGateway, meta, args, opts...)
 }
 
@@ -80,41 +155,149 @@ func NewRoute(meta pgocomp.Meta, args *ec2.RouteArgs, opts ...pulumi.ResourceOpt
 
 // NewRouteTable is a wrapper to the ec2.NewRouteTable function
 func NewRouteTable(meta pgocomp.Meta, args *ec2.RouteTableArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.RouteTable] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ec2.NewRouteTable, meta, args, opts...)
 }
 
+// NewEip is a wrapper to the ec2.NewEip function
+func NewEip(meta pgocomp.Meta, args *ec2.EipArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.Eip] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(ec2.NewEip, meta, args, opts...)
+}
+
+// NewNatGateway is a wrapper to the ec2.NewNatGateway function
+func NewNatGateway(meta pgocomp.Meta, args *ec2.NatGatewayArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.NatGateway] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(ec2.NewNatGateway, meta, args, opts...)
+}
+
 // NewCluster is a wrapper to the ec2.NewCluster function
 func NewCluster(meta pgocomp.Meta, args *ecs.ClusterArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecs.Cluster] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ecs.NewCluster, meta, args, opts...)
 }
 
 // NewCapacityProvider is a wrapper to the ec2.NewCapacityProvider function
 func NewCapacityProvider(meta pgocomp.Meta, args *ecs.CapacityProviderArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecs.CapacityProvider] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ecs.NewCapacityProvider, meta, args, opts...)
 }
 
+// NewClusterCapacityProviders is a wrapper to the ecs.NewClusterCapacityProviders function
+func NewClusterCapacityProviders(meta pgocomp.Meta, args *ecs.ClusterCapacityProvidersArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecs.ClusterCapacityProviders] {
+	return pgocomp.NewPulumiComponentWithMeta(ecs.NewClusterCapacityProviders, meta, args, opts...)
+}
+
+// NewLaunchTemplate is a wrapper to the ec2.NewLaunchTemplate function
+func NewLaunchTemplate(meta pgocomp.Meta, args *ec2.LaunchTemplateArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.LaunchTemplate] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(ec2.NewLaunchTemplate, meta, args, opts...)
+}
+
+// NewAutoScalingGroup is a wrapper to the autoscaling.NewGroup function. The tags of the meta are added to the tags
+// of the group and propagated to its instances. The tags of the args win when a key repeats
+func NewAutoScalingGroup(meta pgocomp.Meta, args *autoscaling.GroupArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*autoscaling.Group] {
+	args = orEmpty(args)
+	explicit, _ := args.Tags.(autoscaling.GroupTagArray)
+	tags := append(autoscaling.GroupTagArray(nil), explicit...)
+	set := make(map[string]bool)
+	for _, tag := range explicit {
+		if tag, ok := tag.(autoscaling.GroupTagArgs); ok {
+			if key, ok := tag.Key.(pulumi.String); ok {
+				set[string(key)] = true
+			}
+		}
+	}
+	for _, key := range missingTagKeys(meta, set) {
+		tags = append(tags, autoscaling.GroupTagArgs{
+			Key:               pulumi.String(key),
+			Value:             pulumi.String(meta.Tags[key]),
+			PropagateAtLaunch: pulumi.Bool(true),
+		})
+	}
+	if len(tags) > 0 {
+		args.Tags = tags
+	}
+	return pgocomp.NewPulumiComponentWithMeta(autoscaling.NewGroup, meta, args, opts...)
+}
+
+// NewInstanceProfile is a wrapper to the iam.NewInstanceProfile function
+func NewInstanceProfile(meta pgocomp.Meta, args *iam.InstanceProfileArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*iam.InstanceProfile] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(iam.NewInstanceProfile, meta, args, opts...)
+}
+
 // NewECSService is a wrapper to the ec2.NewService function
 func NewECSService(meta pgocomp.Meta, args *ecs.ServiceArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecs.Service] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ecs.NewService, meta, args, opts...)
 }
 
-// NewECSNativeService is a wrapper to the ecsn.NewECSNativeService function
+// NewECSNativeService is a wrapper to the ecsn.NewECSNativeService function. The tags of the meta are added to the tags
+// of the service. The tags of the args win when a key repeats
 func NewECSNativeService(meta pgocomp.Meta, args *ecsn.ServiceArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecsn.Service] {
+	args = orEmpty(args)
+	explicit, _ := args.Tags.(ecsn.ServiceTagArray)
+	tags := append(ecsn.ServiceTagArray(nil), explicit...)
+	set := make(map[string]bool)
+	for _, tag := range explicit {
+		if tag, ok := tag.(ecsn.ServiceTagArgs); ok {
+			if key, ok := tag.Key.(pulumi.String); ok {
+				set[string(key)] = true
+			}
+		}
+	}
+	for _, key := range missingTagKeys(meta, set) {
+		tags = append(tags, ecsn.ServiceTagArgs{Key: pulumi.String(key), Value: pulumi.String(meta.Tags[key])})
+	}
+	if len(tags) > 0 {
+		args.Tags = tags
+	}
 	return pgocomp.NewPulumiComponentWithMeta(ecsn.NewService, meta, args, opts...)
 }
 
 // NewFargateService is a wrapper to the ec2.NewService function
 func NewFargateService(meta pgocomp.Meta, args *ecsx.FargateServiceArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecsx.FargateService] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ecsx.NewFargateService, meta, args, opts...)
 }
 
-// NewEcsNativeTaskDefinition is a wrapper to the ec2.NewService function
+// NewEcsNativeTaskDefinition is a wrapper to the ecsn.NewTaskDefinition function. The tags of the meta are added to the tags
+// of the task definition. The tags of the args win when a key repeats
 func NewEcsNativeTaskDefinition(meta pgocomp.Meta, args *ecsn.TaskDefinitionArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecsn.TaskDefinition] {
+	args = orEmpty(args)
+	explicit, _ := args.Tags.(ecsn.TaskDefinitionTagArray)
+	tags := append(ecsn.TaskDefinitionTagArray(nil), explicit...)
+	set := make(map[string]bool)
+	for _, tag := range explicit {
+		if tag, ok := tag.(ecsn.TaskDefinitionTagArgs); ok {
+			if key, ok := tag.Key.(pulumi.String); ok {
+				set[string(key)] = true
+			}
+		}
+	}
+	for _, key := range missingTagKeys(meta, set) {
+		tags = append(tags, ecsn.TaskDefinitionTagArgs{Key: pulumi.String(key), Value: pulumi.String(meta.Tags[key])})
+	}
+	if len(tags) > 0 {
+		args.Tags = tags
+	}
 	return pgocomp.NewPulumiComponentWithMeta(ecsn.NewTaskDefinition, meta, args, opts...)
 }
 
 // NewEcsTaskDefinition is a wrapper to the ec2.NewService function
 func NewEcsTaskDefinition(meta pgocomp.Meta, args *ecs.TaskDefinitionArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ecs.TaskDefinition] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(ecs.NewTaskDefinition, meta, args, opts...)
 }
 
@@ -125,10 +308,145 @@ func NewRouteTableAssociation(meta pgocomp.Meta, args *ec2.RouteTableAssociation
 
 // NewCertificate is a wrapped to create a new Acm certificate
 func NewCertificate(meta pgocomp.Meta, args *acm.CertificateArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*acm.Certificate] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(acm.NewCertificate, meta, args, opts...)
 }
 
+// NewCertificateValidation is a wrapper to the acm.NewCertificateValidation
+func NewCertificateValidation(meta pgocomp.Meta, args *acm.CertificateValidationArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*acm.CertificateValidation] {
+	return pgocomp.NewPulumiComponentWithMeta(acm.NewCertificateValidation, meta, args, opts...)
+}
+
+// NewZone is a wrapper to the route53.NewZone
+func NewZone(meta pgocomp.Meta, args *route53.ZoneArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*route53.Zone] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(route53.NewZone, meta, args, opts...)
+}
+
+// NewRecord is a wrapper to the route53.NewRecord
+func NewRecord(meta pgocomp.Meta, args *route53.RecordArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*route53.Record] {
+	return pgocomp.NewPulumiComponentWithMeta(route53.NewRecord, meta, args, opts...)
+}
+
+// NewTargetGroupAttachment is a wrapper to the lb.NewTargetGroupAttachment
+func NewTargetGroupAttachment(meta pgocomp.Meta, args *lb.TargetGroupAttachmentArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*lb.TargetGroupAttachment] {
+	return pgocomp.NewPulumiComponentWithMeta(lb.NewTargetGroupAttachment, meta, args, opts...)
+}
+
+// NewVpcEndpointService is a wrapper to the ec2.NewVpcEndpointService
+func NewVpcEndpointService(meta pgocomp.Meta, args *ec2.VpcEndpointServiceArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.VpcEndpointService] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(ec2.NewVpcEndpointService, meta, args, opts...)
+}
+
+// NewVpcEndpoint is a wrapper to the ec2.NewVpcEndpoint
+func NewVpcEndpoint(meta pgocomp.Meta, args *ec2.VpcEndpointArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ec2.VpcEndpoint] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(ec2.NewVpcEndpoint, meta, args, opts...)
+}
+
+// NewLogGroup is a wrapper to the cloudwatch.NewLogGroup
+func NewLogGroup(meta pgocomp.Meta, args *cloudwatch.LogGroupArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*cloudwatch.LogGroup] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(cloudwatch.NewLogGroup, meta, args, opts...)
+}
+
+// NewSecret is a wrapper to the secretsmanager.NewSecret
+func NewSecret(meta pgocomp.Meta, args *secretsmanager.SecretArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*secretsmanager.Secret] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(secretsmanager.NewSecret, meta, args, opts...)
+}
+
+// NewSecretVersion is a wrapper to the secretsmanager.NewSecretVersion
+func NewSecretVersion(meta pgocomp.Meta, args *secretsmanager.SecretVersionArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*secretsmanager.SecretVersion] {
+	return pgocomp.NewPulumiComponentWithMeta(secretsmanager.NewSecretVersion, meta, args, opts...)
+}
+
+// NewParameter is a wrapper to the ssm.NewParameter
+func NewParameter(meta pgocomp.Meta, args *ssm.ParameterArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*ssm.Parameter] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(ssm.NewParameter, meta, args, opts...)
+}
+
+// NewPrivateDNSNamespace is a wrapper to the servicediscovery.NewPrivateDnsNamespace
+func NewPrivateDNSNamespace(meta pgocomp.Meta, args *servicediscovery.PrivateDnsNamespaceArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*servicediscovery.PrivateDnsNamespace] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(servicediscovery.NewPrivateDnsNamespace, meta, args, opts...)
+}
+
+// NewDiscoveryService is a wrapper to the servicediscovery.NewService
+func NewDiscoveryService(meta pgocomp.Meta, args *servicediscovery.ServiceArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*servicediscovery.Service] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(servicediscovery.NewService, meta, args, opts...)
+}
+
+// NewCodeDeployApplication is a wrapper to the codedeploy.NewApplication
+func NewCodeDeployApplication(meta pgocomp.Meta, args *codedeploy.ApplicationArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*codedeploy.Application] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(codedeploy.NewApplication, meta, args, opts...)
+}
+
+// NewDeploymentGroup is a wrapper to the codedeploy.NewDeploymentGroup
+func NewDeploymentGroup(meta pgocomp.Meta, args *codedeploy.DeploymentGroupArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*codedeploy.DeploymentGroup] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(codedeploy.NewDeploymentGroup, meta, args, opts...)
+}
+
+// NewScalingTarget is a wrapper to the appautoscaling.NewTarget
+func NewScalingTarget(meta pgocomp.Meta, args *appautoscaling.TargetArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*appautoscaling.Target] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(appautoscaling.NewTarget, meta, args, opts...)
+}
+
+// NewScalingPolicy is a wrapper to the appautoscaling.NewPolicy
+func NewScalingPolicy(meta pgocomp.Meta, args *appautoscaling.PolicyArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*appautoscaling.Policy] {
+	return pgocomp.NewPulumiComponentWithMeta(appautoscaling.NewPolicy, meta, args, opts...)
+}
+
+// NewScheduledAction is a wrapper to the appautoscaling.NewScheduledAction
+func NewScheduledAction(meta pgocomp.Meta, args *appautoscaling.ScheduledActionArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*appautoscaling.ScheduledAction] {
+	return pgocomp.NewPulumiComponentWithMeta(appautoscaling.NewScheduledAction, meta, args, opts...)
+}
+
+// NewMetricAlarm is a wrapper to the cloudwatch.NewMetricAlarm
+func NewMetricAlarm(meta pgocomp.Meta, args *cloudwatch.MetricAlarmArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*cloudwatch.MetricAlarm] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(cloudwatch.NewMetricAlarm, meta, args, opts...)
+}
+
+// NewRole is a wrapper to the iam.NewRole
+func NewRole(meta pgocomp.Meta, args *iam.RoleArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*iam.Role] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
+	return pgocomp.NewPulumiComponentWithMeta(iam.NewRole, meta, args, opts...)
+}
+
+// NewRolePolicy is a wrapper to the iam.NewRolePolicy
+func NewRolePolicy(meta pgocomp.Meta, args *iam.RolePolicyArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*iam.RolePolicy] {
+	return pgocomp.NewPulumiComponentWithMeta(iam.NewRolePolicy, meta, args, opts...)
+}
+
+// NewRolePolicyAttachment is a wrapper to the iam.NewRolePolicyAttachment
+func NewRolePolicyAttachment(meta pgocomp.Meta, args *iam.RolePolicyAttachmentArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*iam.RolePolicyAttachment] {
+	return pgocomp.NewPulumiComponentWithMeta(iam.NewRolePolicyAttachment, meta, args, opts...)
+}
+
 // NewListenerRule add a new rule to the listener
 func NewListenerRule(meta pgocomp.Meta, args *lb.ListenerRuleArgs, opts ...pulumi.ResourceOption) *pgocomp.ComponentWithMeta[*lb.ListenerRule] {
+	args = orEmpty(args)
+	args.Tags = withTags(args.Tags, meta)
 	return pgocomp.NewPulumiComponentWithMeta(lb.NewListenerRule, meta, args, opts...)
 }
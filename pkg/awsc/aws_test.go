@@ -0,0 +1,142 @@
+package awsc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pgotest"
+
+	ecsn "github.com/pulumi/pulumi-aws-native/sdk/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/autoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	ecsx "github.com/pulumi/pulumi-awsx/sdk/go/awsx/ecs"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func TestWithTags(t *testing.T) {
+	meta := pgocomp.Meta{Tags: map[string]string{"team": "web", "env": "prod"}}
+	tests := []struct {
+		name     string
+		tags     pulumi.StringMapInput
+		meta     pgocomp.Meta
+		expected pulumi.StringMapInput
+	}{
+		{name: "no tags", meta: pgocomp.Meta{}},
+		{name: "only the args", tags: pulumi.StringMap{"a": pulumi.String("b")}, expected: pulumi.StringMap{"a": pulumi.String("b")}},
+		{name: "only the meta", meta: meta, expected: pulumi.StringMap{"team": pulumi.String("web"), "env": pulumi.String("prod")}},
+		{
+			name:     "the args win",
+			tags:     pulumi.StringMap{"env": pulumi.String("dev"), "a": pulumi.String("b")},
+			meta:     meta,
+			expected: pulumi.StringMap{"team": pulumi.String("web"), "env": pulumi.String("dev"), "a": pulumi.String("b")},
+		},
+	}
+	for _, test := range tests {
+		if tags := withTags(test.tags, test.meta); !reflect.DeepEqual(tags, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, tags)
+		}
+	}
+}
+
+func TestWrappersKeepTheArgs(t *testing.T) {
+	args := &ec2.VpcArgs{CidrBlock: pulumi.String("10.0.0.0/16")}
+	NewVpc(pgocomp.Meta{Name: "vpc", Tags: map[string]string{"team": "web"}}, args)
+	if args.Tags != nil {
+		t.Fatalf("the wrapper changed the tags of the args to %v", args.Tags)
+	}
+}
+
+func TestAutoScalingGroupTags(t *testing.T) {
+	meta := pgocomp.Meta{Name: "asg", Tags: map[string]string{"team": "web", "AmazonECSManaged": "false"}}
+	args := &autoscaling.GroupArgs{
+		MinSize: pulumi.Int(0),
+		MaxSize: pulumi.Int(1),
+		Tags: autoscaling.GroupTagArray{autoscaling.GroupTagArgs{
+			Key:               pulumi.String("AmazonECSManaged"),
+			Value:             pulumi.String("true"),
+			PropagateAtLaunch: pulumi.Bool(true),
+		}},
+	}
+	result, err := pgotest.Run(NewAutoScalingGroup(meta, args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, ok := result.Find("aws:autoscaling/group:Group", "asg")
+	if !ok {
+		t.Fatal("the group was not registered")
+	}
+	tags := make(map[string]string)
+	for _, tag := range group.Inputs["tags"].([]any) {
+		tag := tag.(map[string]any)
+		key := tag["key"].(string)
+		if _, ok := tags[key]; ok {
+			t.Fatalf("the tag %s repeats", key)
+		}
+		tags[key] = tag["value"].(string)
+	}
+	if expected := map[string]string{"team": "web", "AmazonECSManaged": "true"}; !reflect.DeepEqual(tags, expected) {
+		t.Fatalf("expected the tags %v, got %v", expected, tags)
+	}
+	if len(args.Tags.(autoscaling.GroupTagArray)) != 1 {
+		t.Fatalf("the wrapper changed the tags of the args to %v", args.Tags)
+	}
+}
+
+func TestECSWrapperTags(t *testing.T) {
+	meta := pgocomp.Meta{Tags: map[string]string{"team": "web", "env": "prod"}}
+	named := func(name string) pgocomp.Meta {
+		m := meta
+		m.Name = name
+		return m
+	}
+	serviceArgs := &ecsn.ServiceArgs{Tags: ecsn.ServiceTagArray{ecsn.ServiceTagArgs{Key: pulumi.String("env"), Value: pulumi.String("dev")}}}
+	result, err := pgotest.Run(pgocomp.ApplierFunc(func(ctx *pulumi.Context) error {
+		return pgocomp.ApplyAll(ctx,
+			NewECSNativeService(named("native"), serviceArgs),
+			NewEcsNativeTaskDefinition(named("task"), nil),
+			NewFargateService(named("fargate"), &ecsx.FargateServiceArgs{Tags: pulumi.StringMap{"env": pulumi.String("dev")}}),
+		)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		token    string
+		name     string
+		expected map[string]string
+	}{
+		{"aws-native:ecs:Service", "native", map[string]string{"team": "web", "env": "dev"}},
+		{"aws-native:ecs:TaskDefinition", "task", map[string]string{"team": "web", "env": "prod"}},
+		{"awsx:ecs:FargateService", "fargate", map[string]string{"team": "web", "env": "dev"}},
+	}
+	for _, test := range tests {
+		resource, ok := result.Find(test.token, test.name)
+		if !ok {
+			t.Errorf("the resource %s was not registered", test.name)
+			continue
+		}
+		tags := make(map[string]string)
+		switch inputs := resource.Inputs["tags"].(type) {
+		case []any:
+			for _, tag := range inputs {
+				tag := tag.(map[string]any)
+				key := tag["key"].(string)
+				if _, ok := tags[key]; ok {
+					t.Errorf("%s: the tag %s repeats", test.name, key)
+				}
+				tags[key] = tag["value"].(string)
+			}
+		case map[string]any:
+			for key, value := range inputs {
+				tags[key] = value.(string)
+			}
+		}
+		if !reflect.DeepEqual(tags, test.expected) {
+			t.Errorf("%s: expected the tags %v, got %v", test.name, test.expected, tags)
+		}
+	}
+	if len(serviceArgs.Tags.(ecsn.ServiceTagArray)) != 1 {
+		t.Fatalf("the wrapper changed the tags of the args to %v", serviceArgs.Tags)
+	}
+}
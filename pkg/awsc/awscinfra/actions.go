@@ -0,0 +1,236 @@
+package awscinfra
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// forwardTo returns an action that forwards every request to one target group
+func forwardTo(lookupName string) LBActionParameters {
+	return LBActionParameters{
+		Type:    ActionForward,
+		Forward: LBForwardAction{TargetGroups: []LBWeightedTargetGroup{{TargetGroupLookupName: lookupName}}},
+	}
+}
+
+// actions returns the actions of the listener. Without Actions, it redirects to HTTPS or forwards to TargetGroupLookupName
+func (p *LBListenerParameters) actions() []LBActionParameters {
+	switch {
+	case len(p.Actions) > 0:
+		return p.Actions
+	case p.RedirectToHTTPS:
+		return []LBActionParameters{{
+			Type:     ActionRedirect,
+			Redirect: LBRedirectAction{Port: "443", Protocol: string(HTTPS), Permanent: true},
+		}}
+	default:
+		return []LBActionParameters{forwardTo(p.TargetGroupLookupName)}
+	}
+}
+
+// actions returns the actions of the rule. Without Actions, it forwards to TargetGroupLookupName
+func (p *LBRuleParameters) actions() []LBActionParameters {
+	if len(p.Actions) > 0 {
+		return p.Actions
+	}
+	return []LBActionParameters{forwardTo(p.TargetGroupLookupName)}
+}
+
+// targetGroupLookupNames returns the target groups the listener and its rules forward to, once each
+func (p *LBListenerParameters) targetGroupLookupNames() (names []string) {
+	actions := append([]LBActionParameters{}, p.actions()...)
+	for _, rule := range p.Rules {
+		actions = append(actions, rule.actions()...)
+	}
+	seen := make(map[string]bool)
+	for _, action := range actions {
+		for _, name := range action.lookupNames() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return
+}
+
+// lookupNames returns the lookup names of the target groups used by the action
+func (a *LBActionParameters) lookupNames() (names []string) {
+	if a.Type != ActionForward {
+		return nil
+	}
+	for _, tg := range a.Forward.TargetGroups {
+		names = append(names, tg.TargetGroupLookupName)
+	}
+	return
+}
+
+// ListenerDefaultActions returns the default actions of a listener. Actions are ordered as they are listed
+func ListenerDefaultActions(actions []LBActionParameters, tgs map[string]*lb.TargetGroup) (array lb.ListenerDefaultActionArray, err error) {
+	for i, action := range actions {
+		args := lb.ListenerDefaultActionArgs{
+			Type:  pulumi.String(action.Type),
+			Order: actionOrder(i, len(actions)),
+		}
+		switch action.Type {
+		case ActionForward:
+			if len(action.Forward.TargetGroups) == 1 && action.Forward.StickinessDuration == 0 {
+				if args.TargetGroupArn, err = targetGroupID(tgs, action.Forward.TargetGroups[0].TargetGroupLookupName); err != nil {
+					return
+				}
+				break
+			}
+			var targetGroups lb.ListenerDefaultActionForwardTargetGroupArray
+			for _, wtg := range action.Forward.TargetGroups {
+				tg := lb.ListenerDefaultActionForwardTargetGroupArgs{}
+				if tg.Arn, err = targetGroupID(tgs, wtg.TargetGroupLookupName); err != nil {
+					return
+				}
+				if len(action.Forward.TargetGroups) > 1 {
+					tg.Weight = pulumi.Int(wtg.Weight)
+				}
+				targetGroups = append(targetGroups, tg)
+			}
+			forward := lb.ListenerDefaultActionForwardArgs{TargetGroups: targetGroups}
+			if action.Forward.StickinessDuration > 0 {
+				forward.Stickiness = lb.ListenerDefaultActionForwardStickinessArgs{
+					Enabled:  pulumi.Bool(true),
+					Duration: pulumi.Int(action.Forward.StickinessDuration),
+				}
+			}
+			args.Forward = forward
+		case ActionFixedResponse:
+			args.FixedResponse = lb.ListenerDefaultActionFixedResponseArgs{
+				ContentType: pulumi.String(valueOrDefault(action.FixedResponse.ContentType, "text/plain")),
+				MessageBody: optionalString(action.FixedResponse.MessageBody),
+				StatusCode:  pulumi.String(strconv.Itoa(action.FixedResponse.StatusCode)),
+			}
+		case ActionRedirect:
+			args.Redirect = lb.ListenerDefaultActionRedirectArgs{
+				Protocol:   optionalString(action.Redirect.Protocol),
+				Host:       optionalString(action.Redirect.Host),
+				Port:       optionalString(action.Redirect.Port),
+				Path:       optionalString(action.Redirect.Path),
+				Query:      optionalString(action.Redirect.Query),
+				StatusCode: pulumi.String(redirectStatusCode(action.Redirect.Permanent)),
+			}
+		case ActionAuthenticateOidc:
+			oidc := action.AuthenticateOidc
+			if oidc.ClientSecret == nil {
+				return nil, fmt.Errorf("the OIDC action %d has no client secret", i)
+			}
+			args.AuthenticateOidc = lb.ListenerDefaultActionAuthenticateOidcArgs{
+				Issuer:                   pulumi.String(oidc.Issuer),
+				AuthorizationEndpoint:    pulumi.String(oidc.AuthorizationEndpoint),
+				TokenEndpoint:            pulumi.String(oidc.TokenEndpoint),
+				UserInfoEndpoint:         pulumi.String(oidc.UserInfoEndpoint),
+				ClientId:                 pulumi.String(oidc.ClientID),
+				ClientSecret:             pulumi.ToSecret(oidc.ClientSecret.ToStringOutput()).(pulumi.StringOutput),
+				Scope:                    optionalString(oidc.Scope),
+				OnUnauthenticatedRequest: optionalString(oidc.OnUnauthenticatedRequest),
+				SessionTimeout:           optionalInt(oidc.SessionTimeout),
+			}
+		case ActionAuthenticateCognito:
+			cognito := action.AuthenticateCognito
+			args.AuthenticateCognito = lb.ListenerDefaultActionAuthenticateCognitoArgs{
+				UserPoolArn:              pulumi.String(cognito.UserPoolArn),
+				UserPoolClientId:         pulumi.String(cognito.UserPoolClientID),
+				UserPoolDomain:           pulumi.String(cognito.UserPoolDomain),
+				Scope:                    optionalString(cognito.Scope),
+				OnUnauthenticatedRequest: optionalString(cognito.OnUnauthenticatedRequest),
+				SessionTimeout:           optionalInt(cognito.SessionTimeout),
+			}
+		default:
+			return nil, fmt.Errorf("unknown action type %s", action.Type)
+		}
+		array = append(array, args)
+	}
+	return
+}
+
+// ListenerRuleActions returns the actions of a listener rule. They are built as the default actions of a listener, then
+// converted: the action types of listeners and of rules have the same fields
+func ListenerRuleActions(actions []LBActionParameters, tgs map[string]*lb.TargetGroup) (array lb.ListenerRuleActionArray, err error) {
+	defaults, err := ListenerDefaultActions(actions, tgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, action := range defaults {
+		array = append(array, listenerRuleAction(action.(lb.ListenerDefaultActionArgs)))
+	}
+	return
+}
+
+// listenerRuleAction converts an action built by ListenerDefaultActions. The nested args are converted as a whole, so
+// their fields are all kept; TestListenerRuleActionFields checks the fields of the actions themselves
+func listenerRuleAction(action lb.ListenerDefaultActionArgs) lb.ListenerRuleActionArgs {
+	args := lb.ListenerRuleActionArgs{
+		Type:           action.Type,
+		Order:          action.Order,
+		TargetGroupArn: action.TargetGroupArn,
+	}
+	if forward, ok := action.Forward.(lb.ListenerDefaultActionForwardArgs); ok {
+		var targetGroups lb.ListenerRuleActionForwardTargetGroupArray
+		for _, tg := range forward.TargetGroups.(lb.ListenerDefaultActionForwardTargetGroupArray) {
+			targetGroups = append(targetGroups, lb.ListenerRuleActionForwardTargetGroupArgs(tg.(lb.ListenerDefaultActionForwardTargetGroupArgs)))
+		}
+		ruleForward := lb.ListenerRuleActionForwardArgs{TargetGroups: targetGroups}
+		if stickiness, ok := forward.Stickiness.(lb.ListenerDefaultActionForwardStickinessArgs); ok {
+			ruleForward.Stickiness = lb.ListenerRuleActionForwardStickinessArgs(stickiness)
+		}
+		args.Forward = ruleForward
+	}
+	if fixedResponse, ok := action.FixedResponse.(lb.ListenerDefaultActionFixedResponseArgs); ok {
+		args.FixedResponse = lb.ListenerRuleActionFixedResponseArgs(fixedResponse)
+	}
+	if redirect, ok := action.Redirect.(lb.ListenerDefaultActionRedirectArgs); ok {
+		args.Redirect = lb.ListenerRuleActionRedirectArgs(redirect)
+	}
+	if oidc, ok := action.AuthenticateOidc.(lb.ListenerDefaultActionAuthenticateOidcArgs); ok {
+		args.AuthenticateOidc = lb.ListenerRuleActionAuthenticateOidcArgs(oidc)
+	}
+	if cognito, ok := action.AuthenticateCognito.(lb.ListenerDefaultActionAuthenticateCognitoArgs); ok {
+		args.AuthenticateCognito = lb.ListenerRuleActionAuthenticateCognitoArgs(cognito)
+	}
+	return args
+}
+
+func targetGroupID(tgs map[string]*lb.TargetGroup, lookupName string) (pulumi.StringInput, error) {
+	tg, ok := tgs[lookupName]
+	if !ok {
+		return nil, fmt.Errorf("Target group Lookup Name %s not found", lookupName)
+	}
+	return tg.ID(), nil
+}
+
+// actionOrder numbers the actions when there are several, so authenticate actions run first
+func actionOrder(i, count int) pulumi.IntPtrInput {
+	if count < 2 {
+		return nil
+	}
+	return pulumi.Int(i + 1)
+}
+
+func redirectStatusCode(permanent bool) string {
+	if permanent {
+		return "HTTP_301"
+	}
+	return "HTTP_302"
+}
+
+func optionalString(value string) pulumi.StringPtrInput {
+	if value == "" {
+		return nil
+	}
+	return pulumi.String(value)
+}
+
+func optionalInt(value int) pulumi.IntPtrInput {
+	if value == 0 {
+		return nil
+	}
+	return pulumi.Int(value)
+}
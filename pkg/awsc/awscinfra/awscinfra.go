@@ -3,8 +3,9 @@ package awscinfra
 import (
 	"errors"
 	"fmt"
-	"regexp"
+	"sort"
 	"strconv"
+	"sync"
 
 	"github.com/fpco-internal/pgocomp/pkg/awsc"
 
@@ -12,27 +13,52 @@ import (
 
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/acm"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/route53"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/servicediscovery"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-// New create a new infrastructure
+// New create a new infrastructure. The parameters are validated here, so invalid parameters register no resource, not even
+// the ComponentResource of the infra: applying the component returns the validation errors
 func New(params InfraParameters) *pgocomp.ComponentWithMeta[*InfraComponent] {
+	if err := params.Validate(); err != nil {
+		meta := params.Meta
+		meta.RegisterComponent = false
+		return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (*InfraComponent, error) {
+			return nil, err
+		})
+	}
 	return pgocomp.NewComponentWithMeta(params.Meta, func(ctx *pulumi.Context, name string) (response *InfraComponent, err error) {
 		response = &InfraComponent{
 			Vpcs: make(map[string]*pgocomp.GetComponentWithMetaResponse[*VpcComponent]),
 		}
+		var lock sync.Mutex
+		var vpcs []pgocomp.Applier
 		for _, vpcParams := range params.Vpcs {
-			err = CreateVpcComponent(vpcParams).GetAndThen(ctx, func(vpc *pgocomp.GetComponentWithMetaResponse[*VpcComponent]) error {
-				response.Vpcs[vpcParams.Name] = vpc
-				return nil
-			})
-			if err != nil {
-				return
-			}
+			vpcParams := vpcParams
+			//The tags of the infra become default tags of the providers, so every resource of the Vpc gets them
+			vpcParams.Provider.Meta = vpcParams.Provider.Meta.Inherit(&params.Meta)
+			untagged := params.Meta
+			untagged.Tags = nil
+			vpcParams.Meta = vpcParams.Meta.Inherit(&untagged)
+			vpcs = append(vpcs, pgocomp.ApplierFunc(func(ctx *pulumi.Context) error {
+				return CreateVpcComponent(vpcParams).GetAndThen(ctx, func(vpc *pgocomp.GetComponentWithMetaResponse[*VpcComponent]) error {
+					lock.Lock()
+					defer lock.Unlock()
+					response.Vpcs[vpcParams.Name] = vpc
+					return nil
+				})
+			}))
+		}
+		if params.Workers > 0 {
+			err = pgocomp.ApplyAllConcurrently(ctx, params.Workers, vpcs...)
+		} else {
+			err = pgocomp.ApplyAll(ctx, vpcs...)
 		}
 		return
 	})
@@ -42,8 +68,14 @@ func New(params InfraParameters) *pgocomp.ComponentWithMeta[*InfraComponent] {
 func CreateVpcComponent(params VpcParameters) *pgocomp.ComponentWithMeta[*VpcComponent] {
 	return pgocomp.NewComponentWithMeta(params.Meta, func(ctx *pulumi.Context, name string) (response *VpcComponent, err error) {
 		response = &VpcComponent{
-			Partitions:   make(map[string]*pgocomp.GetComponentWithMetaResponse[*NetworkPartitionComponent]),
-			Certificates: make(map[string]*pgocomp.GetComponentWithMetaResponse[*acm.Certificate]),
+			Partitions:            make(map[string]*pgocomp.GetComponentWithMetaResponse[*NetworkPartitionComponent]),
+			Certificates:          make(map[string]*pgocomp.GetComponentWithMetaResponse[*CertificateComponent]),
+			HostedZones:           make(map[string]*pgocomp.GetComponentWithMetaResponse[*route53.Zone]),
+			AliasRecords:          make(map[string]*pgocomp.GetComponentWithMetaResponse[*route53.Record]),
+			NatGateways:           make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.NatGateway]),
+			ElasticIPs:            make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Eip]),
+			GatewayEndpoints:      make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.VpcEndpoint]),
+			GatewayEndpointRoutes: make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Route]),
 		}
 		err = errors.Join(
 			CreateProvider(
@@ -53,22 +85,14 @@ func CreateVpcComponent(params VpcParameters) *pgocomp.ComponentWithMeta[*VpcCom
 				return CreateVPC(params.Meta, params, provider.Component).GetAndThen(ctx, func(vpc *pgocomp.GetComponentWithMetaResponse[*ec2.Vpc]) error {
 					response.Vpc = vpc
 					return errors.Join(
-						CreateInternetGateway(pgocomp.Meta{
-							Name: vpc.Meta.Name + "-igw",
-						}, provider.Component, vpc.Component).GetAndThen(ctx, func(igw *pgocomp.GetComponentWithMetaResponse[*ec2.InternetGateway]) error {
+						CreateInternetGateway(vpc.Meta.Child("igw"), provider.Component, vpc.Component).GetAndThen(ctx, func(igw *pgocomp.GetComponentWithMetaResponse[*ec2.InternetGateway]) error {
 							response.Gateway.InternetGateway = igw
-							return AttachInternetGatewayToVPC(pgocomp.Meta{
-								Name: vpc.Meta.Name + "-igw-attach",
-							}, provider.Component, vpc.Component, igw.Component).GetAndThen(ctx, func(iga *pgocomp.GetComponentWithMetaResponse[*ec2.InternetGatewayAttachment]) error {
+							return AttachInternetGatewayToVPC(vpc.Meta.Child("igw-attach"), provider.Component, vpc.Component, igw.Component).GetAndThen(ctx, func(iga *pgocomp.GetComponentWithMetaResponse[*ec2.InternetGatewayAttachment]) error {
 								response.Gateway.VpcGatewayAttachment = iga
-								return CreateRouteTable(pgocomp.Meta{
-									Name: vpc.Meta.Name + "-igw-routetable",
-								}, provider.Component, vpc.Component).GetAndThen(ctx, func(rt *pgocomp.GetComponentWithMetaResponse[*ec2.RouteTable]) error {
+								return CreateRouteTable(vpc.Meta.Child("igw-routetable"), provider.Component, vpc.Component).GetAndThen(ctx, func(rt *pgocomp.GetComponentWithMetaResponse[*ec2.RouteTable]) error {
 									response.Gateway.RouteTable = rt
 									return errors.Join(
-										CreateAndAttachDefaultRoute(pgocomp.Meta{
-											Name: vpc.Meta.Name + "-igw-default-route",
-										}, provider.Component, rt.Component, igw.Component, iga.Component).GetAndThen(ctx, func(r *pgocomp.GetComponentWithMetaResponse[*ec2.Route]) error {
+										CreateAndAttachDefaultRoute(vpc.Meta.Child("igw-default-route"), provider.Component, rt.Component, igw.Component, iga.Component).GetAndThen(ctx, func(r *pgocomp.GetComponentWithMetaResponse[*ec2.Route]) error {
 											response.Gateway.DefaultRoute = r
 											return nil
 										}),
@@ -77,9 +101,23 @@ func CreateVpcComponent(params VpcParameters) *pgocomp.ComponentWithMeta[*VpcCom
 							})
 						}),
 						func() (err error) {
+							zones := make(map[string]*route53.Zone)
+							for _, zone := range params.HostedZones {
+								zone.Meta = zone.Meta.Inherit(&params.Meta)
+								err = CreateHostedZone(zone.Meta, zone, provider.Component, vpc.Component).
+									GetAndThen(ctx, func(z *pgocomp.GetComponentWithMetaResponse[*route53.Zone]) error {
+										response.HostedZones[z.Meta.Name] = z
+										zones[z.Meta.Name] = z.Component
+										return nil
+									})
+								if err != nil {
+									return
+								}
+							}
 							for _, certificate := range params.Certificates {
-								err = CreateCertificate(certificate.Meta, certificate, provider.Component).
-									GetAndThen(ctx, func(cert *pgocomp.GetComponentWithMetaResponse[*acm.Certificate]) (err error) {
+								certificate.Meta = certificate.Meta.Inherit(&params.Meta)
+								err = CreateCertificateComponent(certificate.Meta, certificate, provider.Component, zones).
+									GetAndThen(ctx, func(cert *pgocomp.GetComponentWithMetaResponse[*CertificateComponent]) (err error) {
 										response.Certificates[cert.Meta.Name] = cert
 										return
 									})
@@ -90,22 +128,56 @@ func CreateVpcComponent(params VpcParameters) *pgocomp.ComponentWithMeta[*VpcCom
 							return
 						}(),
 						func() (err error) {
-							for _, partition := range params.Partitions {
-								certs := make(map[string]*acm.Certificate)
-								for k, v := range response.Certificates {
-									certs[k] = v.Component
-								}
-								err = CreateNetworkPartition(
-									partition.Meta, partition, provider.Component, vpc.Component, response.Gateway.RouteTable.Component, certs).
-									GetAndThen(ctx, func(npc *pgocomp.GetComponentWithMetaResponse[*NetworkPartitionComponent]) error {
-										response.Partitions[npc.Meta.Name] = npc
-										return nil
-									})
+							var namespace *servicediscovery.PrivateDnsNamespace
+							if params.Namespace != nil {
+								params.Namespace.Meta = params.Namespace.Meta.Inherit(&params.Meta)
+								err = CreateNamespace(params.Namespace.Meta, *params.Namespace, provider.Component, vpc.Component).GetAndThen(ctx, func(ns *pgocomp.GetComponentWithMetaResponse[*servicediscovery.PrivateDnsNamespace]) error {
+									response.Namespace = ns
+									namespace = ns.Component
+									return nil
+								})
 								if err != nil {
-									break
+									return
 								}
 							}
-							return
+							//Public partitions are created first, so the NAT gateways of the private partitions can be placed in their subnets
+							createPartitions := func(public bool, nats NatGateways) (err error) {
+								for _, partition := range params.Partitions {
+									if partition.IsPublic != public {
+										continue
+									}
+									partition.Meta = partition.Meta.Inherit(&params.Meta)
+									partition.defaultTags = params.Provider.Meta.Tags
+									certs := make(map[string]*CertificateComponent)
+									for k, v := range response.Certificates {
+										certs[k] = v.Component
+									}
+									err = CreateNetworkPartition(
+										partition.Meta, partition, provider.Component, vpc.Component, response.Gateway.RouteTable.Component, nats, certs, namespace).
+										GetAndThen(ctx, func(npc *pgocomp.GetComponentWithMetaResponse[*NetworkPartitionComponent]) error {
+											response.Partitions[npc.Meta.Name] = npc
+											return nil
+										})
+									if err != nil {
+										break
+									}
+								}
+								return
+							}
+							if err = createPartitions(true, nil); err != nil {
+								return
+							}
+							nats := make(NatGateways)
+							if err = createNatGateways(ctx, params, *vpc.Meta, provider.Component, response, nats); err != nil {
+								return
+							}
+							if err = createPartitions(false, nats); err != nil {
+								return
+							}
+							if err = createAliasRecords(ctx, params, provider.Component, response); err != nil {
+								return
+							}
+							return createGatewayEndpoints(ctx, params, provider.Component, vpc.Component, response)
 						}(),
 					)
 				})
@@ -116,14 +188,89 @@ func CreateVpcComponent(params VpcParameters) *pgocomp.ComponentWithMeta[*VpcCom
 	)
 }
 
-// CreateNetworkPartition takes some paramenters and creates a new Network Partition
-func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters, provider *aws.Provider, vpc *ec2.Vpc, rt *ec2.RouteTable, certs map[string]*acm.Certificate) *pgocomp.ComponentWithMeta[*NetworkPartitionComponent] {
+// createNatGateways creates the NAT gateways of the Vpc in the subnets of its public partitions, following the NatMode of the Vpc.
+// No NAT gateway is created when the Vpc has no private subnet
+func createNatGateways(ctx *pulumi.Context, params VpcParameters, meta pgocomp.Meta, provider *aws.Provider, response *VpcComponent, nats NatGateways) error {
+	mode := valueOrDefault(params.NatMode, NatNone)
+	if mode == NatNone || !hasPrivateSubnets(params) {
+		return nil
+	}
+	for _, partition := range params.Partitions {
+		public, ok := response.Partitions[partition.Name]
+		if !partition.IsPublic || !ok {
+			continue
+		}
+		for _, subnetParams := range partition.Subnets {
+			subnet, ok := public.Component.Subnets[subnetParams.Name]
+			if !ok {
+				continue
+			}
+			var zone string
+			if mode == NatPerAZ {
+				zone = public.Component.AvailabilityZones[subnetParams.Name]
+			}
+			if _, ok := nats[zone]; ok {
+				continue
+			}
+			err := CreateElasticIP(meta.Child(zoneSuffix("nat-eip", zone)), provider, response.Gateway.VpcGatewayAttachment.Component).GetAndThen(ctx, func(eip *pgocomp.GetComponentWithMetaResponse[*ec2.Eip]) error {
+				response.ElasticIPs[zone] = eip
+				return CreateNatGateway(meta.Child(zoneSuffix("nat", zone)), provider, eip.Component, subnet.Component, response.Gateway.VpcGatewayAttachment.Component).GetAndThen(ctx, func(nat *pgocomp.GetComponentWithMetaResponse[*ec2.NatGateway]) error {
+					response.NatGateways[zone] = nat
+					nats[zone] = nat.Component
+					return nil
+				})
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(nats) == 0 {
+		return fmt.Errorf("the vpc %s needs a public subnet for its NAT gateway", meta.Name)
+	}
+	return nil
+}
+
+func hasPrivateSubnets(params VpcParameters) bool {
+	for _, partition := range params.Partitions {
+		if !partition.IsPublic && len(partition.Subnets) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPublicSubnets(params VpcParameters) bool {
+	for _, partition := range params.Partitions {
+		if partition.IsPublic && len(partition.Subnets) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// zoneSuffix appends the availability zone to a name, when there is one
+func zoneSuffix(name, zone string) string {
+	if zone == "" {
+		return name
+	}
+	return name + "-" + zone
+}
+
+// CreateNetworkPartition takes some paramenters and creates a new Network Partition.
+// Public subnets are associated to the route table of the internet gateway. Private subnets get route tables
+// of their own, with a default route to the NAT gateway of their availability zone when there is one.
+// The namespace is the namespace of the Vpc, or nil
+func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters, provider *aws.Provider, vpc *ec2.Vpc, rt *ec2.RouteTable, nats NatGateways, certs map[string]*CertificateComponent, namespace *servicediscovery.PrivateDnsNamespace) *pgocomp.ComponentWithMeta[*NetworkPartitionComponent] {
 	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *NetworkPartitionComponent, err error) {
 		response = &NetworkPartitionComponent{
-			Subnets:       make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Subnet]),
-			LoadBalancers: make(map[string]*pgocomp.GetComponentWithMetaResponse[*LoadBalancerComponent]),
-			TargetGroups:  make(map[string]*pgocomp.GetComponentWithMetaResponse[*lb.TargetGroup]),
-			ECSClusters:   make(map[string]*pgocomp.GetComponentWithMetaResponse[*ECSClusterComponent]),
+			Subnets:                make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Subnet]),
+			AvailabilityZones:      make(map[string]string),
+			RouteTables:            make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.RouteTable]),
+			LoadBalancers:          make(map[string]*pgocomp.GetComponentWithMetaResponse[*LoadBalancerComponent]),
+			TargetGroups:           make(map[string]*pgocomp.GetComponentWithMetaResponse[*lb.TargetGroup]),
+			ECSClusters:            make(map[string]*pgocomp.GetComponentWithMetaResponse[*ECSClusterComponent]),
+			TargetGroupAttachments: make(map[string]*pgocomp.GetComponentWithMetaResponse[*lb.TargetGroupAttachment]),
 		}
 		azs, err := aws.GetAvailabilityZones(ctx, &aws.GetAvailabilityZonesArgs{
 			State: pulumi.StringRef("available"),
@@ -131,15 +278,44 @@ func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters
 		if err != nil {
 			return nil, err
 		}
+		//privateRouteTable returns the route table of the private subnets of an availability zone, creating it the first time
+		privateRouteTable := func(az string) (*ec2.RouteTable, error) {
+			var zone string
+			if _, ok := nats[az]; ok {
+				zone = az
+			}
+			if prt, ok := response.RouteTables[zone]; ok {
+				return prt.Component, nil
+			}
+			nat := nats.For(az)
+			if len(nats) > 0 && nat == nil {
+				return nil, fmt.Errorf("no NAT gateway in the availability zone %s of the partition %s, add a public subnet in it", az, name)
+			}
+			var prt *ec2.RouteTable
+			err := CreateRouteTable(meta.Child(zoneSuffix("routetable", zone)), provider, vpc).GetAndThen(ctx, func(r *pgocomp.GetComponentWithMetaResponse[*ec2.RouteTable]) error {
+				response.RouteTables[zone] = r
+				prt = r.Component
+				if nat == nil {
+					return nil
+				}
+				return CreateAndAttachNatRoute(meta.Child(zoneSuffix("nat-route", zone)), provider, r.Component, nat).Apply(ctx)
+			})
+			return prt, err
+		}
 		err = errors.Join(
 			//CreateSubnets
 			func() (err error) {
 				for i, subnet := range params.Subnets {
+					subnet.Meta = subnet.Meta.Inherit(&meta)
+					az := azs.Names[i%len(azs.Names)]
+					response.AvailabilityZones[subnet.Meta.Name] = az
 					var srt = rt
 					if !params.IsPublic {
-						srt = nil
+						if srt, err = privateRouteTable(az); err != nil {
+							return
+						}
 					}
-					err = CreateSubnetAndAssociateToRoute(subnet.Meta, subnet, azs.Names[i%len(azs.Names)], provider, vpc, srt).GetAndThen(ctx, func(subnet *pgocomp.GetComponentWithMetaResponse[*ec2.Subnet]) error {
+					err = CreateSubnetAndAssociateToRoute(subnet.Meta, subnet, az, provider, vpc, srt).GetAndThen(ctx, func(subnet *pgocomp.GetComponentWithMetaResponse[*ec2.Subnet]) error {
 						response.Subnets[subnet.Meta.Name] = subnet
 						return nil
 					})
@@ -153,6 +329,7 @@ func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters
 			//CreateTargetGroups
 			func() (err error) {
 				for _, tg := range params.LBTargetGroups {
+					tg.Meta = tg.Meta.Inherit(&meta)
 					err = CreateTargetGroup(tg.Meta, tg, provider, vpc).GetAndThen(ctx, func(tgc *pgocomp.GetComponentWithMetaResponse[*lb.TargetGroup]) error {
 						response.TargetGroups[tg.Meta.Name] = tgc
 						return nil
@@ -166,11 +343,13 @@ func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters
 
 			//CreateLoadBalancers
 			func() (err error) {
-				var subnets []*ec2.Subnet
-				for _, s := range response.Subnets {
-					subnets = append(subnets, s.Component)
+				subnets := make(map[string]*ec2.Subnet)
+				for k, s := range response.Subnets {
+					subnets[k] = s.Component
 				}
 				for _, loadBalancer := range params.LoadBalancers {
+					loadBalancer.Meta = loadBalancer.Meta.Inherit(&meta)
+					loadBalancer.Listeners = params.blueGreenListeners(loadBalancer)
 					err = CreateLoadBalancerComponent(loadBalancer.Meta, loadBalancer, provider, vpc, subnets, response.TargetGroups, certs).GetAndThen(ctx, func(lbc *pgocomp.GetComponentWithMetaResponse[*LoadBalancerComponent]) error {
 						response.LoadBalancers[loadBalancer.Meta.Name] = lbc
 						return nil
@@ -179,16 +358,43 @@ func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters
 						return
 					}
 				}
+				//Application load balancers are registered in the alb target groups of the network load balancers
+				for _, tg := range params.LBTargetGroups {
+					if tg.TargetType != TGAlb {
+						continue
+					}
+					tg.Meta = tg.Meta.Inherit(&meta)
+					targetGroup, ok := response.TargetGroups[tg.Name]
+					if !ok {
+						return fmt.Errorf("Target group Lookup Name %s not found", tg.Name)
+					}
+					loadBalancer, ok := response.LoadBalancers[tg.LoadBalancerLookupName]
+					if !ok {
+						return fmt.Errorf("Load balancer Lookup Name %s not found", tg.LoadBalancerLookupName)
+					}
+					err = CreateTargetGroupAttachment(tg.Meta.Child("attachment"), tg, provider, targetGroup.Component, loadBalancer.Component).GetAndThen(ctx, func(tga *pgocomp.GetComponentWithMetaResponse[*lb.TargetGroupAttachment]) error {
+						response.TargetGroupAttachments[tg.Name] = tga
+						return nil
+					})
+					if err != nil {
+						return
+					}
+				}
 				return
 			}(),
 
 			//CreateClusters
 			func() (err error) {
 
-				//Collect subnets
+				//Collect subnets, by name so the subnets of the services and groups keep their order
+				var subnetNames []string
+				for subnetName := range response.Subnets {
+					subnetNames = append(subnetNames, subnetName)
+				}
+				sort.Strings(subnetNames)
 				var subnets []*ec2.Subnet
-				for _, s := range response.Subnets {
-					subnets = append(subnets, s.Component)
+				for _, subnetName := range subnetNames {
+					subnets = append(subnets, response.Subnets[subnetName].Component)
 				}
 
 				//Collect target groups
@@ -197,14 +403,42 @@ func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters
 					tgs[k] = v.Component
 				}
 
-				//Collect security groups
+				//Collect security groups, by lookup name of their load balancer so their order is the same on every run
+				var lbNames []string
+				for lbName := range response.LoadBalancers {
+					lbNames = append(lbNames, lbName)
+				}
+				sort.Strings(lbNames)
 				var sgs []*ec2.SecurityGroup
-				for _, b := range response.LoadBalancers {
-					sgs = append(sgs, b.Component.SecurityGroup.Component)
+				for _, lbName := range lbNames {
+					if b := response.LoadBalancers[lbName]; b.Component.SecurityGroup != nil {
+						sgs = append(sgs, b.Component.SecurityGroup.Component)
+					}
+				}
+
+				//Collect the application load balancers that forward to the target groups
+				var loadBalancers = make(map[string]*lb.LoadBalancer)
+				for _, loadBalancer := range params.LoadBalancers {
+					b, ok := response.LoadBalancers[loadBalancer.Name]
+					if !ok || loadBalancer.Type != Application {
+						continue
+					}
+					for _, listener := range loadBalancer.Listeners {
+						for _, tgName := range listener.targetGroupLookupNames() {
+							loadBalancers[tgName] = b.Component.LoadBalancer.Component
+						}
+					}
 				}
 
 				for _, cluster := range params.ECSClusters {
-					err = CreateECSClusterComponent(cluster.Meta, cluster, provider, vpc, subnets, tgs, sgs).GetAndThen(ctx, func(cls *pgocomp.GetComponentWithMetaResponse[*ECSClusterComponent]) error {
+					cluster.Meta = cluster.Meta.Inherit(&meta)
+					cluster.defaultTags = params.defaultTags
+					cluster.public = params.IsPublic
+					var blueGreen map[string]*BlueGreenTargets
+					if blueGreen, err = createBlueGreenTargets(ctx, meta, params, cluster, provider, vpc, certs, response); err != nil {
+						return
+					}
+					err = CreateECSClusterComponent(cluster.Meta, cluster, provider, vpc, subnets, tgs, loadBalancers, sgs, namespace, blueGreen).GetAndThen(ctx, func(cls *pgocomp.GetComponentWithMetaResponse[*ECSClusterComponent]) error {
 						response.ECSClusters[cls.Meta.Name] = cls
 						return nil
 					})
@@ -219,43 +453,106 @@ func CreateNetworkPartition(meta pgocomp.Meta, params NetworkPartitionParameters
 	})
 }
 
-// CreateLoadBalancerComponent takes some paramenters and creates a new Network Partition
-func CreateLoadBalancerComponent(meta pgocomp.Meta, params LoadBalancerParameters, provider *aws.Provider, vpc *ec2.Vpc, subnets []*ec2.Subnet, tgs map[string]*pgocomp.GetComponentWithMetaResponse[*lb.TargetGroup], certs map[string]*acm.Certificate) *pgocomp.ComponentWithMeta[*LoadBalancerComponent] {
+// CreateLoadBalancerComponent takes some paramenters and creates a new Network Partition.
+// Application load balancers get a security group open on the ports of their listeners. Network load balancers have no
+// security group, and get an elastic ip in each subnet when ElasticIPs is set
+func CreateLoadBalancerComponent(meta pgocomp.Meta, params LoadBalancerParameters, provider *aws.Provider, vpc *ec2.Vpc, subnets map[string]*ec2.Subnet, tgs map[string]*pgocomp.GetComponentWithMetaResponse[*lb.TargetGroup], certs map[string]*CertificateComponent) *pgocomp.ComponentWithMeta[*LoadBalancerComponent] {
 	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (*LoadBalancerComponent, error) {
 		var response LoadBalancerComponent = LoadBalancerComponent{
-			Listeners: make(map[string]*pgocomp.GetComponentWithMetaResponse[*lb.Listener]),
+			Listeners:  make(map[string]*pgocomp.GetComponentWithMetaResponse[*lb.Listener]),
+			ElasticIPs: make(map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Eip]),
+		}
+		var subnetNames []string
+		for subnetName := range subnets {
+			subnetNames = append(subnetNames, subnetName)
+		}
+		sort.Strings(subnetNames)
+		var subnetList []*ec2.Subnet
+		for _, subnetName := range subnetNames {
+			subnetList = append(subnetList, subnets[subnetName])
+		}
+		createListeners := func(loadBalancer *pgocomp.GetComponentWithMetaResponse[*lb.LoadBalancer], sg *ec2.SecurityGroup) error {
+			response.LoadBalancer = loadBalancer
+			pgocomp.Export(ctx, loadBalancer.GetComponentResponse.Name+"-dns", loadBalancer.Component.DnsName)
+			var ltgs = make(map[string]*lb.TargetGroup)
+			for k, v := range tgs {
+				ltgs[k] = v.Component
+			}
+			for _, lis := range params.Listeners {
+				lis.Meta = lis.Meta.Inherit(&meta)
+				if err := CreateListener(
+					lis.Meta, lis, provider, loadBalancer.Component, ltgs, sg, certs).GetAndThen(ctx, func(l *pgocomp.GetComponentWithMetaResponse[*lb.Listener]) error {
+					response.Listeners[l.Meta.Name] = l
+					if sg == nil {
+						return nil
+					}
+					var cidrs pulumi.StringArray
+					if params.IsInternal {
+						cidrs = pulumi.StringArray{vpc.CidrBlock}
+					} else {
+						cidrs = pulumi.StringArray{pulumi.String("0.0.0.0/0")}
+					}
+					return CreateAndAttachTCPIngressSecurityGroupRule(
+						lis.Meta.Child("rule"),
+						provider, sg, lis.Port, lis.Port, cidrs).Apply(ctx)
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if params.Type == Gateway {
+			err := CreateGatewayLoadBalancer(meta, provider, subnetList).GetAndThen(ctx, func(loadBalancer *pgocomp.GetComponentWithMetaResponse[*lb.LoadBalancer]) error {
+				return errors.Join(
+					createListeners(loadBalancer, nil),
+					CreateEndpointService(meta.Child("endpoint-service"), params.EndpointService, provider, loadBalancer.Component).GetAndThen(ctx, func(service *pgocomp.GetComponentWithMetaResponse[*ec2.VpcEndpointService]) error {
+						response.EndpointService = service
+						return nil
+					}),
+				)
+			})
+			return &response, err
+		}
+		if params.Type == Network {
+			args := &lb.LoadBalancerArgs{
+				LoadBalancerType:             pulumi.String(params.Type),
+				Internal:                     pulumi.Bool(params.IsInternal),
+				EnableCrossZoneLoadBalancing: pulumi.Bool(params.CrossZone),
+			}
+			if params.ElasticIPs {
+				var mappings lb.LoadBalancerSubnetMappingArray
+				for _, subnetName := range subnetNames {
+					err := CreateElasticIP(meta.Child("eip-"+subnetName), provider, nil).GetAndThen(ctx, func(eip *pgocomp.GetComponentWithMetaResponse[*ec2.Eip]) error {
+						response.ElasticIPs[subnetName] = eip
+						mappings = append(mappings, lb.LoadBalancerSubnetMappingArgs{
+							SubnetId:     subnets[subnetName].ID(),
+							AllocationId: eip.Component.AllocationId,
+						})
+						return nil
+					})
+					if err != nil {
+						return &response, err
+					}
+				}
+				args.SubnetMappings = mappings
+			} else {
+				var customResources []*pulumi.CustomResourceState
+				for _, subnet := range subnetList {
+					customResources = append(customResources, &subnet.CustomResourceState)
+				}
+				args.Subnets = awsc.ToIDStringArray(customResources...)
+			}
+			err := awsc.NewLoadBalancer(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(loadBalancer *pgocomp.GetComponentWithMetaResponse[*lb.LoadBalancer]) error {
+				return createListeners(loadBalancer, nil)
+			})
+			return &response, err
 		}
 		var err = errors.Join(
-			CreateSecurityGroup(pgocomp.Meta{
-				Name: meta.Name + "-sg",
-			}, provider, vpc).GetAndThen(ctx, func(sg *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]) error {
+			CreateSecurityGroup(meta.Child("sg"), provider, vpc).GetAndThen(ctx, func(sg *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]) error {
 				response.SecurityGroup = sg
 				return errors.Join(
-					CreateLoadBalancerAndAssociateToSubnets(meta, params.Type, provider, subnets, sg.Component).GetAndThen(ctx, func(loadBalancer *pgocomp.GetComponentWithMetaResponse[*lb.LoadBalancer]) error {
-						response.LoadBalancer = loadBalancer
-						ctx.Export(loadBalancer.Meta.FullName()+"-dns", loadBalancer.Component.DnsName)
-						var ltgs = make(map[string]*lb.TargetGroup)
-						for k, v := range tgs {
-							ltgs[k] = v.Component
-						}
-						for _, lis := range params.Listeners {
-							if err := CreateListener(
-								lis.Meta, lis, provider, loadBalancer.Component, ltgs, sg.Component, certs).GetAndThen(ctx, func(l *pgocomp.GetComponentWithMetaResponse[*lb.Listener]) error {
-								response.Listeners[l.Meta.Name] = l
-								var cidrs pulumi.StringArray
-								if params.IsInternal {
-									cidrs = pulumi.StringArray{vpc.CidrBlock}
-								} else {
-									cidrs = pulumi.StringArray{pulumi.String("0.0.0.0/0")}
-								}
-								return CreateAndAttachTCPIngressSecurityGroupRule(
-									pgocomp.Meta{Name: lis.Meta.Name + "-rule"},
-									provider, sg.Component, lis.Port, lis.Port, cidrs).Apply(ctx)
-							}); err != nil {
-								return err
-							}
-						}
-						return nil
+					CreateLoadBalancerAndAssociateToSubnets(meta, params.Type, provider, subnetList, sg.Component).GetAndThen(ctx, func(loadBalancer *pgocomp.GetComponentWithMetaResponse[*lb.LoadBalancer]) error {
+						return createListeners(loadBalancer, sg.Component)
 					}),
 				)
 			}),
@@ -264,17 +561,37 @@ func CreateLoadBalancerComponent(meta pgocomp.Meta, params LoadBalancerParameter
 	})
 }
 
-// CreateECSClusterComponent takes some paramenters and creates a new Network Partition
-func CreateECSClusterComponent(meta pgocomp.Meta, params ECSClusterParameters, provider *aws.Provider, vpc *ec2.Vpc, subnets []*ec2.Subnet, tgs map[string]*lb.TargetGroup, sgs []*ec2.SecurityGroup) *pgocomp.ComponentWithMeta[*ECSClusterComponent] {
+// CreateECSClusterComponent takes some paramenters and creates a new Network Partition.
+// The load balancers are the application load balancers that forward to the target groups, by target group lookup name.
+// The namespace of the Vpc, when there is one, is the namespace of a cluster that has none, and blueGreen are the traffic routes
+// of the services deployed in the blue/green mode, by service name
+func CreateECSClusterComponent(meta pgocomp.Meta, params ECSClusterParameters, provider *aws.Provider, vpc *ec2.Vpc, subnets []*ec2.Subnet, tgs map[string]*lb.TargetGroup, loadBalancers map[string]*lb.LoadBalancer, sgs []*ec2.SecurityGroup, namespace *servicediscovery.PrivateDnsNamespace, blueGreen map[string]*BlueGreenTargets) *pgocomp.ComponentWithMeta[*ECSClusterComponent] {
 	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *ECSClusterComponent, err error) {
 		response = &ECSClusterComponent{
-			FargateServices: make(map[string]*pgocomp.GetComponentWithMetaResponse[*ecs.Service]),
+			FargateServices:   make(map[string]*pgocomp.GetComponentWithMetaResponse[*ECSServiceComponent]),
+			CapacityProviders: make(map[string]*pgocomp.GetComponentWithMetaResponse[*EC2CapacityProviderComponent]),
+		}
+		if params.Namespace != nil {
+			params.Namespace.Meta = params.Namespace.Meta.Inherit(&meta)
+			err = CreateNamespace(params.Namespace.Meta, *params.Namespace, provider, vpc).GetAndThen(ctx, func(ns *pgocomp.GetComponentWithMetaResponse[*servicediscovery.PrivateDnsNamespace]) error {
+				response.Namespace = ns
+				namespace = ns.Component
+				return nil
+			})
+			if err != nil {
+				return
+			}
 		}
 		err = errors.Join(
 			CreateECSCluster(
-				meta, params, provider).GetAndThen(ctx, func(cluster *pgocomp.GetComponentWithMetaResponse[*ecs.Cluster]) error {
+				meta, params, provider, namespace).GetAndThen(ctx, func(cluster *pgocomp.GetComponentWithMetaResponse[*ecs.Cluster]) error {
 				response.Cluster = cluster
+				capacity, err := createCapacityProviders(ctx, meta, params, provider, vpc, cluster.Component, subnets, response)
+				if err != nil {
+					return err
+				}
 				for _, svcParams := range params.Services {
+					svcParams.Meta = svcParams.Meta.Inherit(&meta)
 					err := CreateEcsFargateServiceComponent(
 						svcParams.Meta,
 						svcParams,
@@ -283,7 +600,11 @@ func CreateECSClusterComponent(meta pgocomp.Meta, params ECSClusterParameters, p
 						cluster.Component,
 						subnets,
 						tgs,
-					).GetAndThen(ctx, func(svc *pgocomp.GetComponentWithMetaResponse[*ecs.Service]) error {
+						loadBalancers,
+						capacity,
+						namespace,
+						blueGreen[svcParams.Name],
+					).GetAndThen(ctx, func(svc *pgocomp.GetComponentWithMetaResponse[*ECSServiceComponent]) error {
 						response.FargateServices[svc.Meta.Name] = svc
 						return nil
 					})
@@ -298,14 +619,6 @@ func CreateECSClusterComponent(meta pgocomp.Meta, params ECSClusterParameters, p
 	})
 }
 
-func matchOrPanic(pattern string, str string) bool {
-	b, err := regexp.MatchString(pattern, str)
-	if err != nil {
-		panic(err)
-	}
-	return b
-}
-
 func valueOrDefault[T comparable](value T, _default T) T {
 	var zero T
 	if value == zero {
@@ -316,13 +629,18 @@ func valueOrDefault[T comparable](value T, _default T) T {
 
 // CreateCertificate creates a new certificate with a CertificateParameters
 func CreateCertificate(meta pgocomp.Meta, params CertificateParameters, provider *aws.Provider) *pgocomp.ComponentWithMeta[*acm.Certificate] {
-	return awsc.NewCertificate(meta, &acm.CertificateArgs{
+	args := &acm.CertificateArgs{
 		DomainName:       pulumi.String(params.Domain),
 		ValidationMethod: pulumi.String(params.ValidationMethod),
-	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+	}
+	if len(params.SubjectAlternativeNames) > 0 {
+		args.SubjectAlternativeNames = pulumi.ToStringArray(params.SubjectAlternativeNames)
+	}
+	return awsc.NewCertificate(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
 }
 
-// CreateEcsFargateServiceComponent is
+// CreateEcsFargateServiceComponent creates a Fargate service with its task definition, its security group and,
+// when they are needed, the task role of its containers and the execution role used by ECS to start them
 func CreateEcsFargateServiceComponent(
 	meta pgocomp.Meta,
 	params ECSServiceParameters,
@@ -331,39 +649,90 @@ func CreateEcsFargateServiceComponent(
 	cluster *ecs.Cluster,
 	subnets []*ec2.Subnet,
 	targetGroups map[string]*lb.TargetGroup,
-) *pgocomp.ComponentWithMeta[*ecs.Service] {
-	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *ecs.Service, err error) {
+	loadBalancers map[string]*lb.LoadBalancer,
+	capacity *ClusterCapacity,
+	namespace *servicediscovery.PrivateDnsNamespace,
+	blueGreen *BlueGreenTargets,
+) *pgocomp.ComponentWithMeta[*ECSServiceComponent] {
+	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *ECSServiceComponent, err error) {
+		response = &ECSServiceComponent{
+			Secrets: make(map[string]*pgocomp.GetComponentWithMetaResponse[*SecretComponent]),
+		}
+		taskDefinitionArgs := &ecs.TaskDefinitionArgs{}
+		var logGroup *cloudwatch.LogGroup
+		if params.Logs.Enabled {
+			err = CreateLogGroup(meta.Child("logs"), "/ecs/"+name, params.Logs, provider).GetAndThen(ctx, func(group *pgocomp.GetComponentWithMetaResponse[*cloudwatch.LogGroup]) error {
+				response.LogGroup = group
+				logGroup = group.Component
+				return nil
+			})
+			if err != nil {
+				return
+			}
+		}
+		var valueFrom pulumi.StringMap
+		if valueFrom, err = createContainerSecrets(ctx, meta, params, provider, response); err != nil {
+			return
+		}
+		if !params.TaskRole.isEmpty() {
+			err = CreateRoleComponent(meta.Child("task-role"), ecsTasksPrincipal, params.TaskRole.taskRolePolicy(), params.TaskRole.ManagedPolicyArns, provider).
+				GetAndThen(ctx, func(role *pgocomp.GetComponentWithMetaResponse[*RoleComponent]) error {
+					response.TaskRole = role
+					taskDefinitionArgs.TaskRoleArn = role.Component.Role.Component.Arn
+					return nil
+				})
+			if err != nil {
+				return
+			}
+		}
+		if statements := params.executionStatements(logGroup, response.Secrets); len(statements) > 0 {
+			err = CreateRoleComponent(meta.Child("execution-role"), ecsTasksPrincipal, policyDocument(statements), nil, provider).
+				GetAndThen(ctx, func(role *pgocomp.GetComponentWithMetaResponse[*RoleComponent]) error {
+					response.ExecutionRole = role
+					taskDefinitionArgs.ExecutionRoleArn = role.Component.Role.Component.Arn
+					return nil
+				})
+			if err != nil {
+				return
+			}
+		}
 
-		//Security group for the Service
-		err = CreateSecurityGroup(pgocomp.Meta{Name: meta.Name + "-sg"}, provider, vpc).GetAndThen(ctx, func(sg *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]) (err error) {
-			var containerDefinitions string
-			containerDefinitions, err = params.ECSTaskDefinitionContainerDefinitionArray()
+		var registry *servicediscovery.Service
+		if params.ServiceDiscovery != nil && namespace != nil {
+			err = CreateDiscoveryService(meta.Child("discovery"), name, *params.ServiceDiscovery, provider, namespace).GetAndThen(ctx, func(s *pgocomp.GetComponentWithMetaResponse[*servicediscovery.Service]) error {
+				response.DiscoveryService = s
+				registry = s.Component
+				return nil
+			})
 			if err != nil {
 				return
 			}
+		}
+
+		//Security group for the Service
+		err = CreateSecurityGroup(meta.Child("sg"), provider, vpc).GetAndThen(ctx, func(sg *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]) (err error) {
+			response.SecurityGroup = sg
+			if err = createDiscoveryRules(ctx, meta, params, provider, vpc, sg.Component); err != nil {
+				return
+			}
+			taskDefinitionArgs.ContainerDefinitions = params.containerDefinitions(name, logGroup, provider.Region.Elem(), valueFrom)
+			taskDefinitionArgs.NetworkMode = pulumi.String("awsvpc")
+			taskDefinitionArgs.RequiresCompatibilities = params.requiresCompatibilities(capacity)
+			taskDefinitionArgs.Family = pulumi.String(name + "-task")
+			taskDefinitionArgs.Cpu = pulumi.String(strconv.Itoa(params.CPU))
+			taskDefinitionArgs.Memory = pulumi.String(strconv.Itoa(params.Memory))
 			err = awsc.NewEcsTaskDefinition(
-				pgocomp.Meta{
-					Name: meta.Name + "-task",
-				},
-				&ecs.TaskDefinitionArgs{
-					NetworkMode: pulumi.String("awsvpc"),
-					RequiresCompatibilities: pulumi.StringArray{
-						pulumi.String("FARGATE"),
-					},
-					Family:               pulumi.String(meta.Name + "-task"),
-					Cpu:                  pulumi.String(strconv.Itoa(params.CPU)),
-					Memory:               pulumi.String(strconv.Itoa(params.Memory)),
-					ContainerDefinitions: pulumi.String(containerDefinitions),
-				}, func() []pulumi.ResourceOption {
+				meta.Child("task"),
+				taskDefinitionArgs,
+				func() []pulumi.ResourceOption {
 					dependsOn := []pulumi.Resource{cluster}
 					for _, subnet := range subnets {
 						dependsOn = append(dependsOn, subnet)
 					}
 					for _, c := range params.Containers {
 						for _, p := range c.PortMappings {
-							if tg, ok := targetGroups[p.TargetGroupLookupName]; !ok {
+							if tg, ok := targetGroups[p.TargetGroupLookupName]; ok {
 								dependsOn = append(dependsOn, tg)
-
 							}
 						}
 					}
@@ -372,12 +741,43 @@ func CreateEcsFargateServiceComponent(
 						pulumi.DependsOn(dependsOn),
 					}
 				}()...).GetAndThen(ctx, func(taskDef *pgocomp.GetComponentWithMetaResponse[*ecs.TaskDefinition]) error {
+				response.TaskDefinition = taskDef
+				var loadBalancerArgs ecs.ServiceLoadBalancerArray
+				for _, c := range params.Containers {
+					for _, p := range c.PortMappings {
+						if tg, ok := targetGroups[p.TargetGroupLookupName]; ok {
+							if err := CreateSecurityGroupRuleForTargetGroup(
+								meta.Child("sg-"+p.TargetGroupLookupName+"-rule"),
+								provider,
+								sg.Component,
+								tg,
+								vpc,
+							).Apply(ctx); err != nil {
+								return err
+							}
+							loadBalancerArgs = append(loadBalancerArgs, ecs.ServiceLoadBalancerArgs{
+								ContainerName:  pulumi.String(c.Name),
+								ContainerPort:  pulumi.Int(p.ContainerPort),
+								TargetGroupArn: tg.ID(),
+							})
+						}
+					}
+				}
 				return awsc.NewECSService(params.Meta, &ecs.ServiceArgs{
-					Name:           pulumi.String(params.Name),
-					Cluster:        cluster.ID(),
-					DesiredCount:   pulumi.Int(params.DesiredCount),
-					LaunchType:     pulumi.String("FARGATE"),
-					TaskDefinition: taskDef.Component.ID(),
+					Name:                            pulumi.String(name),
+					Cluster:                         cluster.ID(),
+					DesiredCount:                    pulumi.Int(params.desiredCount()),
+					LaunchType:                      params.launchType(),
+					CapacityProviderStrategies:      params.capacityProviderStrategies(capacity),
+					PropagateTags:                   pulumi.String("SERVICE"),
+					TaskDefinition:                  taskDef.Component.ID(),
+					ServiceRegistries:               params.serviceRegistries(registry),
+					ServiceConnectConfiguration:     params.serviceConnectConfiguration(name, namespace, logGroup, provider.Region.Elem()),
+					DeploymentController:            params.Deployment.deploymentController(),
+					DeploymentCircuitBreaker:        params.Deployment.circuitBreaker(),
+					DeploymentMinimumHealthyPercent: params.Deployment.minimumHealthyPercent(),
+					DeploymentMaximumPercent:        optionalInt(params.Deployment.MaximumPercent),
+					HealthCheckGracePeriodSeconds:   optionalInt(params.Deployment.HealthCheckGracePeriod),
 					NetworkConfiguration: ecs.ServiceNetworkConfigurationArgs{
 						AssignPublicIp: pulumi.Bool(params.AssignPublicIP),
 						Subnets: func() (array pulumi.StringArray) {
@@ -388,35 +788,33 @@ func CreateEcsFargateServiceComponent(
 						}(),
 						SecurityGroups: pulumi.StringArray{sg.Component.ID()},
 					},
-					LoadBalancers: func() (array ecs.ServiceLoadBalancerArray) {
-						for _, c := range params.Containers {
-							for _, p := range c.PortMappings {
-								if tg, ok := targetGroups[p.TargetGroupLookupName]; ok {
-									if err := CreateSecurityGroupRuleForTargetGroup(
-										pgocomp.Meta{Name: meta.Name + "-sg-" + p.TargetGroupLookupName + "-rule"},
-										provider,
-										sg.Component,
-										tg,
-										vpc,
-									).Apply(ctx); err != nil {
-										panic(err)
-									}
-									array = append(array, ecs.ServiceLoadBalancerArgs{
-										ContainerName:  pulumi.String(c.Name),
-										ContainerPort:  pulumi.Int(p.ContainerPort),
-										TargetGroupArn: tg.ID(),
-									})
-								}
-							}
-						}
-						return
-					}(),
+					LoadBalancers: loadBalancerArgs,
 				},
 					pulumi.Provider(provider), pulumi.Protect(meta.Protect),
-					//				pulumi.ReplaceOnChanges([]string{"*"}),
-					pulumi.DependsOn([]pulumi.Resource{cluster, taskDef.Component})).GetAndThen(ctx, func(svc *pgocomp.GetComponentWithMetaResponse[*ecs.Service]) error {
-					response = svc.Component
-					return nil
+					pulumi.DependsOn(capacity.dependsOn(cluster, taskDef.Component)),
+					pulumi.IgnoreChanges(params.ignoredChanges())).GetAndThen(ctx, func(svc *pgocomp.GetComponentWithMetaResponse[*ecs.Service]) error {
+					response.Service = svc
+					if params.Deployment.BlueGreen != nil {
+						if blueGreen == nil {
+							return fmt.Errorf("No blue/green traffic routes for the service %s", name)
+						}
+						err := CreateBlueGreenComponent(meta.Child("codedeploy"), params.Deployment, provider, cluster, svc.Component, blueGreen).
+							GetAndThen(ctx, func(bg *pgocomp.GetComponentWithMetaResponse[*BlueGreenComponent]) error {
+								response.BlueGreen = bg
+								return nil
+							})
+						if err != nil {
+							return err
+						}
+					}
+					if params.AutoScaling == nil {
+						return nil
+					}
+					return CreateServiceAutoScalingComponent(meta.Child("scaling"), *params.AutoScaling, provider, cluster, svc.Component, targetGroups, loadBalancers).
+						GetAndThen(ctx, func(scaling *pgocomp.GetComponentWithMetaResponse[*ServiceAutoScalingComponent]) error {
+							response.AutoScaling = scaling
+							return nil
+						})
 				})
 			})
 			return
@@ -425,9 +823,22 @@ func CreateEcsFargateServiceComponent(
 	})
 }
 
-// CreateECSCluster creates a new ECSCluster
-func CreateECSCluster(meta pgocomp.Meta, params ECSClusterParameters, provider *aws.Provider) *pgocomp.ComponentWithMeta[*ecs.Cluster] {
-	return awsc.NewCluster(meta, &ecs.ClusterArgs{}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+// CreateLogGroup creates the log group of a service
+func CreateLogGroup(meta pgocomp.Meta, logGroupName string, params LogParameters, provider *aws.Provider) *pgocomp.ComponentWithMeta[*cloudwatch.LogGroup] {
+	return awsc.NewLogGroup(meta, &cloudwatch.LogGroupArgs{
+		Name:            pulumi.String(logGroupName),
+		RetentionInDays: optionalInt(params.RetentionInDays),
+		KmsKeyId:        optionalString(params.KmsKeyArn),
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateECSCluster creates a new ECSCluster. The namespace, when there is one, is the default namespace of its Service Connect services
+func CreateECSCluster(meta pgocomp.Meta, params ECSClusterParameters, provider *aws.Provider, namespace *servicediscovery.PrivateDnsNamespace) *pgocomp.ComponentWithMeta[*ecs.Cluster] {
+	args := &ecs.ClusterArgs{}
+	if namespace != nil {
+		args.ServiceConnectDefaults = &ecs.ClusterServiceConnectDefaultsArgs{Namespace: namespace.Arn}
+	}
+	return awsc.NewCluster(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
 }
 
 // CreateProvider takes a name and a region and returns an aws.Provider Component
@@ -448,31 +859,40 @@ func CreateVPC(meta pgocomp.Meta, params VpcParameters, provider *aws.Provider)
 }
 
 // CreateListener creates a new Listener Component
-func CreateListener(meta pgocomp.Meta, params LBListenerParameters, provider *aws.Provider, loadBalancer *lb.LoadBalancer, tgs map[string]*lb.TargetGroup, sg *ec2.SecurityGroup, certs map[string]*acm.Certificate) *pgocomp.ComponentWithMeta[*lb.Listener] {
+func CreateListener(meta pgocomp.Meta, params LBListenerParameters, provider *aws.Provider, loadBalancer *lb.LoadBalancer, tgs map[string]*lb.TargetGroup, sg *ec2.SecurityGroup, certs map[string]*CertificateComponent) *pgocomp.ComponentWithMeta[*lb.Listener] {
 
 	return pgocomp.NewComponentWithMeta[*lb.Listener](meta, func(ctx *pulumi.Context, name string) (response *lb.Listener, err error) {
-		tg, ok := tgs[params.TargetGroupLookupName]
-		if !ok {
-			return nil, fmt.Errorf("Target group Lookup Name %s not found", params.TargetGroupLookupName)
-		}
-
+		//The listeners of gateway load balancers have no port or protocol
 		args := &lb.ListenerArgs{
-			Port:            pulumi.Int(params.Port),
+			Port:            optionalInt(params.Port),
 			LoadBalancerArn: loadBalancer.ID(),
-			Protocol:        pulumi.String(params.Protocol),
-			DefaultActions: lb.ListenerDefaultActionArray{
-				lb.ListenerDefaultActionArgs{
-					TargetGroupArn: tg.ID(),
-					Type:           pulumi.String("forward"),
-				},
-			},
+			Protocol:        optionalString(string(params.Protocol)),
+		}
+		if args.DefaultActions, err = ListenerDefaultActions(params.actions(), tgs); err != nil {
+			return nil, err
 		}
 		if cert, ok := certs[params.CertificateLookupName]; ok {
-			args.CertificateArn = cert.ID()
+			args.CertificateArn = cert.Arn()
+		}
+		if params.Protocol == HTTPS || params.Protocol == TLS {
+			args.SslPolicy = pulumi.String(valueOrDefault(params.SslPolicy, DefaultSslPolicy))
+		}
+		if params.Protocol == TLS {
+			args.AlpnPolicy = optionalString(string(params.AlpnPolicy))
 		}
-		err = awsc.NewListener(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(l *pgocomp.GetComponentWithMetaResponse[*lb.Listener]) (err error) {
+		err = awsc.NewListener(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect), pulumi.IgnoreChanges(params.ignoredChanges())).GetAndThen(ctx, func(l *pgocomp.GetComponentWithMetaResponse[*lb.Listener]) (err error) {
 			response = l.Component
+			for _, lookupName := range params.SniCertificateLookupNames {
+				cert, ok := certs[lookupName]
+				if !ok {
+					return fmt.Errorf("Certificate Lookup Name %s not found", lookupName)
+				}
+				if err = CreateListenerCertificate(meta.Child("cert-"+lookupName), provider, l.Component, cert).Apply(ctx); err != nil {
+					return
+				}
+			}
 			for _, rule := range params.Rules {
+				rule.Meta = rule.Meta.Inherit(&meta)
 				var conditions lb.ListenerRuleConditionArray
 				for _, condition := range rule.Conditions {
 					switch condition.RuleConditionType {
@@ -535,17 +955,12 @@ func CreateListener(meta pgocomp.Meta, params LBListenerParameters, provider *aw
 						})
 					}
 				}
-				rtg, ok := tgs[rule.TargetGroupLookupName]
-				if !ok {
-					return fmt.Errorf("Target group Lookup Name %s not found", rule.TargetGroupLookupName)
+				var actions lb.ListenerRuleActionArray
+				if actions, err = ListenerRuleActions(rule.actions(), tgs); err != nil {
+					return
 				}
 				err = awsc.NewListenerRule(rule.Meta, &lb.ListenerRuleArgs{
-					Actions: lb.ListenerRuleActionArray{
-						lb.ListenerRuleActionArgs{
-							TargetGroupArn: rtg.ID(),
-							Type:           pulumi.String("forward"),
-						},
-					},
+					Actions:     actions,
 					ListenerArn: l.Component.ID(),
 					Conditions:  conditions,
 					Priority:    pulumi.Int(rule.Priority),
@@ -560,24 +975,97 @@ func CreateListener(meta pgocomp.Meta, params LBListenerParameters, provider *aw
 
 }
 
-// CreateTargetGroup creates a new LoadBalancer Component
-func CreateTargetGroup(meta pgocomp.Meta, params LBTargetGroupParameters, provider *aws.Provider, vpc *ec2.Vpc) *pgocomp.ComponentWithMeta[*lb.TargetGroup] {
-	return awsc.NewTargetGroup(meta, &lb.TargetGroupArgs{
-		Port:       pulumi.Int(params.Port),
-		VpcId:      vpc.ID(),
-		Protocol:   pulumi.String(params.Protocol),
-		TargetType: pulumi.String("ip"),
-		HealthCheck: lb.TargetGroupHealthCheckArgs{
-			Enabled:            pulumi.Bool(!params.Inactive),
-			HealthyThreshold:   pulumi.Int(valueOrDefault(params.HealthCheck.HealthyThreshold, 3)),
-			Path:               pulumi.String(valueOrDefault(params.HealthCheck.Path, "/")),
-			UnhealthyThreshold: pulumi.Int(valueOrDefault(params.HealthCheck.UnhealthyThreshold, 5)),
-			Timeout:            pulumi.Int(valueOrDefault(params.HealthCheck.Timeout, 6)),
-			Matcher:            pulumi.String(valueOrDefault(params.HealthCheck.StatusCodeRange, "200-399")),
-		},
+// CreateListenerCertificate attaches an extra certificate to a listener, served by SNI
+func CreateListenerCertificate(meta pgocomp.Meta, provider *aws.Provider, listener *lb.Listener, cert *CertificateComponent) *pgocomp.ComponentWithMeta[*lb.ListenerCertificate] {
+	return awsc.NewListenerCertificate(meta, &lb.ListenerCertificateArgs{
+		ListenerArn:    listener.ID(),
+		CertificateArn: cert.Arn(),
 	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
 }
 
+// CreateTargetGroup creates a new LoadBalancer Component. Lambda target groups have no port, protocol or vpc
+func CreateTargetGroup(meta pgocomp.Meta, params LBTargetGroupParameters, provider *aws.Provider, vpc *ec2.Vpc) *pgocomp.ComponentWithMeta[*lb.TargetGroup] {
+	args := &lb.TargetGroupArgs{
+		TargetType:                 pulumi.String(valueOrDefault(params.TargetType, TGIp)),
+		HealthCheck:                targetGroupHealthCheck(params),
+		ProtocolVersion:            optionalString(string(params.ProtocolVersion)),
+		DeregistrationDelay:        optionalInt(params.DeregistrationDelay),
+		SlowStart:                  optionalInt(params.SlowStart),
+		LoadBalancingAlgorithmType: optionalString(string(params.Algorithm)),
+	}
+	if params.TargetType != TGLambda {
+		args.Port = pulumi.Int(params.Port)
+		args.VpcId = vpc.ID()
+		args.Protocol = pulumi.String(params.Protocol)
+	}
+	if params.Stickiness.Type != "" {
+		args.Stickiness = lb.TargetGroupStickinessArgs{
+			Enabled:        pulumi.Bool(true),
+			Type:           pulumi.String(params.Stickiness.Type),
+			CookieDuration: optionalInt(params.Stickiness.Duration),
+			CookieName:     optionalString(params.Stickiness.CookieName),
+		}
+	}
+	return awsc.NewTargetGroup(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateTargetGroupAttachment registers an application load balancer in an alb target group, once the load balancer listens on the port of the target group
+func CreateTargetGroupAttachment(meta pgocomp.Meta, params LBTargetGroupParameters, provider *aws.Provider, tg *lb.TargetGroup, loadBalancer *LoadBalancerComponent) *pgocomp.ComponentWithMeta[*lb.TargetGroupAttachment] {
+	var dependsOn []pulumi.Resource
+	for _, listener := range loadBalancer.Listeners {
+		dependsOn = append(dependsOn, listener.Component)
+	}
+	return awsc.NewTargetGroupAttachment(meta, &lb.TargetGroupAttachmentArgs{
+		TargetGroupArn: tg.Arn,
+		TargetId:       loadBalancer.LoadBalancer.Component.Arn,
+		Port:           pulumi.Int(params.Port),
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect), pulumi.DependsOn(dependsOn))
+}
+
+// targetGroupHealthCheck returns the health check of a target group. The path and the status codes are only sent to HTTP and HTTPS checks,
+// and default to the health check service of gRPC for GRPC target groups
+func targetGroupHealthCheck(params LBTargetGroupParameters) lb.TargetGroupHealthCheckArgs {
+	hc := params.HealthCheck
+	args := lb.TargetGroupHealthCheckArgs{
+		Enabled:            pulumi.Bool(!hc.Disabled),
+		HealthyThreshold:   pulumi.Int(valueOrDefault(hc.HealthyThreshold, 3)),
+		UnhealthyThreshold: pulumi.Int(valueOrDefault(hc.UnhealthyThreshold, 5)),
+		Timeout:            pulumi.Int(valueOrDefault(hc.Timeout, 6)),
+		Interval:           optionalInt(hc.Interval),
+		Port:               optionalString(hc.Port),
+		Protocol:           optionalString(string(hc.Protocol)),
+	}
+	if params.healthCheckProtocol() == TGProtoTCP {
+		return args
+	}
+	path, matcher := "/", "200-399"
+	if params.ProtocolVersion == TGGRPC {
+		path, matcher = "/AWS.ALB/healthcheck", "12"
+	}
+	args.Path = pulumi.String(valueOrDefault(hc.Path, path))
+	args.Matcher = pulumi.String(valueOrDefault(hc.StatusCodeRange, matcher))
+	return args
+}
+
+// isHTTP tells if the target group balances HTTP or HTTPS requests, behind an application load balancer
+func (p *LBTargetGroupParameters) isHTTP() bool {
+	return p.Protocol == TGProtoHTTP || p.Protocol == TGProtoHTTPS
+}
+
+// healthCheckProtocol returns the protocol of the health check of the target group. Lambda targets are checked with HTTP
+func (p *LBTargetGroupParameters) healthCheckProtocol() TGProtocol {
+	switch {
+	case p.HealthCheck.Protocol != "":
+		return p.HealthCheck.Protocol
+	case p.isHTTP():
+		return p.Protocol
+	case p.TargetType == TGLambda || p.TargetType == TGAlb:
+		return TGProtoHTTP
+	default:
+		return TGProtoTCP
+	}
+}
+
 // CreateLoadBalancerAndAssociateToSubnets creates a new LoadBalancer Component
 func CreateLoadBalancerAndAssociateToSubnets(meta pgocomp.Meta, lbType LBType, provider *aws.Provider, subnets []*ec2.Subnet, sg *ec2.SecurityGroup) *pgocomp.ComponentWithMeta[*lb.LoadBalancer] {
 	var dependsOn []pulumi.Resource
@@ -653,7 +1141,7 @@ func CreateSubnetAndAssociateToRoute(meta pgocomp.Meta, params SubnetParameters,
 		var subnet *ec2.Subnet
 		err := CreateSubnet(meta, params, az, provider, vpc, rt).GetAndThen(ctx, func(s *pgocomp.GetComponentWithMetaResponse[*ec2.Subnet]) error {
 			subnet = s.Component
-			return AssociateRouteTableToSubnet(pgocomp.Meta{Name: meta.Name + "-route-association"}, provider, subnet, rt).Apply(ctx)
+			return AssociateRouteTableToSubnet(meta.Child("route-association"), provider, subnet, rt).Apply(ctx)
 		})
 		return subnet, err
 	})
@@ -726,6 +1214,47 @@ func CreateAndAttachDefaultRoute(meta pgocomp.Meta, provider *aws.Provider, rt *
 	)
 }
 
+// CreateElasticIP takes a meta and an internet gateway attachment, if any, and returns an Eip Component in the Vpc domain
+func CreateElasticIP(meta pgocomp.Meta, provider *aws.Provider, iga *ec2.InternetGatewayAttachment) *pgocomp.ComponentWithMeta[*ec2.Eip] {
+	opts := []pulumi.ResourceOption{pulumi.Provider(provider), pulumi.Protect(meta.Protect)}
+	if iga != nil {
+		opts = append(opts, pulumi.DependsOn([]pulumi.Resource{iga}))
+	}
+	return awsc.NewEip(
+		meta,
+		&ec2.EipArgs{
+			Vpc: pulumi.Bool(true),
+		},
+		opts...,
+	)
+}
+
+// CreateNatGateway takes a meta, an elastic ip and a public subnet and returns a NatGateway Component
+func CreateNatGateway(meta pgocomp.Meta, provider *aws.Provider, eip *ec2.Eip, subnet *ec2.Subnet, iga *ec2.InternetGatewayAttachment) *pgocomp.ComponentWithMeta[*ec2.NatGateway] {
+	return awsc.NewNatGateway(
+		meta,
+		&ec2.NatGatewayArgs{
+			AllocationId: eip.ID(),
+			SubnetId:     subnet.ID(),
+		},
+		pulumi.Provider(provider), pulumi.Protect(meta.Protect),
+		pulumi.DependsOn([]pulumi.Resource{iga}),
+	)
+}
+
+// CreateAndAttachNatRoute takes a meta, a route table and a NAT gateway and returns the default Route Component of the route table
+func CreateAndAttachNatRoute(meta pgocomp.Meta, provider *aws.Provider, rt *ec2.RouteTable, nat *ec2.NatGateway) *pgocomp.ComponentWithMeta[*ec2.Route] {
+	return awsc.NewRoute(
+		meta,
+		&ec2.RouteArgs{
+			RouteTableId:         rt.ID(),
+			DestinationCidrBlock: pulumi.String("0.0.0.0/0"),
+			NatGatewayId:         nat.ID(),
+		},
+		pulumi.Provider(provider), pulumi.Protect(meta.Protect),
+	)
+}
+
 // AssociateRouteTableToSubnet associates a subnet to a route table
 func AssociateRouteTableToSubnet(meta pgocomp.Meta, provider *aws.Provider, subnet *ec2.Subnet, routeTable *ec2.RouteTable) *pgocomp.ComponentWithMeta[*ec2.RouteTableAssociation] {
 	return awsc.NewRouteTableAssociation(meta, &ec2.RouteTableAssociationArgs{
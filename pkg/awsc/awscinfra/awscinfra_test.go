@@ -0,0 +1,688 @@
+package awscinfra
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fpco-internal/pgocomp/pgotest"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// sampleInfra is a representative Vpc: a public partition with an application load balancer in front of a fargate
+// service, a cluster with an EC2 capacity provider, and a private partition that reaches the internet through a NAT gateway
+func sampleInfra() InfraParameters {
+	p := validInfra()
+	p.Tags = map[string]string{"team": "infra", "env": "dev"}
+	vpc(&p).Tags = map[string]string{"env": "prod"}
+	vpc(&p).NatMode = NatSingle
+	service(&p).Tags = map[string]string{"app": "web"}
+	privatePartition(&p)
+	partition(&p).ECSClusters = append(partition(&p).ECSClusters, ECSClusterParameters{
+		Meta: meta("instances"),
+		CapacityProviders: []CapacityProviderParameters{{
+			Meta: meta("workers"),
+			Type: EC2Capacity,
+			EC2:  EC2CapacityParameters{InstanceTypes: []string{"m6i.large"}, MaxSize: 2},
+		}},
+	})
+	return p
+}
+
+func TestInfra(t *testing.T) {
+	result, err := pgotest.Run(New(sampleInfra()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for typ, count := range map[string]int{
+		"aws:ec2/vpc:Vpc":                           1,
+		"aws:ec2/subnet:Subnet":                     3,
+		"aws:ec2/natGateway:NatGateway":             1,
+		"aws:lb/loadBalancer:LoadBalancer":          1,
+		"aws:lb/listener:Listener":                  1,
+		"aws:lb/listenerRule:ListenerRule":          1,
+		"aws:lb/targetGroup:TargetGroup":            1,
+		"aws:ecs/cluster:Cluster":                   2,
+		"aws:ecs/service:Service":                   1,
+		"aws:autoscaling/group:Group":               1,
+		"aws:ecs/capacityProvider:CapacityProvider": 1,
+	} {
+		result.AssertCount(t, typ, count)
+	}
+	result.AssertAllTagged(t, "team", "infra")
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{"port": 80, "protocol": "HTTP"})
+	result.AssertExists(t, "aws:ecs/service:Service", pgotest.Props{"desiredCount": 1, "launchType": "FARGATE"})
+	//The instances of the group get the infra tags, which AWS does not copy from the default tags of the provider
+	result.AssertExists(t, "aws:autoscaling/group:Group", pgotest.Props{"tags": []pgotest.Props{
+		{"key": "AmazonECSManaged", "value": "true", "propagateAtLaunch": true},
+		{"key": "env", "value": "prod", "propagateAtLaunch": true},
+		{"key": "team", "value": "infra", "propagateAtLaunch": true},
+	}})
+	result.AssertGolden(t, "testdata/infra.golden")
+}
+
+func TestInfraTagsWin(t *testing.T) {
+	result, err := pgotest.Run(New(sampleInfra()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, ok := result.Find("aws:ecs/service:Service", "svc")
+	if !ok {
+		t.Fatal("service svc not found")
+	}
+	tags := result.Tags(service)
+	for key, value := range map[string]string{"team": "infra", "env": "prod", "app": "web"} {
+		if tags[key] != value {
+			t.Errorf("expected the service to be tagged %s=%s, got %v", key, value, tags)
+		}
+	}
+}
+
+// infraMocks are the default mocks, plus the outputs AWS computes and the features of awscinfra read: the names of the
+// resources, the DNS names of the load balancers, the endpoint services, the records of the hosted zones and the validation options of the certificates
+func infraMocks() *pgotest.Mocks {
+	mocks := pgotest.NewMocks()
+	mocks.Outputs = func(args pulumi.MockResourceArgs) resource.PropertyMap {
+		outputs := resource.PropertyMap{}
+		if _, ok := args.Inputs["name"]; !ok {
+			outputs["name"] = resource.NewStringProperty(args.Name)
+		}
+		switch args.TypeToken {
+		case "aws:lb/loadBalancer:LoadBalancer":
+			outputs["dnsName"] = resource.NewStringProperty(args.Name + ".elb.amazonaws.com")
+			outputs["zoneId"] = resource.NewStringProperty("ZELB")
+			outputs["arnSuffix"] = resource.NewStringProperty("app/" + args.Name)
+		case "aws:lb/targetGroup:TargetGroup":
+			outputs["arnSuffix"] = resource.NewStringProperty("targetgroup/" + args.Name)
+		case "aws:route53/zone:Zone":
+			outputs["zoneId"] = resource.NewStringProperty("Z" + args.Name)
+		case "aws:route53/record:Record":
+			if name, ok := args.Inputs["name"]; ok {
+				outputs["fqdn"] = name
+			}
+		case "aws:ec2/vpcEndpointService:VpcEndpointService":
+			outputs["serviceName"] = resource.NewStringProperty("com.amazonaws.vpce." + args.Name)
+		case "aws:ec2/eip:Eip":
+			outputs["allocationId"] = resource.NewStringProperty(args.Name + "-allocation")
+		case "aws:acm/certificate:Certificate":
+			domains := []resource.PropertyValue{args.Inputs["domainName"]}
+			if names, ok := args.Inputs["subjectAlternativeNames"]; ok {
+				domains = append(domains, names.ArrayValue()...)
+			}
+			var options []any
+			for _, domain := range domains {
+				options = append(options, map[string]any{
+					"domainName":          domain.StringValue(),
+					"resourceRecordName":  "_validation." + strings.TrimPrefix(domain.StringValue(), "*."),
+					"resourceRecordType":  "CNAME",
+					"resourceRecordValue": "_validation.acm-validations.aws",
+				})
+			}
+			outputs["domainValidationOptions"] = resource.NewPropertyValue(options)
+		}
+		return outputs
+	}
+	return mocks
+}
+
+// runInfra runs the infra under infraMocks
+func runInfra(t *testing.T, p InfraParameters) *pgotest.Result {
+	t.Helper()
+	result, err := pgotest.RunWithMocks(infraMocks(), New(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestHTTPSListeners(t *testing.T) {
+	p := validInfra()
+	https(&p).SniCertificateLookupNames = []string{"other"}
+	vpc(&p).Certificates = append(vpc(&p).Certificates, CertificateParameters{Meta: meta("other"), Domain: "other.com", ValidationMethod: ValidationByEmail})
+	loadBalancer(&p).Listeners = append(loadBalancer(&p).Listeners, LBListenerParameters{Meta: meta("redirect"), Port: 80, Protocol: HTTP, RedirectToHTTPS: true})
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{
+		"port":           443,
+		"protocol":       "HTTPS",
+		"sslPolicy":      DefaultSslPolicy,
+		"certificateArn": "arn:aws:mock:::aws:acm/certificate:Certificate/cert",
+	})
+	result.AssertExists(t, "aws:lb/listenerCertificate:ListenerCertificate", pgotest.Props{
+		"listenerArn":    "http_id",
+		"certificateArn": "arn:aws:mock:::aws:acm/certificate:Certificate/other",
+	})
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{"port": 80, "protocol": "HTTP", "defaultActions": []pgotest.Props{{
+		"type":     "redirect",
+		"redirect": pgotest.Props{"port": "443", "protocol": "HTTPS", "statusCode": "HTTP_301"},
+	}}})
+	for _, port := range []int{80, 443} {
+		result.AssertExists(t, "aws:ec2/securityGroupRule:SecurityGroupRule", pgotest.Props{"securityGroupId": "lb-sg_id", "fromPort": port, "toPort": port})
+	}
+}
+
+func TestListenerActionsWithoutClientSecret(t *testing.T) {
+	actions := []LBActionParameters{{Type: ActionAuthenticateOidc}, forwardTo("web")}
+	tgs := map[string]*lb.TargetGroup{"web": {}}
+	if _, err := ListenerDefaultActions(actions, tgs); err == nil {
+		t.Error("expected an error for the default actions")
+	}
+	if _, err := ListenerRuleActions(actions, tgs); err == nil {
+		t.Error("expected an error for the rule actions")
+	}
+}
+
+// TestListenerRuleActionFields fails when pulumi-aws adds a field listenerRuleAction does not convert
+func TestListenerRuleActionFields(t *testing.T) {
+	for typ, converted := range map[reflect.Type][]string{
+		reflect.TypeOf(lb.ListenerDefaultActionArgs{}): {
+			"Type", "Order", "TargetGroupArn", "Forward", "FixedResponse", "Redirect", "AuthenticateOidc", "AuthenticateCognito",
+		},
+		reflect.TypeOf(lb.ListenerDefaultActionForwardArgs{}): {"TargetGroups", "Stickiness"},
+	} {
+		fields := make(map[string]bool)
+		for _, name := range converted {
+			fields[name] = true
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if !fields[typ.Field(i).Name] {
+				t.Errorf("%s.%s is not converted to a rule action", typ.Name(), typ.Field(i).Name)
+			}
+		}
+	}
+}
+
+func TestListenerActions(t *testing.T) {
+	p := validInfra()
+	https(&p).TargetGroupLookupName = ""
+	listener(&p).Actions = []LBActionParameters{oidc(), forward("web")}
+	partition(&p).LBTargetGroups = append(partition(&p).LBTargetGroups, LBTargetGroupParameters{Meta: meta("canary"), Port: 80, Protocol: TGProtoHTTP, TargetType: TGIp})
+	rule(&p).TargetGroupLookupName = ""
+	rule(&p).Actions = []LBActionParameters{forward("web", "canary")}
+	rule(&p).Actions[0].Forward.StickinessDuration = 60
+	listener(&p).Rules = append(listener(&p).Rules, LBRuleParameters{
+		Meta:       meta("maintenance"),
+		Priority:   20,
+		Conditions: []LBRuleConditionParameters{{RuleConditionType: PathPattern, PathPatterns: []string{"/admin/*"}}},
+		Actions:    []LBActionParameters{{Type: ActionFixedResponse, FixedResponse: LBFixedResponseAction{StatusCode: 503, MessageBody: "maintenance"}}},
+	})
+	result := runInfra(t, p)
+	//The authenticate action comes first, and the client secret stays a secret
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{"defaultActions": []pgotest.Props{
+		{"order": 1, "type": "authenticate-oidc", "authenticateOidc": pgotest.Props{
+			"issuer":       "https://idp.example.com",
+			"clientId":     "client",
+			"clientSecret": "[secret]",
+		}},
+		{"order": 2, "type": "forward", "targetGroupArn": "web_id"},
+	}})
+	result.AssertExists(t, "aws:lb/listenerRule:ListenerRule", pgotest.Props{"priority": 10, "actions": []pgotest.Props{{
+		"type": "forward",
+		"forward": pgotest.Props{
+			"targetGroups": []pgotest.Props{{"arn": "web_id", "weight": 1}, {"arn": "canary_id", "weight": 1}},
+			"stickiness":   pgotest.Props{"enabled": true, "duration": 60},
+		},
+	}}})
+	result.AssertExists(t, "aws:lb/listenerRule:ListenerRule", pgotest.Props{"priority": 20, "actions": []pgotest.Props{{
+		"type":          "fixed-response",
+		"fixedResponse": pgotest.Props{"contentType": "text/plain", "messageBody": "maintenance", "statusCode": "503"},
+	}}})
+}
+
+func TestValidationDomains(t *testing.T) {
+	tests := []struct {
+		domain   string
+		names    []string
+		expected []string
+	}{
+		{"example.com", nil, []string{"example.com"}},
+		{"example.com", []string{"*.example.com"}, []string{"example.com"}},
+		{"*.example.com", []string{"example.com"}, []string{"example.com"}},
+		{"*.example.com", nil, []string{"*.example.com"}},
+		{"example.com", []string{"www.example.com", "*.api.example.com", "www.example.com"}, []string{"example.com", "www.example.com", "*.api.example.com"}},
+	}
+	for _, test := range tests {
+		domains := validationDomains(CertificateParameters{Domain: test.domain, SubjectAlternativeNames: test.names})
+		if !reflect.DeepEqual(domains, test.expected) {
+			t.Errorf("%s %v: expected %v, got %v", test.domain, test.names, test.expected, domains)
+		}
+	}
+}
+
+func TestCertificateValidation(t *testing.T) {
+	p := validInfra()
+	certificate(&p).SubjectAlternativeNames = []string{"*.example.com", "*.api.example.com"}
+	result := runInfra(t, p)
+	//The alias record of the zone, and the validation records: *.example.com shares the record of example.com
+	result.AssertCount(t, "aws:route53/record:Record", 3)
+	for _, domain := range []string{"example.com", "api.example.com"} {
+		result.AssertExists(t, "aws:route53/record:Record", pgotest.Props{
+			"zoneId":         "Zzone",
+			"name":           "_validation." + domain,
+			"type":           "CNAME",
+			"records":        []string{"_validation.acm-validations.aws"},
+			"allowOverwrite": true,
+		})
+	}
+	result.AssertExists(t, "aws:acm/certificateValidation:CertificateValidation", pgotest.Props{
+		"certificateArn":        "arn:aws:mock:::aws:acm/certificate:Certificate/cert",
+		"validationRecordFqdns": []string{"_validation.example.com", "_validation.api.example.com"},
+	})
+}
+
+func TestAliasRecords(t *testing.T) {
+	p := validInfra()
+	hostedZone := zone(&p)
+	hostedZone.Private = true
+	record := &hostedZone.Records[0]
+	record.Types = []DNSRecordType{RecordA, RecordAAAA}
+	record.EvaluateTargetHealth = true
+	record.RoutingPolicy = WeightedRouting
+	record.SetIdentifier = "blue"
+	record.Weight = 10
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:route53/zone:Zone", pgotest.Props{"name": "example.com", "vpcs": []pgotest.Props{{"vpcId": "vpc_id"}}})
+	for _, typ := range []string{"A", "AAAA"} {
+		result.AssertExists(t, "aws:route53/record:Record", pgotest.Props{
+			"zoneId":                  "Zzone",
+			"name":                    "api.example.com",
+			"type":                    typ,
+			"aliases":                 []pgotest.Props{{"name": "lb.elb.amazonaws.com", "zoneId": "ZELB", "evaluateTargetHealth": true}},
+			"setIdentifier":           "blue",
+			"weightedRoutingPolicies": []pgotest.Props{{"weight": 10}},
+		})
+	}
+	//The A record keeps the name of its parameters
+	if _, ok := result.Find("aws:route53/record:Record", "api"); !ok {
+		t.Error("record api not found")
+	}
+}
+
+func TestTargetGroups(t *testing.T) {
+	p := validInfra()
+	tg := targetGroup(&p)
+	tg.ProtocolVersion = TGGRPC
+	tg.DeregistrationDelay = 30
+	tg.SlowStart = 60
+	tg.Algorithm = RoundRobin
+	tg.Stickiness = LBTargetGroupStickiness{Type: StickyLBCookie, Duration: 3600}
+	partition(&p).LBTargetGroups = append(partition(&p).LBTargetGroups, LBTargetGroupParameters{Meta: meta("fn"), TargetType: TGLambda})
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:lb/targetGroup:TargetGroup", pgotest.Props{
+		"port":                       80,
+		"protocol":                   "HTTP",
+		"vpcId":                      "vpc_id",
+		"targetType":                 "ip",
+		"protocolVersion":            "GRPC",
+		"deregistrationDelay":        30,
+		"slowStart":                  60,
+		"loadBalancingAlgorithmType": "round_robin",
+		"stickiness":                 pgotest.Props{"enabled": true, "type": "lb_cookie", "cookieDuration": 3600},
+		"healthCheck":                pgotest.Props{"path": "/AWS.ALB/healthcheck", "matcher": "12"},
+	})
+	fn, ok := result.Find("aws:lb/targetGroup:TargetGroup", "fn")
+	if !ok {
+		t.Fatal("target group fn not found")
+	}
+	if fn.Inputs["targetType"] != "lambda" {
+		t.Errorf("expected a lambda target group, got %v", fn.Inputs["targetType"])
+	}
+	for _, input := range []string{"port", "protocol", "vpcId"} {
+		if value, ok := fn.Inputs[input]; ok {
+			t.Errorf("expected the lambda target group to have no %s, got %v", input, value)
+		}
+	}
+}
+
+func TestNetworkLoadBalancer(t *testing.T) {
+	p := validInfra()
+	loadBalancer(&p).Type = Network
+	loadBalancer(&p).CrossZone = true
+	loadBalancer(&p).ElasticIPs = true
+	listener(&p).Protocol = TCP
+	listener(&p).Rules = nil
+	certificate(&p)
+	loadBalancer(&p).Listeners = append(loadBalancer(&p).Listeners, LBListenerParameters{
+		Meta: meta("tls"), Port: 443, Protocol: TLS, TargetGroupLookupName: "web", CertificateLookupName: "cert", AlpnPolicy: AlpnHTTP2Preferred,
+	})
+	targetGroup(&p).Protocol = TGProtoTCP
+	result := runInfra(t, p)
+	result.AssertCount(t, "aws:ec2/eip:Eip", 2)
+	result.AssertExists(t, "aws:lb/loadBalancer:LoadBalancer", pgotest.Props{
+		"loadBalancerType":             "network",
+		"enableCrossZoneLoadBalancing": true,
+		"subnetMappings": []pgotest.Props{
+			{"subnetId": "a_id", "allocationId": "lb-eip-a-allocation"},
+			{"subnetId": "b_id", "allocationId": "lb-eip-b-allocation"},
+		},
+	})
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{"port": 80, "protocol": "TCP"})
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{
+		"port":           443,
+		"protocol":       "TLS",
+		"sslPolicy":      DefaultSslPolicy,
+		"alpnPolicy":     "HTTP2Preferred",
+		"certificateArn": "arn:aws:mock:::aws:acm/certificate:Certificate/cert",
+	})
+	//TCP target groups are checked with TCP, without path or status codes
+	result.AssertExists(t, "aws:lb/targetGroup:TargetGroup", pgotest.Props{"protocol": "TCP"})
+	result.AssertNotExists(t, "aws:lb/targetGroup:TargetGroup", pgotest.Props{"healthCheck": pgotest.Props{"path": "/"}})
+}
+
+func TestGatewayEndpoints(t *testing.T) {
+	p := validInfra()
+	gatewayEndpoint(&p)
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:lb/loadBalancer:LoadBalancer", pgotest.Props{"loadBalancerType": "gateway", "subnets": []string{"a_id", "b_id"}})
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{"loadBalancerArn": "gwlb_id", "defaultActions": []pgotest.Props{{"type": "forward", "targetGroupArn": "appliances_id"}}})
+	result.AssertExists(t, "aws:lb/targetGroup:TargetGroup", pgotest.Props{"port": 6081, "protocol": "GENEVE"})
+	result.AssertExists(t, "aws:ec2/vpcEndpointService:VpcEndpointService", pgotest.Props{
+		"acceptanceRequired":      false,
+		"gatewayLoadBalancerArns": []string{"arn:aws:mock:::aws:lb/loadBalancer:LoadBalancer/gwlb"},
+	})
+	result.AssertExists(t, "aws:ec2/vpcEndpoint:VpcEndpoint", pgotest.Props{
+		"vpcEndpointType": "GatewayLoadBalancer",
+		"serviceName":     "com.amazonaws.vpce.gwlb-endpoint-service",
+		"subnetIds":       []string{"a_id"},
+	})
+	//The route goes in the route table of the private partition
+	result.AssertExists(t, "aws:ec2/route:Route", pgotest.Props{
+		"routeTableId":         "private-routetable_id",
+		"destinationCidrBlock": "10.1.0.0/16",
+		"vpcEndpointId":        "inspection_id",
+	})
+}
+
+func TestTaskRole(t *testing.T) {
+	p := validInfra()
+	service(&p).TaskRole = TaskRoleParameters{
+		Statements:        []PolicyStatement{{Actions: []string{"s3:GetObject"}, Resources: []string{"arn:aws:s3:::bucket/*"}}},
+		ManagedPolicyArns: []string{"arn:aws:iam::aws:policy/AmazonSQSReadOnlyAccess"},
+	}
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:iam/role:Role", pgotest.Props{
+		"assumeRolePolicy": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+	})
+	result.AssertExists(t, "aws:iam/rolePolicy:RolePolicy", pgotest.Props{
+		"role":   "svc-task-role_id",
+		"policy": `{"Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Resource":["arn:aws:s3:::bucket/*"]}],"Version":"2012-10-17"}`,
+	})
+	result.AssertExists(t, "aws:iam/rolePolicyAttachment:RolePolicyAttachment", pgotest.Props{
+		"role":      "svc-task-role_id",
+		"policyArn": "arn:aws:iam::aws:policy/AmazonSQSReadOnlyAccess",
+	})
+	result.AssertExists(t, "aws:ecs/taskDefinition:TaskDefinition", pgotest.Props{"taskRoleArn": "arn:aws:mock:::aws:iam/role:Role/svc-task-role"})
+	//The task needs no execution role without logs or secrets
+	result.AssertCount(t, "aws:iam/role:Role", 1)
+}
+
+// containerDefinitions returns the container definitions of the task definition of the service svc
+func containerDefinitions(t *testing.T, result *pgotest.Result) string {
+	t.Helper()
+	task, ok := result.Find("aws:ecs/taskDefinition:TaskDefinition", "svc-task")
+	if !ok {
+		t.Fatal("task definition svc-task not found")
+	}
+	definitions, _ := task.Inputs["containerDefinitions"].(string)
+	return definitions
+}
+
+func TestServiceLogs(t *testing.T) {
+	result := runInfra(t, validInfra())
+	result.AssertCount(t, "aws:cloudwatch/logGroup:LogGroup", 0)
+	if definitions := containerDefinitions(t, result); strings.Contains(definitions, "logConfiguration") {
+		t.Errorf("expected no log configuration without Logs.Enabled, got %s", definitions)
+	}
+
+	p := validInfra()
+	service(&p).Logs = LogParameters{Enabled: true, RetentionInDays: 7}
+	result = runInfra(t, p)
+	result.AssertExists(t, "aws:cloudwatch/logGroup:LogGroup", pgotest.Props{"name": "/ecs/svc", "retentionInDays": 7})
+	logConfiguration := `"logConfiguration":{"logDriver":"awslogs","options":{"awslogs-group":"/ecs/svc","awslogs-region":"eu-west-1","awslogs-stream-prefix":"svc"}}`
+	if definitions := containerDefinitions(t, result); !strings.Contains(definitions, logConfiguration) {
+		t.Errorf("expected the log configuration %s, got %s", logConfiguration, definitions)
+	}
+	result.AssertExists(t, "aws:ecs/taskDefinition:TaskDefinition", pgotest.Props{"executionRoleArn": "arn:aws:mock:::aws:iam/role:Role/svc-execution-role"})
+	result.AssertExists(t, "aws:iam/rolePolicy:RolePolicy", pgotest.Props{
+		"role":   "svc-execution-role_id",
+		"policy": `{"Statement":[{"Action":["logs:CreateLogStream","logs:PutLogEvents"],"Effect":"Allow","Resource":["arn:aws:mock:::aws:cloudwatch/logGroup:LogGroup/svc-logs:*"]}],"Version":"2012-10-17"}`,
+	})
+}
+
+func TestContainerSecrets(t *testing.T) {
+	p := validInfra()
+	secret(&p)
+	container(&p).Secrets = append(container(&p).Secrets, ContainerSecret{
+		Name:      "API_KEY",
+		Source:    ParameterStore,
+		Arn:       "arn:aws:ssm:eu-west-1:123456789012:parameter/api-key",
+		KmsKeyArn: "arn:aws:kms:eu-west-1:123456789012:key/api",
+	})
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:secretsmanager/secretVersion:SecretVersion", pgotest.Props{
+		"secretId":     "svc-secret-app-db-password_id",
+		"secretString": "[secret]",
+	})
+	result.AssertCount(t, "aws:ssm/parameter:Parameter", 0)
+	secrets := `"secrets":[{"name":"DB_PASSWORD","valueFrom":"arn:aws:mock:::aws:secretsmanager/secret:Secret/svc-secret-app-db-password"},` +
+		`{"name":"API_KEY","valueFrom":"arn:aws:ssm:eu-west-1:123456789012:parameter/api-key"}]`
+	if definitions := containerDefinitions(t, result); !strings.Contains(definitions, secrets) {
+		t.Errorf("expected the secrets %s, got %s", secrets, definitions)
+	}
+	//The execution role reads the secrets, and decrypts them with their key
+	result.AssertExists(t, "aws:iam/rolePolicy:RolePolicy", pgotest.Props{
+		"role": "svc-execution-role_id",
+		"policy": `{"Statement":[` +
+			`{"Action":["secretsmanager:GetSecretValue"],"Effect":"Allow","Resource":["arn:aws:mock:::aws:secretsmanager/secret:Secret/svc-secret-app-db-password"]},` +
+			`{"Action":["ssm:GetParameters"],"Effect":"Allow","Resource":["arn:aws:ssm:eu-west-1:123456789012:parameter/api-key"]},` +
+			`{"Action":["kms:Decrypt"],"Effect":"Allow","Resource":["arn:aws:kms:eu-west-1:123456789012:key/api"]}` +
+			`],"Version":"2012-10-17"}`,
+	})
+}
+
+func TestServiceAutoScaling(t *testing.T) {
+	p := validInfra()
+	stepScaling(&p)
+	scaling := service(&p).AutoScaling
+	scaling.TargetTracking = []TargetTrackingScalingParameters{
+		{Name: "cpu", Metric: ScaleOnCPU, TargetValue: 60, ScaleInCooldown: 300},
+		{Name: "requests", Metric: ScaleOnRequestCount, TargetValue: 100, TargetGroupLookupName: "web"},
+	}
+	scaling.ScheduledActions = []ScheduledScalingParameters{{Name: "night", Schedule: "cron(0 20 * * ? *)", MinCapacity: 1, MaxCapacity: 1}}
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:appautoscaling/target:Target", pgotest.Props{"resourceId": "service/cluster/svc", "minCapacity": 1, "maxCapacity": 4})
+	result.AssertExists(t, "aws:appautoscaling/policy:Policy", pgotest.Props{
+		"resourceId": "service/cluster/svc",
+		"policyType": "TargetTrackingScaling",
+		"targetTrackingScalingPolicyConfiguration": pgotest.Props{
+			"targetValue":                   60,
+			"scaleInCooldown":               300,
+			"predefinedMetricSpecification": pgotest.Props{"predefinedMetricType": "ECSServiceAverageCPUUtilization"},
+		},
+	})
+	result.AssertExists(t, "aws:appautoscaling/policy:Policy", pgotest.Props{
+		"policyType": "TargetTrackingScaling",
+		"targetTrackingScalingPolicyConfiguration": pgotest.Props{"predefinedMetricSpecification": pgotest.Props{
+			"predefinedMetricType": "ALBRequestCountPerTarget",
+			"resourceLabel":        "app/lb/targetgroup/web",
+		}},
+	})
+	result.AssertExists(t, "aws:appautoscaling/policy:Policy", pgotest.Props{
+		"policyType": "StepScaling",
+		"stepScalingPolicyConfiguration": pgotest.Props{
+			"adjustmentType":  "ChangeInCapacity",
+			"stepAdjustments": []pgotest.Props{{"metricIntervalLowerBound": "0", "scalingAdjustment": 1}},
+		},
+	})
+	//The alarm of the step scaling policy applies it
+	result.AssertExists(t, "aws:cloudwatch/metricAlarm:MetricAlarm", pgotest.Props{
+		"namespace":    "AWS/SQS",
+		"metricName":   "ApproximateNumberOfMessagesVisible",
+		"threshold":    100,
+		"alarmActions": []string{"arn:aws:mock:::aws:appautoscaling/policy:Policy/svc-scaling-queue"},
+	})
+	result.AssertExists(t, "aws:appautoscaling/scheduledAction:ScheduledAction", pgotest.Props{
+		"resourceId":           "service/cluster/svc",
+		"scalableDimension":    "ecs:service:DesiredCount",
+		"serviceNamespace":     "ecs",
+		"schedule":             "cron(0 20 * * ? *)",
+		"scalableTargetAction": pgotest.Props{"minCapacity": 1, "maxCapacity": 1},
+	})
+}
+
+func TestCapacityProviders(t *testing.T) {
+	p := validInfra()
+	cluster(&p).CapacityProviders = []CapacityProviderParameters{{Meta: meta("spot"), Type: FargateSpotCapacity}, {Meta: meta("fargate"), Type: FargateCapacity}}
+	service(&p).CapacityProviderStrategy = []CapacityProviderStrategyItem{
+		{CapacityProviderLookupName: "spot", Weight: 3},
+		{CapacityProviderLookupName: "fargate", Weight: 1, Base: 1},
+	}
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:ecs/clusterCapacityProviders:ClusterCapacityProviders", pgotest.Props{
+		"clusterName":       "cluster",
+		"capacityProviders": []string{"FARGATE_SPOT", "FARGATE"},
+	})
+	result.AssertExists(t, "aws:ecs/service:Service", pgotest.Props{"capacityProviderStrategies": []pgotest.Props{
+		{"capacityProvider": "FARGATE_SPOT", "weight": 3, "base": 0},
+		{"capacityProvider": "FARGATE", "weight": 1, "base": 1},
+	}})
+	//A service with a strategy has no launch type
+	result.AssertNotExists(t, "aws:ecs/service:Service", pgotest.Props{"launchType": "FARGATE"})
+
+	p = validInfra()
+	ec2Capacity(&p)
+	result = runInfra(t, p)
+	//The instances of a public partition need a public ip address to reach the internet gateway
+	result.AssertExists(t, "aws:ec2/launchTemplate:LaunchTemplate", pgotest.Props{
+		"instanceType":       "m6i.large",
+		"iamInstanceProfile": pgotest.Props{"arn": "arn:aws:mock:::aws:iam/instanceProfile:InstanceProfile/instances-instance-profile"},
+		"networkInterfaces": []pgotest.Props{{
+			"deviceIndex":              0,
+			"associatePublicIpAddress": "true",
+			"securityGroups":           []string{"instances-sg_id"},
+		}},
+	})
+	result.AssertNotExists(t, "aws:ec2/launchTemplate:LaunchTemplate", pgotest.Props{"vpcSecurityGroupIds": []string{"instances-sg_id"}})
+	result.AssertExists(t, "aws:iam/rolePolicyAttachment:RolePolicyAttachment", pgotest.Props{
+		"role":      "instances-instance-role_id",
+		"policyArn": "arn:aws:iam::aws:policy/service-role/AmazonEC2ContainerServiceforEC2Role",
+	})
+	result.AssertExists(t, "aws:autoscaling/group:Group", pgotest.Props{"minSize": 0, "maxSize": 2, "vpcZoneIdentifiers": []string{"a_id", "b_id"}})
+	result.AssertExists(t, "aws:ecs/capacityProvider:CapacityProvider", pgotest.Props{"autoScalingGroupProvider": pgotest.Props{
+		"autoScalingGroupArn": "arn:aws:mock:::aws:autoscaling/group:Group/instances-asg",
+		"managedScaling":      pgotest.Props{"status": "ENABLED", "targetCapacity": 100},
+	}})
+	result.AssertExists(t, "aws:ecs/clusterCapacityProviders:ClusterCapacityProviders", pgotest.Props{"capacityProviders": []string{"instances"}})
+	result.AssertExists(t, "aws:ecs/service:Service", pgotest.Props{"capacityProviderStrategies": []pgotest.Props{{"capacityProvider": "instances", "weight": 1}}})
+	result.AssertExists(t, "aws:ecs/taskDefinition:TaskDefinition", pgotest.Props{"requiresCompatibilities": []string{"EC2"}})
+
+	p = validInfra()
+	ec2Capacity(&p)
+	partition(&p).IsPublic = false
+	result = runInfra(t, p)
+	result.AssertExists(t, "aws:ec2/launchTemplate:LaunchTemplate", pgotest.Props{"vpcSecurityGroupIds": []string{"instances-sg_id"}})
+	result.AssertNotExists(t, "aws:ec2/launchTemplate:LaunchTemplate", pgotest.Props{"networkInterfaces": []pgotest.Props{{"associatePublicIpAddress": "true"}}})
+}
+
+func TestServiceDiscovery(t *testing.T) {
+	p := validInfra()
+	namespace(&p)
+	container(&p).PortMappings[0].Name = "http"
+	container(&p).PortMappings[0].AppProtocol = AppHTTP
+	container(&p).PortMappings = append(container(&p).PortMappings, ContainerPortMapping{Name: "grpc", ContainerPort: 9090, AppProtocol: AppGRPC})
+	service(&p).ServiceDiscovery = &ServiceDiscoveryParameters{RecordTypes: []DNSRecordType{RecordA, RecordSRV}, PortName: "http"}
+	service(&p).ServiceConnect = &ServiceConnectParameters{Services: []ServiceConnectService{
+		{PortName: "http", DiscoveryName: "web", ClientPort: 8080},
+		{PortName: "grpc"},
+	}}
+	result := runInfra(t, p)
+	namespaceArn := "arn:aws:mock:::aws:servicediscovery/privateDnsNamespace:PrivateDnsNamespace/ns"
+	result.AssertExists(t, "aws:servicediscovery/privateDnsNamespace:PrivateDnsNamespace", pgotest.Props{"name": "internal.local", "vpc": "vpc_id"})
+	result.AssertExists(t, "aws:ecs/cluster:Cluster", pgotest.Props{"serviceConnectDefaults": pgotest.Props{"namespace": namespaceArn}})
+	result.AssertExists(t, "aws:servicediscovery/service:Service", pgotest.Props{
+		"name": "svc",
+		"dnsConfig": pgotest.Props{
+			"namespaceId":   "ns_id",
+			"routingPolicy": "MULTIVALUE",
+			"dnsRecords":    []pgotest.Props{{"type": "A", "ttl": 10}, {"type": "SRV", "ttl": 10}},
+		},
+	})
+	result.AssertExists(t, "aws:ecs/service:Service", pgotest.Props{
+		"serviceRegistries": pgotest.Props{
+			"registryArn":   "arn:aws:mock:::aws:servicediscovery/service:Service/svc-discovery",
+			"containerName": "app",
+			"containerPort": 80,
+		},
+		"serviceConnectConfiguration": pgotest.Props{
+			"enabled":   true,
+			"namespace": namespaceArn,
+			"services": []pgotest.Props{
+				{"portName": "http", "discoveryName": "web", "clientAlias": []pgotest.Props{{"port": 8080}}},
+				{"portName": "grpc", "clientAlias": []pgotest.Props{{"port": 9090}}},
+			},
+		},
+	})
+	portMapping := `"portMappings":[{"name":"http","containerPort":80,"hostPort":0,"protocol":"","appProtocol":"http"},` +
+		`{"name":"grpc","containerPort":9090,"hostPort":0,"protocol":"","appProtocol":"grpc"}]`
+	if definitions := containerDefinitions(t, result); !strings.Contains(definitions, portMapping) {
+		t.Errorf("expected the port mapping %s, got %s", portMapping, definitions)
+	}
+	//The other tasks of the Vpc reach the published port that no target group opens
+	result.AssertExists(t, "aws:ec2/securityGroupRule:SecurityGroupRule", pgotest.Props{
+		"securityGroupId": "svc-sg_id",
+		"fromPort":        9090,
+		"toPort":          9090,
+		"cidrBlocks":      []string{"10.0.0.0/16"},
+	})
+}
+
+func TestDeployments(t *testing.T) {
+	p := validInfra()
+	service(&p).Deployment = DeploymentParameters{
+		CircuitBreaker: true, Rollback: true, MinimumHealthyPercent: intPtr(50), MaximumPercent: 150, HealthCheckGracePeriod: 60,
+	}
+	result := runInfra(t, p)
+	result.AssertExists(t, "aws:ecs/service:Service", pgotest.Props{
+		"deploymentCircuitBreaker":        pgotest.Props{"enable": true, "rollback": true},
+		"deploymentMinimumHealthyPercent": 50,
+		"deploymentMaximumPercent":        150,
+		"healthCheckGracePeriodSeconds":   60,
+	})
+	result.AssertCount(t, "aws:codedeploy/deploymentGroup:DeploymentGroup", 0)
+
+	p = validInfra()
+	blueGreen(&p)
+	result = runInfra(t, p)
+	result.AssertExists(t, "aws:ecs/service:Service", pgotest.Props{"deploymentController": pgotest.Props{"type": "CODE_DEPLOY"}})
+	//The new tasks go in a copy of the target group, behind a test listener open to the Vpc
+	if _, ok := result.Find("aws:lb/targetGroup:TargetGroup", "web-green"); !ok {
+		t.Error("target group web-green not found")
+	}
+	result.AssertExists(t, "aws:lb/listener:Listener", pgotest.Props{
+		"loadBalancerArn": "lb_id",
+		"port":            8080,
+		"defaultActions":  []pgotest.Props{{"type": "forward", "targetGroupArn": "web-green_id"}},
+	})
+	result.AssertExists(t, "aws:ec2/securityGroupRule:SecurityGroupRule", pgotest.Props{"securityGroupId": "lb-sg_id", "fromPort": 8080, "cidrBlocks": []string{"10.0.0.0/16"}})
+	result.AssertExists(t, "aws:codedeploy/application:Application", pgotest.Props{"computePlatform": "ECS"})
+	result.AssertExists(t, "aws:iam/rolePolicyAttachment:RolePolicyAttachment", pgotest.Props{
+		"role":      "svc-codedeploy-role_id",
+		"policyArn": "arn:aws:iam::aws:policy/AWSCodeDeployRoleForECS",
+	})
+	result.AssertExists(t, "aws:codedeploy/deploymentGroup:DeploymentGroup", pgotest.Props{
+		"appName":              "svc-codedeploy",
+		"deploymentConfigName": DefaultDeploymentConfig,
+		"serviceRoleArn":       "arn:aws:mock:::aws:iam/role:Role/svc-codedeploy-role",
+		"deploymentStyle":      pgotest.Props{"deploymentOption": "WITH_TRAFFIC_CONTROL", "deploymentType": "BLUE_GREEN"},
+		"ecsService":           pgotest.Props{"clusterName": "cluster", "serviceName": "svc"},
+		"blueGreenDeploymentConfig": pgotest.Props{
+			"terminateBlueInstancesOnDeploymentSuccess": pgotest.Props{"action": "TERMINATE", "terminationWaitTimeInMinutes": 5},
+		},
+		"loadBalancerInfo": pgotest.Props{"targetGroupPairInfo": pgotest.Props{
+			"prodTrafficRoute": pgotest.Props{"listenerArns": []string{"arn:aws:mock:::aws:lb/listener:Listener/http"}},
+			"testTrafficRoute": pgotest.Props{"listenerArns": []string{"arn:aws:mock:::aws:lb/listener:Listener/http-test"}},
+			"targetGroups":     []pgotest.Props{{"name": "web"}, {"name": "web-green"}},
+		}},
+	})
+}
@@ -0,0 +1,254 @@
+package awscinfra
+
+import (
+	"encoding/base64"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/autoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ecsInstancePolicyArn is the managed policy that lets the ECS agent of an instance join its cluster
+const ecsInstancePolicyArn = "arn:aws:iam::aws:policy/service-role/AmazonEC2ContainerServiceforEC2Role"
+
+// ClusterCapacity are the capacity providers of a cluster that its services can use, by lookup name
+type ClusterCapacity struct {
+	Names map[string]pulumi.StringInput
+	Types map[string]CapacityProviderType
+	//Association associates the capacity providers with the cluster. The services wait for it
+	Association *ecs.ClusterCapacityProviders
+}
+
+// createCapacityProviders creates the EC2 capacity providers of the cluster and associates every capacity provider with it.
+// It returns nil when the cluster has no capacity providers
+func createCapacityProviders(ctx *pulumi.Context, meta pgocomp.Meta, params ECSClusterParameters, provider *aws.Provider, vpc *ec2.Vpc, cluster *ecs.Cluster, subnets []*ec2.Subnet, response *ECSClusterComponent) (capacity *ClusterCapacity, err error) {
+	if len(params.CapacityProviders) == 0 {
+		return nil, nil
+	}
+	capacity = &ClusterCapacity{
+		Names: make(map[string]pulumi.StringInput),
+		Types: make(map[string]CapacityProviderType),
+	}
+	var names pulumi.StringArray
+	for _, cp := range params.CapacityProviders {
+		cp.Meta = cp.Meta.Inherit(&meta)
+		cp.EC2.defaultTags = params.defaultTags
+		cp.EC2.associatePublicIP = params.public
+		capacity.Types[cp.Name] = cp.Type
+		if cp.Type != EC2Capacity {
+			capacity.Names[cp.Name] = pulumi.String(cp.Type)
+			names = append(names, pulumi.String(cp.Type))
+			continue
+		}
+		err = CreateEC2CapacityProviderComponent(cp.Meta, cp.EC2, provider, vpc, cluster, subnets).GetAndThen(ctx, func(ec2cp *pgocomp.GetComponentWithMetaResponse[*EC2CapacityProviderComponent]) error {
+			response.CapacityProviders[cp.Name] = ec2cp
+			capacity.Names[cp.Name] = ec2cp.Component.CapacityProvider.Component.Name
+			names = append(names, ec2cp.Component.CapacityProvider.Component.Name)
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+	err = awsc.NewClusterCapacityProviders(meta.Child("capacity-providers"), &ecs.ClusterCapacityProvidersArgs{
+		ClusterName:       cluster.Name,
+		CapacityProviders: names,
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(association *pgocomp.GetComponentWithMetaResponse[*ecs.ClusterCapacityProviders]) error {
+		response.CapacityProviderAssociation = association
+		capacity.Association = association.Component
+		return nil
+	})
+	return
+}
+
+// CreateEC2CapacityProviderComponent creates a capacity provider backed by an Auto Scaling group, whose instances join the cluster
+func CreateEC2CapacityProviderComponent(meta pgocomp.Meta, params EC2CapacityParameters, provider *aws.Provider, vpc *ec2.Vpc, cluster *ecs.Cluster, subnets []*ec2.Subnet) *pgocomp.ComponentWithMeta[*EC2CapacityProviderComponent] {
+	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *EC2CapacityProviderComponent, err error) {
+		response = &EC2CapacityProviderComponent{}
+		err = CreateRoleComponent(meta.Child("instance-role"), ec2Principal, nil, []string{ecsInstancePolicyArn}, provider).GetAndThen(ctx, func(role *pgocomp.GetComponentWithMetaResponse[*RoleComponent]) error {
+			response.InstanceRole = role
+			return awsc.NewInstanceProfile(meta.Child("instance-profile"), &iam.InstanceProfileArgs{
+				Role: role.Component.Role.Component.Name,
+			}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(profile *pgocomp.GetComponentWithMetaResponse[*iam.InstanceProfile]) error {
+				response.InstanceProfile = profile
+				return nil
+			})
+		})
+		if err != nil {
+			return
+		}
+		err = CreateSecurityGroup(meta.Child("sg"), provider, vpc).GetAndThen(ctx, func(sg *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]) error {
+			response.SecurityGroup = sg
+			return nil
+		})
+		if err != nil {
+			return
+		}
+		err = CreateLaunchTemplate(meta.Child("launch-template"), params, provider, cluster, response.InstanceProfile.Component, response.SecurityGroup.Component).GetAndThen(ctx, func(template *pgocomp.GetComponentWithMetaResponse[*ec2.LaunchTemplate]) error {
+			response.LaunchTemplate = template
+			return nil
+		})
+		if err != nil {
+			return
+		}
+		err = CreateAutoScalingGroup(meta.Child("asg"), params, provider, response.LaunchTemplate.Component, subnets).GetAndThen(ctx, func(group *pgocomp.GetComponentWithMetaResponse[*autoscaling.Group]) error {
+			response.AutoScalingGroup = group
+			return nil
+		})
+		if err != nil {
+			return
+		}
+		err = awsc.NewCapacityProvider(meta, &ecs.CapacityProviderArgs{
+			AutoScalingGroupProvider: &ecs.CapacityProviderAutoScalingGroupProviderArgs{
+				AutoScalingGroupArn:          response.AutoScalingGroup.Component.Arn,
+				ManagedTerminationProtection: pulumi.String(enabledOrDisabled(params.ManagedTerminationProtection)),
+				ManagedScaling: &ecs.CapacityProviderAutoScalingGroupProviderManagedScalingArgs{
+					Status:                 pulumi.String(enabledOrDisabled(!params.ManagedScaling.Disabled)),
+					TargetCapacity:         pulumi.Int(valueOrDefault(params.ManagedScaling.TargetCapacity, 100)),
+					MinimumScalingStepSize: optionalInt(params.ManagedScaling.MinimumScalingStepSize),
+					MaximumScalingStepSize: optionalInt(params.ManagedScaling.MaximumScalingStepSize),
+				},
+			},
+		}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(cp *pgocomp.GetComponentWithMetaResponse[*ecs.CapacityProvider]) error {
+			response.CapacityProvider = cp
+			return nil
+		})
+		return
+	})
+}
+
+// CreateLaunchTemplate creates the launch template of the instances of an EC2 capacity provider. The instances register
+// in the cluster and require IMDSv2. In a public partition they get a public ip address, without which they cannot reach
+// the ECS endpoints through the internet gateway
+func CreateLaunchTemplate(meta pgocomp.Meta, params EC2CapacityParameters, provider *aws.Provider, cluster *ecs.Cluster, profile *iam.InstanceProfile, sg *ec2.SecurityGroup) *pgocomp.ComponentWithMeta[*ec2.LaunchTemplate] {
+	userData := cluster.Name.ApplyT(func(clusterName string) string {
+		return base64.StdEncoding.EncodeToString([]byte("#!/bin/bash\necho ECS_CLUSTER=" + clusterName + " >> /etc/ecs/ecs.config\n"))
+	}).(pulumi.StringOutput)
+	args := &ec2.LaunchTemplateArgs{
+		ImageId:             pulumi.String(valueOrDefault(params.ImageID, "resolve:ssm:"+DefaultECSOptimizedAMIParameter)),
+		InstanceType:        pulumi.String(params.InstanceTypes[0]),
+		IamInstanceProfile:  &ec2.LaunchTemplateIamInstanceProfileArgs{Arn: profile.Arn},
+		VpcSecurityGroupIds: pulumi.StringArray{sg.ID()},
+		UserData:            userData,
+		MetadataOptions: &ec2.LaunchTemplateMetadataOptionsArgs{
+			HttpEndpoint: pulumi.String("enabled"),
+			HttpTokens:   pulumi.String("required"),
+		},
+	}
+	if params.associatePublicIP {
+		//The security groups move to the network interface, because AWS rejects them in both places
+		args.VpcSecurityGroupIds = nil
+		args.NetworkInterfaces = ec2.LaunchTemplateNetworkInterfaceArray{ec2.LaunchTemplateNetworkInterfaceArgs{
+			DeviceIndex:              pulumi.Int(0),
+			AssociatePublicIpAddress: pulumi.String("true"),
+			SecurityGroups:           pulumi.StringArray{sg.ID()},
+		}}
+	}
+	return awsc.NewLaunchTemplate(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateAutoScalingGroup creates the Auto Scaling group of an EC2 capacity provider, which launches any of its instance types.
+// ECS manages its desired capacity, so Pulumi ignores its changes. The group propagates the default tags of the provider
+// with the tags of the meta, because AWS does not add the default tags to the instances it launches
+func CreateAutoScalingGroup(meta pgocomp.Meta, params EC2CapacityParameters, provider *aws.Provider, template *ec2.LaunchTemplate, subnets []*ec2.Subnet) *pgocomp.ComponentWithMeta[*autoscaling.Group] {
+	meta.Tags = pgocomp.MergeTags(params.defaultTags, meta.Tags)
+	var overrides autoscaling.GroupMixedInstancesPolicyLaunchTemplateOverrideArray
+	for _, instanceType := range params.InstanceTypes {
+		overrides = append(overrides, autoscaling.GroupMixedInstancesPolicyLaunchTemplateOverrideArgs{
+			InstanceType: pulumi.String(instanceType),
+		})
+	}
+	var subnetIDs pulumi.StringArray
+	for _, subnet := range subnets {
+		subnetIDs = append(subnetIDs, subnet.ID())
+	}
+	return awsc.NewAutoScalingGroup(meta, &autoscaling.GroupArgs{
+		MinSize:            pulumi.Int(params.MinSize),
+		MaxSize:            pulumi.Int(params.MaxSize),
+		VpcZoneIdentifiers: subnetIDs,
+		ProtectFromScaleIn: pulumi.Bool(params.ManagedTerminationProtection),
+		MixedInstancesPolicy: &autoscaling.GroupMixedInstancesPolicyArgs{
+			LaunchTemplate: &autoscaling.GroupMixedInstancesPolicyLaunchTemplateArgs{
+				LaunchTemplateSpecification: &autoscaling.GroupMixedInstancesPolicyLaunchTemplateLaunchTemplateSpecificationArgs{
+					LaunchTemplateId: template.ID(),
+					Version:          pulumi.String("$Latest"),
+				},
+				Overrides: overrides,
+			},
+		},
+		//ECS requires this tag on the groups of capacity providers with managed scaling
+		Tags: autoscaling.GroupTagArray{autoscaling.GroupTagArgs{
+			Key:               pulumi.String("AmazonECSManaged"),
+			Value:             pulumi.String("true"),
+			PropagateAtLaunch: pulumi.Bool(true),
+		}},
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect), pulumi.IgnoreChanges([]string{"desiredCapacity"}))
+}
+
+func enabledOrDisabled(enabled bool) string {
+	if enabled {
+		return "ENABLED"
+	}
+	return "DISABLED"
+}
+
+// usesCapacityProviders tells if the tasks of the service run on the capacity providers of its strategy
+func (p *ECSServiceParameters) usesCapacityProviders() bool {
+	return len(p.CapacityProviderStrategy) > 0
+}
+
+// requiresCompatibilities are the launch types the task definition must be compatible with, from the capacity providers the service uses
+func (p *ECSServiceParameters) requiresCompatibilities(capacity *ClusterCapacity) (compatibilities pulumi.StringArray) {
+	fargate, ec2 := !p.usesCapacityProviders(), false
+	for _, item := range p.CapacityProviderStrategy {
+		if capacity != nil && capacity.Types[item.CapacityProviderLookupName] == EC2Capacity {
+			ec2 = true
+		} else {
+			fargate = true
+		}
+	}
+	if fargate {
+		compatibilities = append(compatibilities, pulumi.String("FARGATE"))
+	}
+	if ec2 {
+		compatibilities = append(compatibilities, pulumi.String("EC2"))
+	}
+	return
+}
+
+// launchType is the launch type of a service that doesn't use capacity providers
+func (p *ECSServiceParameters) launchType() pulumi.StringPtrInput {
+	if p.usesCapacityProviders() {
+		return nil
+	}
+	return pulumi.String("FARGATE")
+}
+
+// capacityProviderStrategies returns the strategy of the service, with the names of the capacity providers of the cluster
+func (p *ECSServiceParameters) capacityProviderStrategies(capacity *ClusterCapacity) (array ecs.ServiceCapacityProviderStrategyArray) {
+	if capacity == nil {
+		return nil
+	}
+	for _, item := range p.CapacityProviderStrategy {
+		array = append(array, ecs.ServiceCapacityProviderStrategyArgs{
+			CapacityProvider: capacity.Names[item.CapacityProviderLookupName],
+			Weight:           pulumi.Int(item.Weight),
+			Base:             pulumi.Int(item.Base),
+		})
+	}
+	return
+}
+
+// dependsOn adds the association of the capacity providers to the resources a service depends on
+func (c *ClusterCapacity) dependsOn(resources ...pulumi.Resource) []pulumi.Resource {
+	if c != nil && c.Association != nil {
+		resources = append(resources, c.Association)
+	}
+	return resources
+}
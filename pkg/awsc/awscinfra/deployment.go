@@ -0,0 +1,228 @@
+package awscinfra
+
+import (
+	"fmt"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/codedeploy"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// codeDeployPrincipal is the service that assumes the role of the deployment groups
+const codeDeployPrincipal = "codedeploy.amazonaws.com"
+
+// codeDeployECSPolicyArn is the managed policy that lets CodeDeploy shift the traffic between the tasks of ECS services
+const codeDeployECSPolicyArn = "arn:aws:iam::aws:policy/AWSCodeDeployRoleForECS"
+
+// BlueGreenTargets are the traffic routes of the blue/green deployments of a service: its target group, the copy of it
+// that receives the new tasks, and the production and test listeners
+type BlueGreenTargets struct {
+	Blue         *lb.TargetGroup
+	Green        *lb.TargetGroup
+	ProdListener *lb.Listener
+	TestListener *lb.Listener
+}
+
+// createBlueGreenTargets creates the green target group and the test listener of each service of the cluster deployed in
+// the blue/green mode, and returns their traffic routes by service name
+func createBlueGreenTargets(ctx *pulumi.Context, meta pgocomp.Meta, params NetworkPartitionParameters, cluster ECSClusterParameters, provider *aws.Provider, vpc *ec2.Vpc, certs map[string]*CertificateComponent, response *NetworkPartitionComponent) (map[string]*BlueGreenTargets, error) {
+	targets := make(map[string]*BlueGreenTargets)
+	for _, service := range cluster.Services {
+		bg := service.Deployment.BlueGreen
+		if bg == nil {
+			continue
+		}
+		blue, ok := response.TargetGroups[bg.TargetGroupLookupName]
+		if !ok {
+			return nil, fmt.Errorf("Target group Lookup Name %s not found", bg.TargetGroupLookupName)
+		}
+		loadBalancer, ok := response.LoadBalancers[bg.LoadBalancerLookupName]
+		if !ok {
+			return nil, fmt.Errorf("Load balancer Lookup Name %s not found", bg.LoadBalancerLookupName)
+		}
+		prod, ok := loadBalancer.Component.Listeners[bg.ListenerLookupName]
+		if !ok {
+			return nil, fmt.Errorf("Listener Lookup Name %s not found in the load balancer %s", bg.ListenerLookupName, bg.LoadBalancerLookupName)
+		}
+		var tgParams LBTargetGroupParameters
+		for _, tg := range params.LBTargetGroups {
+			if tg.Name == bg.TargetGroupLookupName {
+				tgParams = tg
+			}
+		}
+		var listenerParams LBListenerParameters
+		for _, b := range params.LoadBalancers {
+			for _, l := range b.Listeners {
+				if b.Name == bg.LoadBalancerLookupName && l.Name == bg.ListenerLookupName {
+					listenerParams = l
+				}
+			}
+		}
+		routes := &BlueGreenTargets{Blue: blue.Component, ProdListener: prod.Component}
+
+		//The green target group is a copy of the blue one, so the new tasks pass the same health checks
+		tgParams.Meta = tgParams.Meta.Inherit(&meta)
+		err := CreateTargetGroup(tgParams.Meta.Child("green"), tgParams, provider, vpc).GetAndThen(ctx, func(green *pgocomp.GetComponentWithMetaResponse[*lb.TargetGroup]) error {
+			response.TargetGroups[bg.greenTargetGroupName()] = green
+			routes.Green = green.Component
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		testMeta := prod.Meta.Child("test")
+		test := LBListenerParameters{
+			Meta:                  testMeta,
+			Port:                  bg.TestListenerPort,
+			Protocol:              listenerParams.Protocol,
+			TargetGroupLookupName: bg.greenTargetGroupName(),
+			CertificateLookupName: listenerParams.CertificateLookupName,
+			SslPolicy:             listenerParams.SslPolicy,
+			AlpnPolicy:            listenerParams.AlpnPolicy,
+		}
+		tgs := map[string]*lb.TargetGroup{bg.greenTargetGroupName(): routes.Green}
+		err = CreateListener(test.Meta, test, provider, loadBalancer.Component.LoadBalancer.Component, tgs, nil, certs).GetAndThen(ctx, func(l *pgocomp.GetComponentWithMetaResponse[*lb.Listener]) error {
+			loadBalancer.Component.Listeners[l.Meta.Name] = l
+			routes.TestListener = l.Component
+			if loadBalancer.Component.SecurityGroup == nil {
+				return nil
+			}
+			return CreateAndAttachTCPIngressSecurityGroupRule(test.Meta.Child("rule"), provider, loadBalancer.Component.SecurityGroup.Component,
+				test.Port, test.Port, pulumi.StringArray{vpc.CidrBlock}).Apply(ctx)
+		})
+		if err != nil {
+			return nil, err
+		}
+		targets[service.Name] = routes
+	}
+	return targets, nil
+}
+
+// CreateBlueGreenComponent creates the CodeDeploy application and deployment group that deploy a service in the blue/green mode,
+// with the role that lets CodeDeploy shift its traffic
+func CreateBlueGreenComponent(meta pgocomp.Meta, params DeploymentParameters, provider *aws.Provider, cluster *ecs.Cluster, service *ecs.Service, targets *BlueGreenTargets) *pgocomp.ComponentWithMeta[*BlueGreenComponent] {
+	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *BlueGreenComponent, err error) {
+		response = &BlueGreenComponent{}
+		bg := params.BlueGreen
+		err = CreateRoleComponent(meta.Child("role"), codeDeployPrincipal, nil, []string{codeDeployECSPolicyArn}, provider).GetAndThen(ctx, func(role *pgocomp.GetComponentWithMetaResponse[*RoleComponent]) error {
+			response.Role = role
+			return awsc.NewCodeDeployApplication(meta, &codedeploy.ApplicationArgs{
+				ComputePlatform: pulumi.String("ECS"),
+			}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(app *pgocomp.GetComponentWithMetaResponse[*codedeploy.Application]) error {
+				response.Application = app
+				return awsc.NewDeploymentGroup(meta.Child("group"), &codedeploy.DeploymentGroupArgs{
+					AppName:              app.Component.Name,
+					DeploymentGroupName:  service.Name,
+					ServiceRoleArn:       role.Component.Role.Component.Arn,
+					DeploymentConfigName: pulumi.String(valueOrDefault(bg.DeploymentConfigName, DefaultDeploymentConfig)),
+					DeploymentStyle: &codedeploy.DeploymentGroupDeploymentStyleArgs{
+						DeploymentOption: pulumi.String("WITH_TRAFFIC_CONTROL"),
+						DeploymentType:   pulumi.String("BLUE_GREEN"),
+					},
+					AutoRollbackConfiguration: &codedeploy.DeploymentGroupAutoRollbackConfigurationArgs{
+						Enabled: pulumi.Bool(params.Rollback),
+						Events:  pulumi.StringArray{pulumi.String("DEPLOYMENT_FAILURE")},
+					},
+					BlueGreenDeploymentConfig: &codedeploy.DeploymentGroupBlueGreenDeploymentConfigArgs{
+						DeploymentReadyOption: &codedeploy.DeploymentGroupBlueGreenDeploymentConfigDeploymentReadyOptionArgs{
+							ActionOnTimeout: pulumi.String("CONTINUE_DEPLOYMENT"),
+						},
+						TerminateBlueInstancesOnDeploymentSuccess: &codedeploy.DeploymentGroupBlueGreenDeploymentConfigTerminateBlueInstancesOnDeploymentSuccessArgs{
+							Action:                       pulumi.String("TERMINATE"),
+							TerminationWaitTimeInMinutes: pulumi.Int(valueOrDefault(bg.TerminationWaitTime, 5)),
+						},
+					},
+					EcsService: &codedeploy.DeploymentGroupEcsServiceArgs{
+						ClusterName: cluster.Name,
+						ServiceName: service.Name,
+					},
+					LoadBalancerInfo: &codedeploy.DeploymentGroupLoadBalancerInfoArgs{
+						TargetGroupPairInfo: &codedeploy.DeploymentGroupLoadBalancerInfoTargetGroupPairInfoArgs{
+							ProdTrafficRoute: &codedeploy.DeploymentGroupLoadBalancerInfoTargetGroupPairInfoProdTrafficRouteArgs{
+								ListenerArns: pulumi.StringArray{targets.ProdListener.Arn},
+							},
+							TestTrafficRoute: &codedeploy.DeploymentGroupLoadBalancerInfoTargetGroupPairInfoTestTrafficRouteArgs{
+								ListenerArns: pulumi.StringArray{targets.TestListener.Arn},
+							},
+							TargetGroups: codedeploy.DeploymentGroupLoadBalancerInfoTargetGroupPairInfoTargetGroupArray{
+								codedeploy.DeploymentGroupLoadBalancerInfoTargetGroupPairInfoTargetGroupArgs{Name: targets.Blue.Name},
+								codedeploy.DeploymentGroupLoadBalancerInfoTargetGroupPairInfoTargetGroupArgs{Name: targets.Green.Name},
+							},
+						},
+					},
+				}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(group *pgocomp.GetComponentWithMetaResponse[*codedeploy.DeploymentGroup]) error {
+					response.DeploymentGroup = group
+					return nil
+				})
+			})
+		})
+		return
+	})
+}
+
+// blueGreenListeners returns a copy of the listeners of the load balancer, where the production listeners of the services
+// deployed in the blue/green mode are marked
+func (p *NetworkPartitionParameters) blueGreenListeners(loadBalancer LoadBalancerParameters) []LBListenerParameters {
+	listeners := append([]LBListenerParameters(nil), loadBalancer.Listeners...)
+	for _, cluster := range p.ECSClusters {
+		for _, service := range cluster.Services {
+			bg := service.Deployment.BlueGreen
+			if bg == nil || bg.LoadBalancerLookupName != loadBalancer.Name {
+				continue
+			}
+			for i := range listeners {
+				if listeners[i].Name == bg.ListenerLookupName {
+					listeners[i].shiftedByCodeDeploy = true
+				}
+			}
+		}
+	}
+	return listeners
+}
+
+// ignoredChanges are the changes of the listener that Pulumi leaves to CodeDeploy, which shifts the production listeners
+// of blue/green deployments between the target groups
+func (p *LBListenerParameters) ignoredChanges() []string {
+	if p.shiftedByCodeDeploy {
+		return []string{"defaultActions"}
+	}
+	return nil
+}
+
+// greenTargetGroupName is the name of the copy of the target group that receives the new tasks
+func (p *BlueGreenParameters) greenTargetGroupName() string {
+	return p.TargetGroupLookupName + "-green"
+}
+
+// deploymentController hands the deployments of the service to CodeDeploy in the blue/green mode
+func (p *DeploymentParameters) deploymentController() ecs.ServiceDeploymentControllerPtrInput {
+	if p.BlueGreen == nil {
+		return nil
+	}
+	return ecs.ServiceDeploymentControllerArgs{Type: pulumi.String("CODE_DEPLOY")}
+}
+
+// circuitBreaker returns the circuit breaker of the rolling deployments, when it is enabled
+func (p *DeploymentParameters) circuitBreaker() ecs.ServiceDeploymentCircuitBreakerPtrInput {
+	if !p.CircuitBreaker {
+		return nil
+	}
+	return ecs.ServiceDeploymentCircuitBreakerArgs{
+		Enable:   pulumi.Bool(true),
+		Rollback: pulumi.Bool(p.Rollback),
+	}
+}
+
+// minimumHealthyPercent returns the minimum healthy percent, which can be zero
+func (p *DeploymentParameters) minimumHealthyPercent() pulumi.IntPtrInput {
+	if p.MinimumHealthyPercent == nil {
+		return nil
+	}
+	return pulumi.Int(*p.MinimumHealthyPercent)
+}
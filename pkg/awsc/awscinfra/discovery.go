@@ -0,0 +1,161 @@
+package awscinfra
+
+import (
+	"strconv"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/servicediscovery"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// CreateNamespace creates a Cloud Map private DNS namespace, which resolves only inside the Vpc
+func CreateNamespace(meta pgocomp.Meta, params NamespaceParameters, provider *aws.Provider, vpc *ec2.Vpc) *pgocomp.ComponentWithMeta[*servicediscovery.PrivateDnsNamespace] {
+	return awsc.NewPrivateDNSNamespace(meta, &servicediscovery.PrivateDnsNamespaceArgs{
+		Name:        pulumi.String(params.Domain),
+		Description: optionalString(params.Description),
+		Vpc:         vpc.ID(),
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateDiscoveryService creates the Cloud Map service where ECS registers the tasks of a service. ECS reports the health
+// of the tasks, so the records only answer with the running tasks
+func CreateDiscoveryService(meta pgocomp.Meta, serviceName string, params ServiceDiscoveryParameters, provider *aws.Provider, namespace *servicediscovery.PrivateDnsNamespace) *pgocomp.ComponentWithMeta[*servicediscovery.Service] {
+	var records servicediscovery.ServiceDnsConfigDnsRecordArray
+	for _, typ := range params.recordTypes() {
+		records = append(records, servicediscovery.ServiceDnsConfigDnsRecordArgs{
+			Type: pulumi.String(typ),
+			Ttl:  pulumi.Int(valueOrDefault(params.TTL, 10)),
+		})
+	}
+	return awsc.NewDiscoveryService(meta, &servicediscovery.ServiceArgs{
+		Name: pulumi.String(valueOrDefault(params.Name, serviceName)),
+		DnsConfig: &servicediscovery.ServiceDnsConfigArgs{
+			NamespaceId:   namespace.ID(),
+			DnsRecords:    records,
+			RoutingPolicy: pulumi.String("MULTIVALUE"),
+		},
+		HealthCheckCustomConfig: &servicediscovery.ServiceHealthCheckCustomConfigArgs{
+			FailureThreshold: pulumi.Int(1),
+		},
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// createDiscoveryRules opens the ports that the other services of the namespace call, to the Vpc. The ports that forward
+// to a target group are already open
+func createDiscoveryRules(ctx *pulumi.Context, meta pgocomp.Meta, params ECSServiceParameters, provider *aws.Provider, vpc *ec2.Vpc, sg *ec2.SecurityGroup) error {
+	opened := make(map[int]bool)
+	for _, c := range params.Containers {
+		for _, p := range c.PortMappings {
+			if p.TargetGroupLookupName != "" || opened[p.ContainerPort] || !params.publishes(p) {
+				continue
+			}
+			opened[p.ContainerPort] = true
+			err := CreateAndAttachTCPIngressSecurityGroupRule(meta.Child("sg-port-"+strconv.Itoa(p.ContainerPort)+"-rule"), provider, sg,
+				p.ContainerPort, p.ContainerPort, pulumi.StringArray{vpc.CidrBlock}).Apply(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// publishes tells if the other services of the namespace can call the port mapping: every port of a service registered
+// in Cloud Map, and the ports of its Service Connect services
+func (p *ECSServiceParameters) publishes(mapping ContainerPortMapping) bool {
+	if p.ServiceDiscovery != nil {
+		return true
+	}
+	if p.ServiceConnect == nil || mapping.Name == "" {
+		return false
+	}
+	for _, s := range p.ServiceConnect.Services {
+		if s.PortName == mapping.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// recordTypes returns the types of the records of the tasks, which default to RecordA
+func (p *ServiceDiscoveryParameters) recordTypes() []DNSRecordType {
+	if len(p.RecordTypes) == 0 {
+		return []DNSRecordType{RecordA}
+	}
+	return p.RecordTypes
+}
+
+// namedPort returns the container and the port mapping with the name
+func (p *ECSServiceParameters) namedPort(name string) (string, ContainerPortMapping, bool) {
+	for _, c := range p.Containers {
+		for _, mapping := range c.PortMappings {
+			if mapping.Name != "" && mapping.Name == name {
+				return c.Name, mapping, true
+			}
+		}
+	}
+	return "", ContainerPortMapping{}, false
+}
+
+// serviceRegistries registers the tasks of the service in the Cloud Map service. SRV records need the container and
+// the port of their named port mapping
+func (p *ECSServiceParameters) serviceRegistries(registry *servicediscovery.Service) ecs.ServiceServiceRegistriesPtrInput {
+	if registry == nil {
+		return nil
+	}
+	args := ecs.ServiceServiceRegistriesArgs{RegistryArn: registry.Arn}
+	for _, typ := range p.ServiceDiscovery.recordTypes() {
+		if typ != RecordSRV {
+			continue
+		}
+		if container, mapping, ok := p.namedPort(p.ServiceDiscovery.PortName); ok {
+			args.ContainerName = pulumi.String(container)
+			args.ContainerPort = pulumi.Int(mapping.ContainerPort)
+		}
+	}
+	return args
+}
+
+// serviceConnectConfiguration returns the Service Connect configuration of the service in the namespace. The proxy logs
+// to the log group of the service, when the logs are enabled
+func (p *ECSServiceParameters) serviceConnectConfiguration(name string, namespace *servicediscovery.PrivateDnsNamespace, logGroup *cloudwatch.LogGroup, region pulumi.StringInput) ecs.ServiceServiceConnectConfigurationPtrInput {
+	if p.ServiceConnect == nil || namespace == nil {
+		return nil
+	}
+	var services ecs.ServiceServiceConnectConfigurationServiceArray
+	for _, s := range p.ServiceConnect.Services {
+		_, mapping, _ := p.namedPort(s.PortName)
+		services = append(services, ecs.ServiceServiceConnectConfigurationServiceArgs{
+			PortName:      pulumi.String(s.PortName),
+			DiscoveryName: optionalString(s.DiscoveryName),
+			ClientAlias: ecs.ServiceServiceConnectConfigurationServiceClientAliasArray{
+				ecs.ServiceServiceConnectConfigurationServiceClientAliasArgs{
+					Port:    pulumi.Int(valueOrDefault(s.ClientPort, mapping.ContainerPort)),
+					DnsName: optionalString(s.DNSName),
+				},
+			},
+		})
+	}
+	args := ecs.ServiceServiceConnectConfigurationArgs{
+		Enabled:   pulumi.Bool(true),
+		Namespace: namespace.Arn,
+		Services:  services,
+	}
+	if logGroup != nil {
+		args.LogConfiguration = ecs.ServiceServiceConnectConfigurationLogConfigurationArgs{
+			LogDriver: pulumi.String("awslogs"),
+			Options: pulumi.StringMap{
+				"awslogs-group":         logGroup.Name,
+				"awslogs-region":        region,
+				"awslogs-stream-prefix": pulumi.String(valueOrDefault(p.Logs.StreamPrefix, name) + "-service-connect"),
+			},
+		}
+	}
+	return args
+}
@@ -0,0 +1,193 @@
+package awscinfra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/acm"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// CreateHostedZone creates a Route 53 hosted zone. A private zone is attached to the vpc
+func CreateHostedZone(meta pgocomp.Meta, params HostedZoneParameters, provider *aws.Provider, vpc *ec2.Vpc) *pgocomp.ComponentWithMeta[*route53.Zone] {
+	args := &route53.ZoneArgs{
+		Name: pulumi.String(params.Domain),
+	}
+	if params.Private {
+		args.Vpcs = route53.ZoneVpcArray{route53.ZoneVpcArgs{VpcId: vpc.ID()}}
+	}
+	return awsc.NewZone(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// createAliasRecords creates the records of the hosted zones of the Vpc, once its partitions and load balancers exist
+func createAliasRecords(ctx *pulumi.Context, params VpcParameters, provider *aws.Provider, response *VpcComponent) error {
+	for _, zoneParams := range params.HostedZones {
+		zone, ok := response.HostedZones[zoneParams.Name]
+		if !ok {
+			return fmt.Errorf("Hosted zone Lookup Name %s not found", zoneParams.Name)
+		}
+		for _, record := range zoneParams.Records {
+			record.Meta = record.Meta.Inherit(&params.Meta)
+			loadBalancer, ok := findLoadBalancer(response, record.LoadBalancerLookupName)
+			if !ok {
+				return fmt.Errorf("Load balancer Lookup Name %s not found", record.LoadBalancerLookupName)
+			}
+			types := record.Types
+			if len(types) == 0 {
+				types = []DNSRecordType{RecordA}
+			}
+			for _, typ := range types {
+				//The A record keeps the name of the parameters, so adding an AAAA record does not replace it
+				meta := record.Meta
+				if typ != RecordA {
+					meta = meta.Child(strings.ToLower(string(typ)))
+				}
+				err := CreateAliasRecord(meta, record, typ, provider, zone.Component, loadBalancer).GetAndThen(ctx, func(r *pgocomp.GetComponentWithMetaResponse[*route53.Record]) error {
+					response.AliasRecords[r.Meta.Name] = r
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findLoadBalancer returns the load balancer of a lookup name, in any partition of the Vpc
+func findLoadBalancer(response *VpcComponent, lookupName string) (*lb.LoadBalancer, bool) {
+	for _, partition := range response.Partitions {
+		if loadBalancer, ok := partition.Component.LoadBalancers[lookupName]; ok {
+			return loadBalancer.Component.LoadBalancer.Component, true
+		}
+	}
+	return nil, false
+}
+
+// CreateAliasRecord creates an alias record of a type that points a name at a load balancer
+func CreateAliasRecord(meta pgocomp.Meta, params AliasRecordParameters, typ DNSRecordType, provider *aws.Provider, zone *route53.Zone, loadBalancer *lb.LoadBalancer) *pgocomp.ComponentWithMeta[*route53.Record] {
+	args := &route53.RecordArgs{
+		ZoneId: zone.ZoneId,
+		Name:   pulumi.String(params.RecordName),
+		Type:   pulumi.String(typ),
+		Aliases: route53.RecordAliasArray{route53.RecordAliasArgs{
+			Name:                 loadBalancer.DnsName,
+			ZoneId:               loadBalancer.ZoneId,
+			EvaluateTargetHealth: pulumi.Bool(params.EvaluateTargetHealth),
+		}},
+		SetIdentifier: optionalString(params.SetIdentifier),
+	}
+	switch params.RoutingPolicy {
+	case WeightedRouting:
+		args.WeightedRoutingPolicies = route53.RecordWeightedRoutingPolicyArray{route53.RecordWeightedRoutingPolicyArgs{
+			Weight: pulumi.Int(params.Weight),
+		}}
+	case FailoverRouting:
+		args.FailoverRoutingPolicies = route53.RecordFailoverRoutingPolicyArray{route53.RecordFailoverRoutingPolicyArgs{
+			Type: pulumi.String(params.Failover),
+		}}
+	}
+	return awsc.NewRecord(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateCertificateComponent creates a certificate. When it is validated by DNS in a hosted zone, it also creates
+// the validation records in the zone and a certificate validation that waits until the certificate is issued
+func CreateCertificateComponent(meta pgocomp.Meta, params CertificateParameters, provider *aws.Provider, zones map[string]*route53.Zone) *pgocomp.ComponentWithMeta[*CertificateComponent] {
+	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *CertificateComponent, err error) {
+		response = &CertificateComponent{
+			ValidationRecords: make(map[string]*pgocomp.GetComponentWithMetaResponse[*route53.Record]),
+		}
+		var zoneID pulumi.StringInput
+		switch {
+		case params.ValidationMethod != ValidationByDNS:
+		case params.HostedZoneLookupName != "":
+			zone, ok := zones[params.HostedZoneLookupName]
+			if !ok {
+				return nil, fmt.Errorf("Hosted zone Lookup Name %s not found", params.HostedZoneLookupName)
+			}
+			zoneID = zone.ZoneId
+		case params.HostedZoneName != "":
+			zone, err := route53.LookupZone(ctx, &route53.LookupZoneArgs{
+				Name:        pulumi.StringRef(params.HostedZoneName),
+				PrivateZone: pulumi.BoolRef(false),
+			}, pulumi.Provider(provider))
+			if err != nil {
+				return nil, err
+			}
+			zoneID = pulumi.String(zone.ZoneId)
+		}
+		err = CreateCertificate(meta, params, provider).GetAndThen(ctx, func(cert *pgocomp.GetComponentWithMetaResponse[*acm.Certificate]) (err error) {
+			response.Certificate = cert
+			if zoneID == nil {
+				return
+			}
+			var fqdns pulumi.StringArray
+			for _, domain := range validationDomains(params) {
+				err = CreateValidationRecord(meta.Child("validation-"+strings.TrimPrefix(domain, "*.")), provider, cert.Component, domain, zoneID).
+					GetAndThen(ctx, func(record *pgocomp.GetComponentWithMetaResponse[*route53.Record]) error {
+						response.ValidationRecords[domain] = record
+						fqdns = append(fqdns, record.Component.Fqdn)
+						return nil
+					})
+				if err != nil {
+					return
+				}
+			}
+			return awsc.NewCertificateValidation(meta.Child("validation"), &acm.CertificateValidationArgs{
+				CertificateArn:        cert.Component.Arn,
+				ValidationRecordFqdns: fqdns,
+			}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(validation *pgocomp.GetComponentWithMetaResponse[*acm.CertificateValidation]) error {
+				response.Validation = validation
+				return nil
+			})
+		})
+		return
+	})
+}
+
+// CreateValidationRecord creates the DNS record that validates a domain of a certificate
+func CreateValidationRecord(meta pgocomp.Meta, provider *aws.Provider, cert *acm.Certificate, domain string, zoneID pulumi.StringInput) *pgocomp.ComponentWithMeta[*route53.Record] {
+	option := cert.DomainValidationOptions.ApplyT(func(options []acm.CertificateDomainValidationOption) acm.CertificateDomainValidationOption {
+		for _, o := range options {
+			if o.DomainName != nil && *o.DomainName == domain {
+				return o
+			}
+		}
+		return acm.CertificateDomainValidationOption{}
+	}).(acm.CertificateDomainValidationOptionOutput)
+	return awsc.NewRecord(meta, &route53.RecordArgs{
+		ZoneId:         zoneID,
+		Name:           option.ResourceRecordName().Elem(),
+		Type:           option.ResourceRecordType().Elem(),
+		Records:        pulumi.StringArray{option.ResourceRecordValue().Elem()},
+		Ttl:            pulumi.Int(60),
+		AllowOverwrite: pulumi.Bool(true),
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// validationDomains returns the domains of a certificate that need a validation record.
+// A wildcard domain shares its record with its base domain, like *.example.com and example.com
+func validationDomains(params CertificateParameters) (domains []string) {
+	all := append([]string{params.Domain}, params.SubjectAlternativeNames...)
+	present := make(map[string]bool)
+	for _, domain := range all {
+		present[domain] = true
+	}
+	added := make(map[string]bool)
+	for _, domain := range all {
+		if base := strings.TrimPrefix(domain, "*."); (base != domain && present[base]) || added[domain] {
+			continue
+		}
+		added[domain] = true
+		domains = append(domains, domain)
+	}
+	return
+}
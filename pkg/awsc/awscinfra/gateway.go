@@ -0,0 +1,139 @@
+package awscinfra
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// CreateGatewayLoadBalancer creates a gateway load balancer in the subnets. It has no security group
+func CreateGatewayLoadBalancer(meta pgocomp.Meta, provider *aws.Provider, subnets []*ec2.Subnet) *pgocomp.ComponentWithMeta[*lb.LoadBalancer] {
+	var customResources []*pulumi.CustomResourceState
+	for _, subnet := range subnets {
+		customResources = append(customResources, &subnet.CustomResourceState)
+	}
+	return awsc.NewLoadBalancer(meta, &lb.LoadBalancerArgs{
+		LoadBalancerType: pulumi.String(Gateway),
+		Subnets:          awsc.ToIDStringArray(customResources...),
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateEndpointService creates the endpoint service of a gateway load balancer
+func CreateEndpointService(meta pgocomp.Meta, params LBEndpointServiceParameters, provider *aws.Provider, loadBalancer *lb.LoadBalancer) *pgocomp.ComponentWithMeta[*ec2.VpcEndpointService] {
+	args := &ec2.VpcEndpointServiceArgs{
+		AcceptanceRequired:      pulumi.Bool(params.AcceptanceRequired),
+		GatewayLoadBalancerArns: pulumi.StringArray{loadBalancer.Arn},
+	}
+	if len(params.AllowedPrincipals) > 0 {
+		args.AllowedPrincipals = pulumi.ToStringArray(params.AllowedPrincipals)
+	}
+	return awsc.NewVpcEndpointService(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// createGatewayEndpoints creates the gateway endpoints of the Vpc and their routes, once its partitions and gateway load balancers exist
+func createGatewayEndpoints(ctx *pulumi.Context, params VpcParameters, provider *aws.Provider, vpc *ec2.Vpc, response *VpcComponent) error {
+	for _, endpoint := range params.GatewayEndpoints {
+		endpoint.Meta = endpoint.Meta.Inherit(&params.Meta)
+		service, err := findEndpointService(response, endpoint.LoadBalancerLookupName)
+		if err != nil {
+			return err
+		}
+		partition, ok := response.Partitions[endpoint.PartitionLookupName]
+		if !ok {
+			return fmt.Errorf("Partition Lookup Name %s not found", endpoint.PartitionLookupName)
+		}
+		subnet, ok := partition.Component.Subnets[endpoint.SubnetLookupName]
+		if !ok {
+			return fmt.Errorf("Subnet Lookup Name %s not found", endpoint.SubnetLookupName)
+		}
+		err = CreateGatewayEndpoint(endpoint.Meta, provider, vpc, subnet.Component, service).GetAndThen(ctx, func(e *pgocomp.GetComponentWithMetaResponse[*ec2.VpcEndpoint]) error {
+			response.GatewayEndpoints[e.Meta.Name] = e
+			for _, route := range endpoint.Routes {
+				tables, err := partitionRouteTables(response, route.PartitionLookupName)
+				if err != nil {
+					return err
+				}
+				for _, table := range tables {
+					meta := endpoint.Meta.Child(zoneSuffix("route-"+route.PartitionLookupName, table.zone))
+					err = CreateGatewayEndpointRoute(meta, provider, table.routeTable, e.Component, route.DestinationCidrBlock).GetAndThen(ctx, func(r *pgocomp.GetComponentWithMetaResponse[*ec2.Route]) error {
+						response.GatewayEndpointRoutes[r.Meta.Name] = r
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findEndpointService returns the endpoint service of a gateway load balancer, in any partition of the Vpc
+func findEndpointService(response *VpcComponent, lookupName string) (*ec2.VpcEndpointService, error) {
+	for _, partition := range response.Partitions {
+		if loadBalancer, ok := partition.Component.LoadBalancers[lookupName]; ok {
+			if loadBalancer.Component.EndpointService == nil {
+				return nil, fmt.Errorf("Load balancer %s is not a gateway load balancer", lookupName)
+			}
+			return loadBalancer.Component.EndpointService.Component, nil
+		}
+	}
+	return nil, fmt.Errorf("Load balancer Lookup Name %s not found", lookupName)
+}
+
+type zoneRouteTable struct {
+	zone       string
+	routeTable *ec2.RouteTable
+}
+
+// partitionRouteTables returns the route tables of a partition, sorted by availability zone.
+// Public partitions share the route table of the internet gateway, so they cannot be routed through an endpoint
+func partitionRouteTables(response *VpcComponent, lookupName string) (tables []zoneRouteTable, err error) {
+	partition, ok := response.Partitions[lookupName]
+	if !ok {
+		return nil, fmt.Errorf("Partition Lookup Name %s not found", lookupName)
+	}
+	if len(partition.Component.RouteTables) == 0 {
+		return nil, fmt.Errorf("the partition %s is public and shares the route table of the internet gateway", lookupName)
+	}
+	for zone, rt := range partition.Component.RouteTables {
+		tables = append(tables, zoneRouteTable{zone: zone, routeTable: rt.Component})
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].zone < tables[j].zone })
+	return
+}
+
+// CreateGatewayEndpoint creates a gateway load balancer endpoint of an endpoint service in a subnet
+func CreateGatewayEndpoint(meta pgocomp.Meta, provider *aws.Provider, vpc *ec2.Vpc, subnet *ec2.Subnet, service *ec2.VpcEndpointService) *pgocomp.ComponentWithMeta[*ec2.VpcEndpoint] {
+	return awsc.NewVpcEndpoint(meta, &ec2.VpcEndpointArgs{
+		VpcId:           vpc.ID(),
+		ServiceName:     service.ServiceName,
+		VpcEndpointType: pulumi.String("GatewayLoadBalancer"),
+		SubnetIds:       pulumi.StringArray{subnet.ID()},
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateGatewayEndpointRoute creates a route to a destination through a gateway load balancer endpoint
+func CreateGatewayEndpointRoute(meta pgocomp.Meta, provider *aws.Provider, rt *ec2.RouteTable, endpoint *ec2.VpcEndpoint, destination string) *pgocomp.ComponentWithMeta[*ec2.Route] {
+	return awsc.NewRoute(
+		meta,
+		&ec2.RouteArgs{
+			RouteTableId:         rt.ID(),
+			DestinationCidrBlock: pulumi.String(destination),
+			VpcEndpointId:        endpoint.ID(),
+		},
+		pulumi.Provider(provider), pulumi.Protect(meta.Protect),
+	)
+}
@@ -0,0 +1,143 @@
+package awscinfra
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ecsTasksPrincipal is the service that assumes the task and execution roles
+const ecsTasksPrincipal = "ecs-tasks.amazonaws.com"
+
+// ec2Principal is the service that assumes the roles of the instances
+const ec2Principal = "ec2.amazonaws.com"
+
+// ecrImagePattern matches the images of private ECR repositories, like 123456789012.dkr.ecr.us-east-1.amazonaws.com/app:latest
+var ecrImagePattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?/([^:@]+)`)
+
+// CreateRoleComponent creates a role that a service, like ecs-tasks.amazonaws.com, can assume, with an inline policy
+// when the document is set and an attachment for each managed policy
+func CreateRoleComponent(meta pgocomp.Meta, principal string, policy pulumi.StringInput, managedPolicyArns []string, provider *aws.Provider) *pgocomp.ComponentWithMeta[*RoleComponent] {
+	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *RoleComponent, err error) {
+		response = &RoleComponent{
+			Attachments: make(map[string]*pgocomp.GetComponentWithMetaResponse[*iam.RolePolicyAttachment]),
+		}
+		err = awsc.NewRole(meta, &iam.RoleArgs{
+			AssumeRolePolicy: assumeRolePolicy(principal),
+		}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(role *pgocomp.GetComponentWithMetaResponse[*iam.Role]) (err error) {
+			response.Role = role
+			if policy != nil {
+				err = awsc.NewRolePolicy(meta.Child("policy"), &iam.RolePolicyArgs{
+					Role:   role.Component.ID(),
+					Policy: policy,
+				}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(p *pgocomp.GetComponentWithMetaResponse[*iam.RolePolicy]) error {
+					response.Policy = p
+					return nil
+				})
+				if err != nil {
+					return
+				}
+			}
+			for _, arn := range managedPolicyArns {
+				policyName := policyNameOf(arn)
+				err = awsc.NewRolePolicyAttachment(meta.Child(policyName), &iam.RolePolicyAttachmentArgs{
+					Role:      role.Component.ID(),
+					PolicyArn: pulumi.String(arn),
+				}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(a *pgocomp.GetComponentWithMetaResponse[*iam.RolePolicyAttachment]) error {
+					response.Attachments[policyName] = a
+					return nil
+				})
+				if err != nil {
+					return
+				}
+			}
+			return
+		})
+		return
+	})
+}
+
+// assumeRolePolicy is the trust policy that lets a service assume a role
+func assumeRolePolicy(service string) pulumi.StringInput {
+	return pulumi.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"` + service + `"},"Action":"sts:AssumeRole"}]}`)
+}
+
+// policyDocument returns the json document of a policy. The resources of the statements can be outputs
+func policyDocument(statements pulumi.Array) pulumi.StringOutput {
+	return pulumi.JSONMarshal(pulumi.Map{
+		"Version":   pulumi.String("2012-10-17"),
+		"Statement": statements,
+	})
+}
+
+// policyStatement returns a statement of a policy document
+func policyStatement(effect PolicyEffect, actions []string, resources pulumi.StringArrayInput) pulumi.Map {
+	return pulumi.Map{
+		"Effect":   pulumi.String(valueOrDefault(effect, Allow)),
+		"Action":   pulumi.ToStringArray(actions),
+		"Resource": resources,
+	}
+}
+
+// policyNameOf returns the name of a managed policy, like AmazonS3ReadOnlyAccess for arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess
+func policyNameOf(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
+// taskRolePolicy returns the inline policy of the task role, or nil when it has no statements
+func (p *TaskRoleParameters) taskRolePolicy() pulumi.StringInput {
+	if len(p.Statements) == 0 {
+		return nil
+	}
+	var statements pulumi.Array
+	for _, s := range p.Statements {
+		statements = append(statements, policyStatement(s.Effect, s.Actions, pulumi.ToStringArray(s.Resources)))
+	}
+	return policyDocument(statements)
+}
+
+// isEmpty is true when the task doesn't need a task role
+func (p *TaskRoleParameters) isEmpty() bool {
+	return len(p.Statements) == 0 && len(p.ManagedPolicyArns) == 0
+}
+
+// executionStatements are the statements of the execution role, from what the containers need to start.
+// The log group is nil when the logs are disabled, and the secrets are the ones created for the containers
+func (p *ECSServiceParameters) executionStatements(logGroup *cloudwatch.LogGroup, secrets map[string]*pgocomp.GetComponentWithMetaResponse[*SecretComponent]) (statements pulumi.Array) {
+	if logGroup != nil {
+		statements = append(statements, policyStatement(Allow, []string{"logs:CreateLogStream", "logs:PutLogEvents"},
+			pulumi.StringArray{pulumi.Sprintf("%s:*", logGroup.Arn)}))
+	}
+	statements = append(statements, p.secretStatements(secrets)...)
+	var repositories []string
+	added := make(map[string]bool)
+	for _, c := range p.Containers {
+		match := ecrImagePattern.FindStringSubmatch(c.Image)
+		if match == nil {
+			continue
+		}
+		partition := "aws"
+		if match[3] != "" {
+			partition = "aws-cn"
+		}
+		arn := "arn:" + partition + ":ecr:" + match[2] + ":" + match[1] + ":repository/" + match[4]
+		if !added[arn] {
+			added[arn] = true
+			repositories = append(repositories, arn)
+		}
+	}
+	if len(repositories) > 0 {
+		statements = append(statements,
+			policyStatement(Allow, []string{"ecr:GetAuthorizationToken"}, pulumi.ToStringArray([]string{"*"})),
+			policyStatement(Allow, []string{"ecr:BatchCheckLayerAvailability", "ecr:GetDownloadUrlForLayer", "ecr:BatchGetImage"}, pulumi.ToStringArray(repositories)),
+		)
+	}
+	return
+}
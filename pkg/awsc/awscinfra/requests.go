@@ -2,12 +2,16 @@ package awscinfra
 
 import (
 	"github.com/fpco-internal/pgocomp"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 // InfraParameters is a configuration for a
 type InfraParameters struct {
 	pgocomp.Meta
 	Vpcs []VpcParameters
+	// Workers is the number of Vpcs created side by side. Vpcs are created one after another when it is zero
+	Workers int
 }
 
 // ProviderParameters are used to create new region infrastructure
@@ -23,12 +27,109 @@ type VpcParameters struct {
 	CidrBlock    string
 	Partitions   []NetworkPartitionParameters
 	Certificates []CertificateParameters
+	//HostedZones are created before the certificates, which can validate their domains in them
+	HostedZones []HostedZoneParameters
+	//NatMode tells how many NAT gateways give internet access to the private partitions. Defaults to NatNone, so existing Vpcs
+	//do not get NAT gateways they did not ask for
+	NatMode NatMode
+	//Namespace is the private DNS namespace of the services of the clusters that have none
+	Namespace *NamespaceParameters
+	//GatewayEndpoints are created once the partitions and their gateway load balancers exist
+	GatewayEndpoints []GatewayEndpointParameters
 }
 
+// NatMode is the number of NAT gateways created in the public subnets of a Vpc
+type NatMode string
+
+const (
+	//NatPerAZ creates a NAT gateway in each availability zone that has a public subnet, so private subnets keep their egress when a zone fails
+	NatPerAZ NatMode = "per-az"
+	//NatSingle creates one NAT gateway shared by all the private subnets of the Vpc
+	NatSingle NatMode = "single"
+	//NatNone creates no NAT gateway, private subnets have no internet access
+	NatNone NatMode = "none"
+)
+
 // ECSClusterParameters are parameters to create an ECS Cluster
 type ECSClusterParameters struct {
 	pgocomp.Meta
 	Services []ECSServiceParameters
+	//CapacityProviders are the capacity providers that the services of the cluster can use in their strategy
+	CapacityProviders []CapacityProviderParameters
+	//Namespace is the private DNS namespace of the services of the cluster. It defaults to the namespace of the Vpc
+	Namespace *NamespaceParameters
+
+	//defaultTags are the default tags of the provider, set on the clusters of a partition
+	defaultTags map[string]string
+	//public tells if the partition of the cluster is public, set on the clusters of a partition
+	public bool
+}
+
+// NamespaceParameters are a Cloud Map private DNS namespace, where the services register and find each other
+type NamespaceParameters struct {
+	pgocomp.Meta
+	//Domain is the name of the namespace, like internal.local. It resolves only inside the Vpc
+	Domain      string
+	Description string
+}
+
+// CapacityProviderType is the kind of capacity of a capacity provider
+type CapacityProviderType string
+
+const (
+	//FargateCapacity runs the tasks on Fargate
+	FargateCapacity CapacityProviderType = "FARGATE"
+	//FargateSpotCapacity runs the tasks on spare Fargate capacity, which can be interrupted
+	FargateSpotCapacity CapacityProviderType = "FARGATE_SPOT"
+	//EC2Capacity runs the tasks on the instances of an Auto Scaling group
+	EC2Capacity CapacityProviderType = "EC2"
+)
+
+// CapacityProviderParameters are a capacity provider of a cluster. The services refer to it by its name
+type CapacityProviderParameters struct {
+	pgocomp.Meta
+	Type CapacityProviderType
+	//EC2 is the Auto Scaling group of an EC2 capacity provider
+	EC2 EC2CapacityParameters
+}
+
+// DefaultECSOptimizedAMIParameter is the Parameter Store parameter of the recommended ECS-optimized Amazon Linux 2 AMI
+const DefaultECSOptimizedAMIParameter = "/aws/service/ecs/optimized-ami/amazon-linux-2/recommended/image_id"
+
+// EC2CapacityParameters are the Auto Scaling group of an EC2 capacity provider, and the launch template of its instances
+type EC2CapacityParameters struct {
+	//InstanceTypes are the types of the instances, by order of priority, like m6i.large
+	InstanceTypes []string
+	MinSize       int
+	MaxSize       int
+	//ImageID is the AMI of the instances. It defaults to the AMI of DefaultECSOptimizedAMIParameter
+	ImageID        string
+	ManagedScaling EC2ManagedScaling
+	//ManagedTerminationProtection keeps the group from terminating the instances that run tasks when it scales in
+	ManagedTerminationProtection bool
+
+	//defaultTags are the default tags of the provider, which AWS does not add to the instances launched by the group
+	defaultTags map[string]string
+	//associatePublicIP gives the instances a public ip address, so they reach the internet gateway of a public partition
+	associatePublicIP bool
+}
+
+// EC2ManagedScaling lets ECS scale the Auto Scaling group to the tasks it runs
+type EC2ManagedScaling struct {
+	Disabled bool
+	//TargetCapacity is the utilization of the instances that ECS keeps, in percent. It defaults to 100
+	TargetCapacity         int
+	MinimumScalingStepSize int
+	MaximumScalingStepSize int
+}
+
+// CapacityProviderStrategyItem is a capacity provider of the strategy of a service
+type CapacityProviderStrategyItem struct {
+	CapacityProviderLookupName string
+	//Weight is the share of the tasks, after the bases, that run on the capacity provider
+	Weight int
+	//Base is the number of tasks that run on the capacity provider before the weights apply
+	Base int
 }
 
 // ECSServiceParameters defines containers to be used in the Infra
@@ -39,6 +140,236 @@ type ECSServiceParameters struct {
 	Memory         int
 	AssignPublicIP bool
 	Containers     []ContainerDefinition
+	//TaskRole are the permissions of the containers. The task has no role when it is empty
+	TaskRole TaskRoleParameters
+	//Logs send the output of the containers to a CloudWatch log group of the service
+	Logs LogParameters
+	//CapacityProviderStrategy spreads the tasks over capacity providers of the cluster. The tasks run on Fargate without it
+	CapacityProviderStrategy []CapacityProviderStrategyItem
+	//AutoScaling changes the desired count of the service between its capacities, when it is set.
+	//DesiredCount is then only the count of the first deployment, and defaults to MinCapacity
+	AutoScaling *ServiceAutoScalingParameters
+	//ServiceDiscovery registers the tasks in the Cloud Map namespace of the cluster, when it is set
+	ServiceDiscovery *ServiceDiscoveryParameters
+	//ServiceConnect lets the service call the Service Connect services of the namespace, and publish its own, when it is set
+	ServiceConnect *ServiceConnectParameters
+	//Deployment tells how ECS, or CodeDeploy in the blue/green mode, replaces the tasks when the service changes
+	Deployment DeploymentParameters
+}
+
+// DeploymentParameters are the deployment settings of a service. ECS replaces the tasks in a rolling deployment by default
+type DeploymentParameters struct {
+	//CircuitBreaker stops a rolling deployment whose tasks keep failing, and Rollback then restores the last deployment that succeeded
+	CircuitBreaker bool
+	Rollback       bool
+	//MinimumHealthyPercent and MaximumPercent bound the running tasks during a deployment, in percent of the desired count.
+	//They default to 100 and 200
+	MinimumHealthyPercent *int
+	MaximumPercent        int
+	//HealthCheckGracePeriod is the time, in seconds, that ECS ignores the health checks of the target groups for new tasks
+	HealthCheckGracePeriod int
+	//BlueGreen hands the deployments to CodeDeploy, which shifts the traffic to a new set of tasks, when it is set
+	BlueGreen *BlueGreenParameters
+}
+
+// DefaultDeploymentConfig shifts all the traffic to the new tasks at once
+const DefaultDeploymentConfig = "CodeDeployDefault.ECSAllAtOnce"
+
+// BlueGreenParameters are the traffic routes of a blue/green deployment. The new tasks are registered in a copy of the
+// target group, which a test listener forwards to until the production listener shifts to it
+type BlueGreenParameters struct {
+	//TargetGroupLookupName is the target group of the service that receives the production traffic
+	TargetGroupLookupName string
+	//LoadBalancerLookupName and ListenerLookupName are the production listener, which forwards to the target group
+	LoadBalancerLookupName string
+	ListenerLookupName     string
+	//TestListenerPort is the port of the test listener, open only to the Vpc
+	TestListenerPort int
+	//DeploymentConfigName is how the traffic shifts, like CodeDeployDefault.ECSCanary10Percent5Minutes. Defaults to DefaultDeploymentConfig
+	DeploymentConfigName string
+	//TerminationWaitTime is the time, in minutes, the old tasks keep running once the traffic has shifted. Defaults to 5
+	TerminationWaitTime int
+}
+
+// ServiceDiscoveryParameters are the DNS records of the tasks of a service in the namespace of its cluster
+type ServiceDiscoveryParameters struct {
+	//Name is the name of the service in the namespace, like api for api.internal.local. It defaults to the name of the service
+	Name string
+	//RecordTypes are RecordA, RecordSRV or both. They default to RecordA
+	RecordTypes []DNSRecordType
+	//TTL is the time to live of the records, in seconds. It defaults to 10
+	TTL int
+	//PortName is the named port mapping of the SRV records
+	PortName string
+}
+
+// ServiceConnectParameters are the Service Connect configuration of a service. A service without Services is only a client
+type ServiceConnectParameters struct {
+	Services []ServiceConnectService
+}
+
+// ServiceConnectService publishes a named port mapping of the service to the other services of the namespace
+type ServiceConnectService struct {
+	PortName string
+	//DiscoveryName is the name of the service in the namespace. It defaults to the port name
+	DiscoveryName string
+	//DNSName is the name the clients call. It defaults to the discovery name
+	DNSName string
+	//ClientPort is the port the clients call. It defaults to the container port
+	ClientPort int
+}
+
+// ServiceAutoScalingParameters are the capacities of a service and the policies and actions that scale it
+type ServiceAutoScalingParameters struct {
+	MinCapacity      int
+	MaxCapacity      int
+	TargetTracking   []TargetTrackingScalingParameters
+	StepScaling      []StepScalingParameters
+	ScheduledActions []ScheduledScalingParameters
+}
+
+// ScalingMetric is a metric of the service tracked by a target tracking policy
+type ScalingMetric string
+
+const (
+	//ScaleOnCPU tracks the average cpu utilization of the service, in percent
+	ScaleOnCPU ScalingMetric = "ECSServiceAverageCPUUtilization"
+	//ScaleOnMemory tracks the average memory utilization of the service, in percent
+	ScaleOnMemory ScalingMetric = "ECSServiceAverageMemoryUtilization"
+	//ScaleOnRequestCount tracks the requests per target of a target group of an application load balancer
+	ScaleOnRequestCount ScalingMetric = "ALBRequestCountPerTarget"
+)
+
+// TargetTrackingScalingParameters keep a metric of the service around a target value
+type TargetTrackingScalingParameters struct {
+	Name        string
+	Metric      ScalingMetric
+	TargetValue float64
+	//TargetGroupLookupName is the target group whose requests are counted, with ScaleOnRequestCount
+	TargetGroupLookupName string
+	//ScaleInCooldown and ScaleOutCooldown are in seconds
+	ScaleInCooldown  int
+	ScaleOutCooldown int
+	DisableScaleIn   bool
+}
+
+// AdjustmentType tells how the steps of a step scaling policy change the desired count
+type AdjustmentType string
+
+const (
+	//ChangeInCapacity adds the adjustment to the desired count
+	ChangeInCapacity AdjustmentType = "ChangeInCapacity"
+	//PercentChangeInCapacity changes the desired count by the adjustment, in percent
+	PercentChangeInCapacity AdjustmentType = "PercentChangeInCapacity"
+	//ExactCapacity sets the desired count to the adjustment
+	ExactCapacity AdjustmentType = "ExactCapacity"
+)
+
+// StepScalingParameters change the desired count when an alarm on a CloudWatch metric fires
+type StepScalingParameters struct {
+	Name  string
+	Alarm ScalingAlarmParameters
+	Steps []ScalingStep
+	//AdjustmentType defaults to ChangeInCapacity
+	AdjustmentType AdjustmentType
+	//Cooldown is in seconds
+	Cooldown int
+}
+
+// ScalingAlarmParameters is the alarm of a step scaling policy, on any CloudWatch metric
+type ScalingAlarmParameters struct {
+	Namespace  string
+	MetricName string
+	Dimensions map[string]string
+	//Statistic is Average, Minimum, Maximum, Sum or SampleCount. It defaults to Average
+	Statistic string
+	//Period is in seconds, and defaults to 60
+	Period int
+	//EvaluationPeriods defaults to 1
+	EvaluationPeriods int
+	//ComparisonOperator is like GreaterThanOrEqualToThreshold or LessThanThreshold
+	ComparisonOperator string
+	Threshold          float64
+}
+
+// ScalingStep is an adjustment applied when the metric is between the bounds, relative to the threshold of the alarm.
+// A nil bound is infinite
+type ScalingStep struct {
+	LowerBound *float64
+	UpperBound *float64
+	Adjustment int
+}
+
+// ScheduledScalingParameters change the capacities of the service on a schedule
+type ScheduledScalingParameters struct {
+	Name string
+	//Schedule is like cron(0 8 * * ? *), rate(1 day) or at(2024-01-01T00:00:00)
+	Schedule string
+	//Timezone is like Europe/Paris. It defaults to UTC
+	Timezone    string
+	MinCapacity int
+	MaxCapacity int
+}
+
+// LogParameters configure the log group of a service and how its containers log to it
+type LogParameters struct {
+	//Enabled creates the log group and the log configuration of the containers. Services have no logs without it
+	Enabled bool
+	//RetentionInDays is one of the periods accepted by CloudWatch. The events never expire when it is 0
+	RetentionInDays int
+	//KmsKeyArn encrypts the log group. The key policy must allow the CloudWatch Logs service to use it
+	KmsKeyArn string
+	//StreamPrefix prefixes the log streams of the containers. It defaults to the name of the service
+	StreamPrefix string
+	//FireLens routes the logs of the containers through a Fluent Bit sidecar, when it is set
+	FireLens *FireLensParameters
+}
+
+// DefaultFireLensImage is the Fluent Bit image of the FireLens sidecar
+const DefaultFireLensImage = "public.ecr.aws/aws-observability/aws-for-fluent-bit:stable"
+
+// FireLensContainerName is the name of the FireLens sidecar
+const FireLensContainerName = "log_router"
+
+// FireLensParameters configure the Fluent Bit sidecar that routes the logs of the containers.
+// The sidecar writes its own logs to the log group of the service
+type FireLensParameters struct {
+	//Image defaults to DefaultFireLensImage
+	Image string
+	//Options configure the Fluent Bit output of the containers, like Name: cloudwatch_logs or Name: firehose.
+	//The permissions to write to the output are given in the task role
+	Options map[string]string
+	//CPU and Memory are reserved for the sidecar from the resources of the service
+	CPU    int64
+	Memory int64
+}
+
+// TaskRoleParameters are the permissions given to the containers of a service, through the task role
+type TaskRoleParameters struct {
+	//Statements are the statements of the inline policy of the role
+	Statements []PolicyStatement
+	//ManagedPolicyArns are the arns of the policies attached to the role, like arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess
+	ManagedPolicyArns []string
+}
+
+// PolicyEffect allows or denies the actions of a statement
+type PolicyEffect string
+
+const (
+	//Allow allows the actions of the statement
+	Allow PolicyEffect = "Allow"
+	//Deny denies the actions of the statement, even when another statement allows them
+	Deny PolicyEffect = "Deny"
+)
+
+// PolicyStatement is a statement of an IAM policy
+type PolicyStatement struct {
+	//Effect defaults to Allow
+	Effect PolicyEffect
+	//Actions are like s3:GetObject, or s3:* for every action of a service
+	Actions []string
+	//Resources are the arns the actions apply to, or * for every resource
+	Resources []string
 }
 
 // NetworkPartitionParameters defines the parameter for a network block, like public or private
@@ -49,6 +380,9 @@ type NetworkPartitionParameters struct {
 	LoadBalancers  []LoadBalancerParameters
 	LBTargetGroups []LBTargetGroupParameters
 	ECSClusters    []ECSClusterParameters
+
+	//defaultTags are the default tags of the provider, set on the partitions of a Vpc
+	defaultTags map[string]string
 }
 
 // ContainerDefinition defines containers to be used in the Infra
@@ -62,6 +396,32 @@ type ContainerDefinition struct {
 	Command      []string                  `json:"command,omitempty"`
 	CPU          int64                     `json:"cpu,omitempty"`
 	Memory       int64                     `json:"memory,omitempty"`
+	//Secrets are environment variables read by ECS from Secrets Manager or Parameter Store when the container starts
+	Secrets []ContainerSecret `json:"-"`
+}
+
+// SecretSource is the service that stores a secret
+type SecretSource string
+
+const (
+	//SecretsManager stores the secret in AWS Secrets Manager
+	SecretsManager SecretSource = "secretsmanager"
+	//ParameterStore stores the secret in a SecureString parameter of the SSM Parameter Store
+	ParameterStore SecretSource = "ssm"
+)
+
+// ContainerSecret is an environment variable of a container whose value is a secret. The secret either exists, and
+// is referenced by its Arn, or is created with the Value
+type ContainerSecret struct {
+	//Name is the name of the environment variable
+	Name   string
+	Source SecretSource
+	//Arn is the arn of an existing secret or parameter. The arn of a Secrets Manager secret can select a json key, like arn:...:secret:db-AbCdEf:password::
+	Arn string
+	//Value creates a secret or a parameter with the value, which can come from a secret of the Pulumi config
+	Value pulumi.StringInput
+	//KmsKeyArn is the key that encrypts the secret, when it isn't the AWS managed key. The execution role may decrypt with it
+	KmsKeyArn string
 }
 
 // ContainerEnvironmentVar ...
@@ -72,12 +432,27 @@ type ContainerEnvironmentVar struct {
 
 // ContainerPortMapping are the ports the the container exposes
 type ContainerPortMapping struct {
-	ContainerPort         int        `json:"containerPort"`
-	HostPort              int        `json:"hostPort"`
-	Protocol              TGProtocol `json:"protocol"`
-	TargetGroupLookupName string     `json:"-"`
+	//Name is the name of the port for Service Connect and SRV records, like grpc
+	Name                  string      `json:"name,omitempty"`
+	ContainerPort         int         `json:"containerPort"`
+	HostPort              int         `json:"hostPort"`
+	Protocol              TGProtocol  `json:"protocol"`
+	AppProtocol           AppProtocol `json:"appProtocol,omitempty"`
+	TargetGroupLookupName string      `json:"-"`
 }
 
+// AppProtocol is the application protocol of a port, which gives Service Connect protocol specific metrics
+type AppProtocol string
+
+const (
+	//AppHTTP ...
+	AppHTTP AppProtocol = "http"
+	//AppHTTP2 ...
+	AppHTTP2 AppProtocol = "http2"
+	//AppGRPC ...
+	AppGRPC AppProtocol = "grpc"
+)
+
 // SubnetParameters are parameters used by the CreateSubnet function
 type SubnetParameters struct {
 	pgocomp.Meta
@@ -97,8 +472,37 @@ const (
 	HTTP2 LBProtocol = "HTTP2"
 	//GRPC is the Google remote procedure call procotol used to change Protobuf messages
 	GRPC LBProtocol = "GRPC"
+	//HTTPS is HTTP over TLS, terminated by an application load balancer
+	HTTPS LBProtocol = "HTTPS"
+	//TLS is TCP over TLS, terminated by a network load balancer
+	TLS LBProtocol = "TLS"
+	//TCP is balanced by a network load balancer
+	TCP LBProtocol = "TCP"
+	//UDP is balanced by a network load balancer
+	UDP LBProtocol = "UDP"
+	//TCPUDP balances TCP and UDP on the same port of a network load balancer
+	TCPUDP LBProtocol = "TCP_UDP"
+)
+
+// AlpnPolicy is the application protocol negotiated by a TLS listener with its clients
+type AlpnPolicy string
+
+const (
+	//AlpnHTTP1Only negotiates HTTP/1.*
+	AlpnHTTP1Only AlpnPolicy = "HTTP1Only"
+	//AlpnHTTP2Only negotiates HTTP/2
+	AlpnHTTP2Only AlpnPolicy = "HTTP2Only"
+	//AlpnHTTP2Optional prefers HTTP/1.* and accepts HTTP/2
+	AlpnHTTP2Optional AlpnPolicy = "HTTP2Optional"
+	//AlpnHTTP2Preferred prefers HTTP/2 and accepts HTTP/1.*
+	AlpnHTTP2Preferred AlpnPolicy = "HTTP2Preferred"
+	//AlpnNone negotiates no application protocol
+	AlpnNone AlpnPolicy = "None"
 )
 
+// DefaultSslPolicy is the security policy of HTTPS and TLS listeners that set no SslPolicy. It allows TLS 1.2 and 1.3
+const DefaultSslPolicy = "ELBSecurityPolicy-TLS13-1-2-2021-06"
+
 // TGProtocol is the network protocol tobe balanced
 type TGProtocol string
 
@@ -115,6 +519,8 @@ const (
 	TGProtoHTTPS TGProtocol = "HTTPS"
 	//TGProtoTLS ...
 	TGProtoTLS TGProtocol = "TLS"
+	//TGProtoGENEVE is the protocol of the appliances behind a gateway load balancer, on port 6081
+	TGProtoGENEVE TGProtocol = "GENEVE"
 )
 
 // LoadBalancerParameters are parameters used by the CreateSubnet function
@@ -123,6 +529,39 @@ type LoadBalancerParameters struct {
 	Type       LBType
 	IsInternal bool
 	Listeners  []LBListenerParameters
+	//CrossZone spreads the requests of a network load balancer over the targets of every availability zone
+	CrossZone bool
+	//ElasticIPs gives an internet facing network load balancer a static address in each subnet
+	ElasticIPs bool
+	//EndpointService is the endpoint service of a gateway load balancer, used by its gateway endpoints
+	EndpointService LBEndpointServiceParameters
+}
+
+// LBEndpointServiceParameters configures the endpoint service of a gateway load balancer
+type LBEndpointServiceParameters struct {
+	//AcceptanceRequired makes the connections of the endpoints wait until they are accepted
+	AcceptanceRequired bool
+	//AllowedPrincipals are the arns of the principals that can create endpoints of the service in other accounts
+	AllowedPrincipals []string
+}
+
+// GatewayEndpointParameters creates a gateway load balancer endpoint in a subnet, and the routes that send traffic through it
+type GatewayEndpointParameters struct {
+	pgocomp.Meta
+	//LoadBalancerLookupName is a gateway load balancer of any partition of the Vpc
+	LoadBalancerLookupName string
+	//PartitionLookupName and SubnetLookupName are the subnet of the endpoint
+	PartitionLookupName string
+	SubnetLookupName    string
+	//Routes send the traffic of partitions through the endpoint
+	Routes []GatewayEndpointRoute
+}
+
+// GatewayEndpointRoute sends the traffic of a partition to a destination through a gateway endpoint
+type GatewayEndpointRoute struct {
+	//PartitionLookupName is the partition whose route tables get the route. Public partitions share the route table of the internet gateway
+	PartitionLookupName  string
+	DestinationCidrBlock string
 }
 
 // LBRuleConditionType ...
@@ -144,9 +583,102 @@ const (
 // LBRuleParameters is the paramenters for a listener
 type LBRuleParameters struct {
 	pgocomp.Meta
+	//TargetGroupLookupName is the target group the rule forwards to, when it has no Actions
 	TargetGroupLookupName string
 	Priority              int
 	Conditions            []LBRuleConditionParameters
+	//Actions replace the forward to TargetGroupLookupName. Authenticate actions come before the last action
+	Actions []LBActionParameters
+}
+
+// LBActionType is the type of an action of a listener or a rule
+type LBActionType string
+
+const (
+	//ActionForward forwards requests to one or more weighted target groups
+	ActionForward LBActionType = "forward"
+	//ActionFixedResponse answers requests with a fixed status code and body, like a maintenance page
+	ActionFixedResponse LBActionType = "fixed-response"
+	//ActionRedirect answers requests with a redirect to another url
+	ActionRedirect LBActionType = "redirect"
+	//ActionAuthenticateOidc authenticates users with an OpenID Connect identity provider before the next action. It needs an HTTPS listener
+	ActionAuthenticateOidc LBActionType = "authenticate-oidc"
+	//ActionAuthenticateCognito authenticates users with an Amazon Cognito user pool before the next action. It needs an HTTPS listener
+	ActionAuthenticateCognito LBActionType = "authenticate-cognito"
+)
+
+// LBActionParameters is an action of a listener or a rule. Only the field of its Type is used
+type LBActionParameters struct {
+	Type                LBActionType
+	Forward             LBForwardAction
+	FixedResponse       LBFixedResponseAction
+	Redirect            LBRedirectAction
+	AuthenticateOidc    LBAuthenticateOidcAction
+	AuthenticateCognito LBAuthenticateCognitoAction
+}
+
+// LBForwardAction splits the requests between target groups by their weights
+type LBForwardAction struct {
+	TargetGroups []LBWeightedTargetGroup
+	//StickinessDuration keeps a client on the same target group for this number of seconds. Stickiness is disabled when it is zero
+	StickinessDuration int
+}
+
+// LBWeightedTargetGroup is a target group of a forward action
+type LBWeightedTargetGroup struct {
+	TargetGroupLookupName string
+	//Weight is the share of the requests sent to the target group, from 0 to 999. It is ignored when there is a single target group
+	Weight int
+}
+
+// LBFixedResponseAction answers requests without a target
+type LBFixedResponseAction struct {
+	//StatusCode is a 2XX, 4XX or 5XX code
+	StatusCode int
+	//ContentType defaults to text/plain
+	ContentType string
+	MessageBody string
+}
+
+// LBRedirectAction redirects requests. Empty parts keep the value of the request
+type LBRedirectAction struct {
+	Protocol string
+	Host     string
+	Port     string
+	Path     string
+	Query    string
+	//Permanent answers with a 301 instead of a 302
+	Permanent bool
+}
+
+// LBAuthenticateOidcAction authenticates users with an OpenID Connect identity provider
+type LBAuthenticateOidcAction struct {
+	Issuer                string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	UserInfoEndpoint      string
+	ClientID              string
+	//ClientSecret is stored as a pulumi secret. It can come from a secret of the Pulumi config
+	ClientSecret pulumi.StringInput
+	//Scope defaults to openid
+	Scope string
+	//OnUnauthenticatedRequest is deny, allow or authenticate, the default
+	OnUnauthenticatedRequest string
+	//SessionTimeout is the duration of the session in seconds. Defaults to 7 days
+	SessionTimeout int
+}
+
+// LBAuthenticateCognitoAction authenticates users with an Amazon Cognito user pool
+type LBAuthenticateCognitoAction struct {
+	UserPoolArn      string
+	UserPoolClientID string
+	UserPoolDomain   string
+	//Scope defaults to openid
+	Scope string
+	//OnUnauthenticatedRequest is deny, allow or authenticate, the default
+	OnUnauthenticatedRequest string
+	//SessionTimeout is the duration of the session in seconds. Defaults to 7 days
+	SessionTimeout int
 }
 
 // LBRuleConditionParameters ...
@@ -168,11 +700,25 @@ type LBRuleConditionParameters struct {
 // LBListenerParameters is the paramenters for a listener
 type LBListenerParameters struct {
 	pgocomp.Meta
-	Port                  int
-	Protocol              LBProtocol
+	Port     int
+	Protocol LBProtocol
+	//TargetGroupLookupName is the target group the listener forwards to, when it has no Actions
 	TargetGroupLookupName string
 	Rules                 []LBRuleParameters
+	//Actions replace the forward to TargetGroupLookupName. Authenticate actions come before the last action
+	Actions []LBActionParameters
+	//CertificateLookupName is the default certificate of HTTPS and TLS listeners
 	CertificateLookupName string
+	//SniCertificateLookupNames are extra certificates, chosen by the load balancer with the server name sent by the client
+	SniCertificateLookupNames []string
+	//SslPolicy is the TLS security policy of HTTPS and TLS listeners. Defaults to DefaultSslPolicy
+	SslPolicy string
+	//RedirectToHTTPS makes the listener answer every request with a 301 redirect to HTTPS on port 443, instead of forwarding it
+	RedirectToHTTPS bool
+	//AlpnPolicy is the application protocol negotiated by TLS listeners. None when empty
+	AlpnPolicy AlpnPolicy
+	//shiftedByCodeDeploy is set on the production listeners of blue/green deployments, whose default actions belong to CodeDeploy
+	shiftedByCodeDeploy bool
 }
 
 // CertificateValidationMethod is the method used to validate the certificate. By DNS or EMAIL
@@ -188,8 +734,79 @@ const (
 // CertificateParameters creates a new certificate
 type CertificateParameters struct {
 	pgocomp.Meta
-	Domain           string
-	ValidationMethod CertificateValidationMethod
+	Domain                  string
+	SubjectAlternativeNames []string
+	ValidationMethod        CertificateValidationMethod
+	//HostedZoneLookupName is a hosted zone of the Vpc where the DNS validation records are created
+	HostedZoneLookupName string
+	//HostedZoneName is the domain of an existing public hosted zone where the DNS validation records are created, like example.com
+	HostedZoneName string
+}
+
+// HostedZoneParameters creates a Route 53 hosted zone
+type HostedZoneParameters struct {
+	pgocomp.Meta
+	//Domain is the name of the zone, like example.com
+	Domain string
+	//Private zones are attached to the Vpc and only resolve inside it
+	Private bool
+	//Records are created in the zone once the load balancers of the Vpc exist
+	Records []AliasRecordParameters
+}
+
+// DNSRecordType is the type of an alias record, or of a record of the tasks of a service in a namespace
+type DNSRecordType string
+
+const (
+	//RecordA is an alias record for the IPv4 addresses of the load balancer
+	RecordA DNSRecordType = "A"
+	//RecordAAAA is an alias record for the IPv6 addresses of a dualstack load balancer
+	RecordAAAA DNSRecordType = "AAAA"
+	//RecordSRV is a record of the address and port of each task of a service, in a namespace
+	RecordSRV DNSRecordType = "SRV"
+)
+
+// RoutingPolicy tells how Route 53 answers when several records have the same name and type
+type RoutingPolicy string
+
+const (
+	//SimpleRouting answers with the only record of the name
+	SimpleRouting RoutingPolicy = "simple"
+	//WeightedRouting answers with the records in proportion to their weights
+	WeightedRouting RoutingPolicy = "weighted"
+	//FailoverRouting answers with the primary record while it is healthy, and with the secondary one otherwise
+	FailoverRouting RoutingPolicy = "failover"
+)
+
+// FailoverRole is the role of a record in the failover routing policy
+type FailoverRole string
+
+const (
+	//FailoverPrimary is the record used while it is healthy
+	FailoverPrimary FailoverRole = "PRIMARY"
+	//FailoverSecondary is the record used when the primary record is unhealthy
+	FailoverSecondary FailoverRole = "SECONDARY"
+)
+
+// AliasRecordParameters creates alias records that point a name at a load balancer of the Vpc
+type AliasRecordParameters struct {
+	pgocomp.Meta
+	//RecordName is the full name of the record, like api.example.com. It must be in the domain of the zone
+	RecordName string
+	//LoadBalancerLookupName is a load balancer of any partition of the Vpc
+	LoadBalancerLookupName string
+	//Types are the types of the records created for the name. Defaults to RecordA
+	Types []DNSRecordType
+	//EvaluateTargetHealth makes Route 53 skip the record when the load balancer has no healthy target
+	EvaluateTargetHealth bool
+	//RoutingPolicy defaults to SimpleRouting. Weighted and failover records need a SetIdentifier
+	RoutingPolicy RoutingPolicy
+	//SetIdentifier tells apart the records of the same name and type
+	SetIdentifier string
+	//Weight is the weight of a record in the WeightedRouting policy, from 0 to 255
+	Weight int
+	//Failover is the role of a record in the FailoverRouting policy
+	Failover FailoverRole
 }
 
 // TargetType the Target type of a Target group
@@ -200,6 +817,44 @@ const (
 	TGIp TargetType = "ip"
 	//TGInstance is a target group that will group members by their instances
 	TGInstance TargetType = "instance"
+	//TGLambda is a target group of one lambda function. It has no port, protocol or vpc
+	TGLambda TargetType = "lambda"
+	//TGAlb is a target group of one application load balancer, behind a network load balancer. Its protocol is TCP
+	TGAlb TargetType = "alb"
+)
+
+// TGProtocolVersion is the version of the HTTP protocol sent to the targets of an HTTP or HTTPS target group
+type TGProtocolVersion string
+
+const (
+	//TGHTTP1 sends the requests to the targets with HTTP/1.1
+	TGHTTP1 TGProtocolVersion = "HTTP1"
+	//TGHTTP2 sends the requests to the targets with HTTP/2
+	TGHTTP2 TGProtocolVersion = "HTTP2"
+	//TGGRPC sends gRPC requests to the targets
+	TGGRPC TGProtocolVersion = "GRPC"
+)
+
+// LBAlgorithm is the algorithm used to pick the target of a request in an HTTP or HTTPS target group
+type LBAlgorithm string
+
+const (
+	//RoundRobin sends the requests to the targets in turn
+	RoundRobin LBAlgorithm = "round_robin"
+	//LeastOutstandingRequests sends a request to the target with the fewest requests in progress
+	LeastOutstandingRequests LBAlgorithm = "least_outstanding_requests"
+)
+
+// StickinessType is how a target group binds the requests of a client to a target
+type StickinessType string
+
+const (
+	//StickyLBCookie binds the client with a cookie generated by the load balancer. HTTP and HTTPS target groups only
+	StickyLBCookie StickinessType = "lb_cookie"
+	//StickyAppCookie binds the client with a cookie of the application, named by CookieName. HTTP and HTTPS target groups only
+	StickyAppCookie StickinessType = "app_cookie"
+	//StickySourceIP binds the client by its ip address. TCP, UDP and TLS target groups only
+	StickySourceIP StickinessType = "source_ip"
 )
 
 // LBTargetGroupParameters is the paramenters for a listener
@@ -209,6 +864,28 @@ type LBTargetGroupParameters struct {
 	Protocol    TGProtocol
 	TargetType  TargetType
 	HealthCheck LBTargetGroupHealthCHeck
+	//ProtocolVersion is the version of HTTP used with the targets of HTTP and HTTPS target groups. Defaults to TGHTTP1
+	ProtocolVersion TGProtocolVersion
+	//DeregistrationDelay is the time in seconds given to a target to finish its requests before it is removed, up to 3600. Defaults to 300
+	DeregistrationDelay int
+	//SlowStart is the time in seconds during which a new target receives a growing share of the requests, from 30 to 900. Disabled when zero
+	SlowStart int
+	//Algorithm is the load balancing algorithm of HTTP and HTTPS target groups. Defaults to RoundRobin
+	Algorithm LBAlgorithm
+	//Stickiness binds the requests of a client to one target. Disabled when its type is empty
+	Stickiness LBTargetGroupStickiness
+	//LoadBalancerLookupName is the application load balancer of the partition registered in an alb target group.
+	//It needs a listener on the port of the target group
+	LoadBalancerLookupName string
+}
+
+// LBTargetGroupStickiness binds the requests of a client to one target of the target group
+type LBTargetGroupStickiness struct {
+	Type StickinessType
+	//Duration is the lifetime of the cookie in seconds, from 1 to 604800. Defaults to 86400
+	Duration int
+	//CookieName is the cookie of the application used by StickyAppCookie
+	CookieName string
 }
 
 // LBTargetGroupHealthCHeck ...
@@ -219,6 +896,12 @@ type LBTargetGroupHealthCHeck struct {
 	UnhealthyThreshold int
 	Timeout            int
 	StatusCodeRange    string
+	//Port is the port of the targets checked, or traffic-port for the port that receives the requests. Defaults to traffic-port
+	Port string
+	//Protocol is the protocol of the check: HTTP, HTTPS or TCP. Defaults to the protocol of HTTP and HTTPS target groups, and to TCP otherwise
+	Protocol TGProtocol
+	//Interval is the time in seconds between two checks, from 5 to 300. Defaults to 30
+	Interval int
 }
 
 // GatewayParameters are parameters used by the CreateSubnet function
@@ -227,12 +910,15 @@ type GatewayParameters struct {
 }
 
 const (
-	//Classic is the classic elastic load balancer that works on layer 4 and 7
-	Classic LBType = "gateway"
+	//Classic is the classic elastic load balancer that works on layer 4 and 7. It is not supported by awscinfra
+	Classic LBType = "classic"
 
 	//Application is the application load balancer that works on layer 7
 	Application LBType = "application"
 
 	//Network is the network load balancer that works on layer 4
 	Network LBType = "network"
+
+	//Gateway is the gateway load balancer that sends the traffic of the Vpc to virtual appliances, like firewalls, with GENEVE
+	Gateway LBType = "gateway"
 )
@@ -5,9 +5,19 @@ import (
 
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/acm"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/appautoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/autoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/codedeploy"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ec2"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
 	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/route53"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/servicediscovery"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
 // SingleRegionInfra is the return type of the function NewBasicNetworkComponent
@@ -31,26 +41,196 @@ type VpcComponent struct {
 		DefaultRoute         *pgocomp.GetComponentWithMetaResponse[*ec2.Route]
 	}
 	Partitions   map[string]*pgocomp.GetComponentWithMetaResponse[*NetworkPartitionComponent]
-	Certificates map[string]*pgocomp.GetComponentWithMetaResponse[*acm.Certificate]
+	Certificates map[string]*pgocomp.GetComponentWithMetaResponse[*CertificateComponent]
+	HostedZones  map[string]*pgocomp.GetComponentWithMetaResponse[*route53.Zone]
+	//AliasRecords are the records of the hosted zones, by resource name
+	AliasRecords map[string]*pgocomp.GetComponentWithMetaResponse[*route53.Record]
+	//NatGateways and ElasticIPs are indexed by availability zone, or by an empty zone in the NatSingle mode
+	NatGateways map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.NatGateway]
+	ElasticIPs  map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Eip]
+	//GatewayEndpoints are the gateway load balancer endpoints, by name, and GatewayEndpointRoutes their routes, by resource name
+	GatewayEndpoints      map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.VpcEndpoint]
+	GatewayEndpointRoutes map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Route]
+	Namespace             *pgocomp.GetComponentWithMetaResponse[*servicediscovery.PrivateDnsNamespace]
+}
+
+// NatGateways are the NAT gateways of a Vpc by availability zone. A single NAT gateway is stored under the empty zone
+type NatGateways map[string]*ec2.NatGateway
+
+// For returns the NAT gateway used by the subnets of an availability zone, or nil
+func (n NatGateways) For(az string) *ec2.NatGateway {
+	if nat, ok := n[az]; ok {
+		return nat
+	}
+	return n[""]
 }
 
 // NetworkPartitionComponent is the response of CreateBlockComponent
 type NetworkPartitionComponent struct {
-	Subnets       map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Subnet]
+	Subnets map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Subnet]
+	//AvailabilityZones are the zones of the subnets, by subnet name
+	AvailabilityZones map[string]string
+	//RouteTables are the route tables of a private partition, by availability zone in the NatPerAZ mode, or by an empty zone
+	RouteTables   map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.RouteTable]
 	LoadBalancers map[string]*pgocomp.GetComponentWithMetaResponse[*LoadBalancerComponent]
 	TargetGroups  map[string]*pgocomp.GetComponentWithMetaResponse[*lb.TargetGroup]
 	ECSClusters   map[string]*pgocomp.GetComponentWithMetaResponse[*ECSClusterComponent]
+	//TargetGroupAttachments register the application load balancers in alb target groups, by target group name
+	TargetGroupAttachments map[string]*pgocomp.GetComponentWithMetaResponse[*lb.TargetGroupAttachment]
 }
 
 // LoadBalancerComponent is the response of CreateLoadBalancerComponent function
 type LoadBalancerComponent struct {
-	LoadBalancer  *pgocomp.GetComponentWithMetaResponse[*lb.LoadBalancer]
+	LoadBalancer *pgocomp.GetComponentWithMetaResponse[*lb.LoadBalancer]
+	//SecurityGroup is the security group of an application load balancer. Network load balancers have none
 	SecurityGroup *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]
 	Listeners     map[string]*pgocomp.GetComponentWithMetaResponse[*lb.Listener]
+	//ElasticIPs are the static addresses of a network load balancer, by subnet name
+	ElasticIPs map[string]*pgocomp.GetComponentWithMetaResponse[*ec2.Eip]
+	//EndpointService is the endpoint service of a gateway load balancer
+	EndpointService *pgocomp.GetComponentWithMetaResponse[*ec2.VpcEndpointService]
+}
+
+// CertificateComponent holds a certificate and, when it is validated by DNS in a hosted zone, its validation records and validation
+type CertificateComponent struct {
+	Certificate       *pgocomp.GetComponentWithMetaResponse[*acm.Certificate]
+	ValidationRecords map[string]*pgocomp.GetComponentWithMetaResponse[*route53.Record]
+	Validation        *pgocomp.GetComponentWithMetaResponse[*acm.CertificateValidation]
+}
+
+// Arn returns the arn of the certificate once it is issued, or right away when it is not validated in a hosted zone
+func (c *CertificateComponent) Arn() pulumi.StringOutput {
+	if c.Validation != nil {
+		return c.Validation.Component.CertificateArn
+	}
+	return c.Certificate.Component.Arn
 }
 
 // ECSClusterComponent holds the created cluster components
 type ECSClusterComponent struct {
 	Cluster         *pgocomp.GetComponentWithMetaResponse[*ecs.Cluster]
-	FargateServices map[string]*pgocomp.GetComponentWithMetaResponse[*ecs.Service]
+	FargateServices map[string]*pgocomp.GetComponentWithMetaResponse[*ECSServiceComponent]
+	//CapacityProviders are the EC2 capacity providers, by name, and CapacityProviderAssociation associates every capacity provider with the cluster
+	CapacityProviders           map[string]*pgocomp.GetComponentWithMetaResponse[*EC2CapacityProviderComponent]
+	CapacityProviderAssociation *pgocomp.GetComponentWithMetaResponse[*ecs.ClusterCapacityProviders]
+	//Namespace is the namespace of the cluster, when it has its own
+	Namespace *pgocomp.GetComponentWithMetaResponse[*servicediscovery.PrivateDnsNamespace]
+}
+
+// EC2CapacityProviderComponent is an EC2 capacity provider with its Auto Scaling group and the launch template,
+// the role and the security group of its instances
+type EC2CapacityProviderComponent struct {
+	CapacityProvider *pgocomp.GetComponentWithMetaResponse[*ecs.CapacityProvider]
+	AutoScalingGroup *pgocomp.GetComponentWithMetaResponse[*autoscaling.Group]
+	LaunchTemplate   *pgocomp.GetComponentWithMetaResponse[*ec2.LaunchTemplate]
+	InstanceRole     *pgocomp.GetComponentWithMetaResponse[*RoleComponent]
+	InstanceProfile  *pgocomp.GetComponentWithMetaResponse[*iam.InstanceProfile]
+	SecurityGroup    *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]
+}
+
+// ECSServiceComponent is the response of CreateEcsFargateServiceComponent function
+type ECSServiceComponent struct {
+	Service        *pgocomp.GetComponentWithMetaResponse[*ecs.Service]
+	TaskDefinition *pgocomp.GetComponentWithMetaResponse[*ecs.TaskDefinition]
+	SecurityGroup  *pgocomp.GetComponentWithMetaResponse[*ec2.SecurityGroup]
+	//TaskRole is the role of the containers, when the service has TaskRole parameters
+	TaskRole *pgocomp.GetComponentWithMetaResponse[*RoleComponent]
+	//ExecutionRole is the role used by ECS to start the containers, when they need one
+	ExecutionRole *pgocomp.GetComponentWithMetaResponse[*RoleComponent]
+	//LogGroup receives the logs of the containers, unless the logs are disabled
+	LogGroup *pgocomp.GetComponentWithMetaResponse[*cloudwatch.LogGroup]
+	//Secrets are the secrets created from the values of the containers, by container and variable name, like app/DB_PASSWORD
+	Secrets map[string]*pgocomp.GetComponentWithMetaResponse[*SecretComponent]
+	//AutoScaling scales the service, when it has AutoScaling parameters
+	AutoScaling *pgocomp.GetComponentWithMetaResponse[*ServiceAutoScalingComponent]
+	//DiscoveryService is the Cloud Map service of the tasks, when the service has ServiceDiscovery parameters
+	DiscoveryService *pgocomp.GetComponentWithMetaResponse[*servicediscovery.Service]
+	//BlueGreen deploys the service, in the blue/green mode
+	BlueGreen *pgocomp.GetComponentWithMetaResponse[*BlueGreenComponent]
+}
+
+// BlueGreenComponent is the CodeDeploy application and deployment group of a service, with the role of the deployment group
+type BlueGreenComponent struct {
+	Application     *pgocomp.GetComponentWithMetaResponse[*codedeploy.Application]
+	DeploymentGroup *pgocomp.GetComponentWithMetaResponse[*codedeploy.DeploymentGroup]
+	Role            *pgocomp.GetComponentWithMetaResponse[*RoleComponent]
+}
+
+// ServiceAutoScalingComponent is the scalable target of a service with its policies, by name, the alarms of its step
+// scaling policies, by policy name, and its scheduled actions, by name
+type ServiceAutoScalingComponent struct {
+	Target           *pgocomp.GetComponentWithMetaResponse[*appautoscaling.Target]
+	Policies         map[string]*pgocomp.GetComponentWithMetaResponse[*appautoscaling.Policy]
+	Alarms           map[string]*pgocomp.GetComponentWithMetaResponse[*cloudwatch.MetricAlarm]
+	ScheduledActions map[string]*pgocomp.GetComponentWithMetaResponse[*appautoscaling.ScheduledAction]
+}
+
+// SecretComponent is a secret created for a container, either a Secrets Manager secret and its version or a Parameter Store parameter
+type SecretComponent struct {
+	Secret        *pgocomp.GetComponentWithMetaResponse[*secretsmanager.Secret]
+	SecretVersion *pgocomp.GetComponentWithMetaResponse[*secretsmanager.SecretVersion]
+	Parameter     *pgocomp.GetComponentWithMetaResponse[*ssm.Parameter]
+}
+
+// Arn returns the arn of the secret or of the parameter
+func (s *SecretComponent) Arn() pulumi.StringOutput {
+	if s.Parameter != nil {
+		return s.Parameter.Component.Arn
+	}
+	return s.Secret.Component.Arn
+}
+
+// RoleComponent is an IAM role with its inline policy and its managed policy attachments, by policy name
+type RoleComponent struct {
+	Role        *pgocomp.GetComponentWithMetaResponse[*iam.Role]
+	Policy      *pgocomp.GetComponentWithMetaResponse[*iam.RolePolicy]
+	Attachments map[string]*pgocomp.GetComponentWithMetaResponse[*iam.RolePolicyAttachment]
+}
+
+// Outputs are registered in the ComponentResource of the Vpc when Meta.RegisterComponent is set
+func (v *VpcComponent) Outputs() pulumi.Map {
+	outputs := pulumi.Map{}
+	if v.Vpc != nil && v.Vpc.Component != nil {
+		outputs["vpcId"] = v.Vpc.Component.ID()
+		outputs["cidrBlock"] = v.Vpc.Component.CidrBlock
+	}
+	if v.Namespace != nil && v.Namespace.Component != nil {
+		outputs["namespaceId"] = v.Namespace.Component.ID()
+	}
+	return outputs
+}
+
+// Outputs are registered in the ComponentResource of the LoadBalancer when Meta.RegisterComponent is set
+func (l *LoadBalancerComponent) Outputs() pulumi.Map {
+	outputs := pulumi.Map{}
+	if l.LoadBalancer != nil && l.LoadBalancer.Component != nil {
+		outputs["arn"] = l.LoadBalancer.Component.Arn
+		outputs["dnsName"] = l.LoadBalancer.Component.DnsName
+		outputs["zoneId"] = l.LoadBalancer.Component.ZoneId
+	}
+	return outputs
+}
+
+// Outputs are registered in the ComponentResource of the ECS Service when Meta.RegisterComponent is set
+func (s *ECSServiceComponent) Outputs() pulumi.Map {
+	outputs := pulumi.Map{}
+	if s.Service != nil && s.Service.Component != nil {
+		outputs["serviceName"] = s.Service.Component.Name
+	}
+	if s.TaskDefinition != nil && s.TaskDefinition.Component != nil {
+		outputs["taskDefinitionArn"] = s.TaskDefinition.Component.Arn
+	}
+	return outputs
+}
+
+// Outputs are registered in the ComponentResource of the ECS Cluster when Meta.RegisterComponent is set
+func (c *ECSClusterComponent) Outputs() pulumi.Map {
+	outputs := pulumi.Map{}
+	if c.Cluster != nil && c.Cluster.Component != nil {
+		outputs["clusterArn"] = c.Cluster.Component.Arn
+	}
+	if c.Namespace != nil && c.Namespace.Component != nil {
+		outputs["namespaceId"] = c.Namespace.Component.ID()
+	}
+	return outputs
 }
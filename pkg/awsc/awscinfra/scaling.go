@@ -0,0 +1,191 @@
+package awscinfra
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/appautoscaling"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/lb"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// CreateServiceAutoScalingComponent registers the desired count of a service as a scalable target, with its policies and scheduled actions.
+// The load balancers are the application load balancers that forward to the target groups, by target group lookup name
+func CreateServiceAutoScalingComponent(
+	meta pgocomp.Meta,
+	params ServiceAutoScalingParameters,
+	provider *aws.Provider,
+	cluster *ecs.Cluster,
+	service *ecs.Service,
+	targetGroups map[string]*lb.TargetGroup,
+	loadBalancers map[string]*lb.LoadBalancer,
+) *pgocomp.ComponentWithMeta[*ServiceAutoScalingComponent] {
+	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *ServiceAutoScalingComponent, err error) {
+		response = &ServiceAutoScalingComponent{
+			Policies:         make(map[string]*pgocomp.GetComponentWithMetaResponse[*appautoscaling.Policy]),
+			Alarms:           make(map[string]*pgocomp.GetComponentWithMetaResponse[*cloudwatch.MetricAlarm]),
+			ScheduledActions: make(map[string]*pgocomp.GetComponentWithMetaResponse[*appautoscaling.ScheduledAction]),
+		}
+		err = awsc.NewScalingTarget(meta, &appautoscaling.TargetArgs{
+			MinCapacity:       pulumi.Int(params.MinCapacity),
+			MaxCapacity:       pulumi.Int(params.MaxCapacity),
+			ResourceId:        pulumi.Sprintf("service/%s/%s", cluster.Name, service.Name),
+			ScalableDimension: pulumi.String("ecs:service:DesiredCount"),
+			ServiceNamespace:  pulumi.String("ecs"),
+		}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(target *pgocomp.GetComponentWithMetaResponse[*appautoscaling.Target]) (err error) {
+			response.Target = target
+			for _, policy := range params.TargetTracking {
+				metric := &appautoscaling.PolicyTargetTrackingScalingPolicyConfigurationPredefinedMetricSpecificationArgs{
+					PredefinedMetricType: pulumi.String(policy.Metric),
+				}
+				if policy.Metric == ScaleOnRequestCount {
+					tg, ok := targetGroups[policy.TargetGroupLookupName]
+					if !ok {
+						return fmt.Errorf("Target group Lookup Name %s not found", policy.TargetGroupLookupName)
+					}
+					loadBalancer, ok := loadBalancers[policy.TargetGroupLookupName]
+					if !ok {
+						return fmt.Errorf("No application load balancer forwards to the target group %s", policy.TargetGroupLookupName)
+					}
+					metric.ResourceLabel = pulumi.Sprintf("%s/%s", loadBalancer.ArnSuffix, tg.ArnSuffix)
+				}
+				err = CreateScalingPolicy(meta.Child(policy.Name), target.Component, &appautoscaling.PolicyArgs{
+					PolicyType: pulumi.String("TargetTrackingScaling"),
+					TargetTrackingScalingPolicyConfiguration: &appautoscaling.PolicyTargetTrackingScalingPolicyConfigurationArgs{
+						TargetValue:                   pulumi.Float64(policy.TargetValue),
+						PredefinedMetricSpecification: metric,
+						ScaleInCooldown:               optionalInt(policy.ScaleInCooldown),
+						ScaleOutCooldown:              optionalInt(policy.ScaleOutCooldown),
+						DisableScaleIn:                pulumi.Bool(policy.DisableScaleIn),
+					},
+				}, provider).GetAndThen(ctx, func(p *pgocomp.GetComponentWithMetaResponse[*appautoscaling.Policy]) error {
+					response.Policies[policy.Name] = p
+					return nil
+				})
+				if err != nil {
+					return
+				}
+			}
+			for _, policy := range params.StepScaling {
+				err = CreateScalingPolicy(meta.Child(policy.Name), target.Component, &appautoscaling.PolicyArgs{
+					PolicyType: pulumi.String("StepScaling"),
+					StepScalingPolicyConfiguration: &appautoscaling.PolicyStepScalingPolicyConfigurationArgs{
+						AdjustmentType:        pulumi.String(valueOrDefault(policy.AdjustmentType, ChangeInCapacity)),
+						Cooldown:              optionalInt(policy.Cooldown),
+						MetricAggregationType: pulumi.String(policy.Alarm.aggregation()),
+						StepAdjustments:       policy.stepAdjustments(),
+					},
+				}, provider).GetAndThen(ctx, func(p *pgocomp.GetComponentWithMetaResponse[*appautoscaling.Policy]) error {
+					response.Policies[policy.Name] = p
+					return CreateScalingAlarm(meta.Child(policy.Name+"-alarm"), policy.Alarm, provider, p.Component).GetAndThen(ctx, func(alarm *pgocomp.GetComponentWithMetaResponse[*cloudwatch.MetricAlarm]) error {
+						response.Alarms[policy.Name] = alarm
+						return nil
+					})
+				})
+				if err != nil {
+					return
+				}
+			}
+			for _, action := range params.ScheduledActions {
+				err = awsc.NewScheduledAction(meta.Child(action.Name), &appautoscaling.ScheduledActionArgs{
+					ResourceId:        target.Component.ResourceId,
+					ScalableDimension: target.Component.ScalableDimension,
+					ServiceNamespace:  target.Component.ServiceNamespace,
+					Schedule:          pulumi.String(action.Schedule),
+					Timezone:          optionalString(action.Timezone),
+					ScalableTargetAction: &appautoscaling.ScheduledActionScalableTargetActionArgs{
+						MinCapacity: pulumi.Int(action.MinCapacity),
+						MaxCapacity: pulumi.Int(action.MaxCapacity),
+					},
+				}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(a *pgocomp.GetComponentWithMetaResponse[*appautoscaling.ScheduledAction]) error {
+					response.ScheduledActions[action.Name] = a
+					return nil
+				})
+				if err != nil {
+					return
+				}
+			}
+			return
+		})
+		return
+	})
+}
+
+// CreateScalingPolicy creates a scaling policy of the scalable target
+func CreateScalingPolicy(meta pgocomp.Meta, target *appautoscaling.Target, args *appautoscaling.PolicyArgs, provider *aws.Provider) *pgocomp.ComponentWithMeta[*appautoscaling.Policy] {
+	args.ResourceId = target.ResourceId
+	args.ScalableDimension = target.ScalableDimension
+	args.ServiceNamespace = target.ServiceNamespace
+	return awsc.NewScalingPolicy(meta, args, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// CreateScalingAlarm creates the alarm that applies a step scaling policy
+func CreateScalingAlarm(meta pgocomp.Meta, params ScalingAlarmParameters, provider *aws.Provider, policy *appautoscaling.Policy) *pgocomp.ComponentWithMeta[*cloudwatch.MetricAlarm] {
+	return awsc.NewMetricAlarm(meta, &cloudwatch.MetricAlarmArgs{
+		Namespace:          pulumi.String(params.Namespace),
+		MetricName:         pulumi.String(params.MetricName),
+		Dimensions:         pulumi.ToStringMap(params.Dimensions),
+		Statistic:          pulumi.String(valueOrDefault(params.Statistic, "Average")),
+		Period:             pulumi.Int(valueOrDefault(params.Period, 60)),
+		EvaluationPeriods:  pulumi.Int(valueOrDefault(params.EvaluationPeriods, 1)),
+		ComparisonOperator: pulumi.String(params.ComparisonOperator),
+		Threshold:          pulumi.Float64(params.Threshold),
+		AlarmActions:       pulumi.Array{policy.Arn},
+	}, pulumi.Provider(provider), pulumi.Protect(meta.Protect))
+}
+
+// desiredCount is the desired count of the service. With auto scaling, it defaults to the minimum capacity
+func (p *ECSServiceParameters) desiredCount() int {
+	if p.AutoScaling != nil {
+		return valueOrDefault(p.DesiredCount, p.AutoScaling.MinCapacity)
+	}
+	return p.DesiredCount
+}
+
+// ignoredChanges are the changes of the service that Pulumi leaves to AWS. The desired count belongs to the auto scaling, and
+// the task definition and the target group to CodeDeploy in the blue/green mode
+func (p *ECSServiceParameters) ignoredChanges() (changes []string) {
+	if p.AutoScaling != nil {
+		changes = append(changes, "desiredCount")
+	}
+	if p.Deployment.BlueGreen != nil {
+		changes = append(changes, "taskDefinition", "loadBalancers")
+	}
+	return
+}
+
+// aggregation is the aggregation of the metric by a step scaling policy. It follows the statistic of the alarm
+// when the policy supports it
+func (p *ScalingAlarmParameters) aggregation() string {
+	switch p.Statistic {
+	case "Minimum", "Maximum":
+		return p.Statistic
+	default:
+		return "Average"
+	}
+}
+
+// stepAdjustments returns the steps of the policy
+func (p *StepScalingParameters) stepAdjustments() (array appautoscaling.PolicyStepScalingPolicyConfigurationStepAdjustmentArray) {
+	for _, step := range p.Steps {
+		array = append(array, appautoscaling.PolicyStepScalingPolicyConfigurationStepAdjustmentArgs{
+			MetricIntervalLowerBound: optionalBound(step.LowerBound),
+			MetricIntervalUpperBound: optionalBound(step.UpperBound),
+			ScalingAdjustment:        pulumi.Int(step.Adjustment),
+		})
+	}
+	return
+}
+
+func optionalBound(bound *float64) pulumi.StringPtrInput {
+	if bound == nil {
+		return nil
+	}
+	return pulumi.String(strconv.FormatFloat(*bound, 'f', -1, 64))
+}
@@ -0,0 +1,119 @@
+package awscinfra
+
+import (
+	"strings"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pkg/awsc"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/secretsmanager"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/ssm"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// createContainerSecrets creates the secrets that have a value, and returns where ECS reads each secret of the containers from,
+// by container and variable name
+func createContainerSecrets(ctx *pulumi.Context, meta pgocomp.Meta, params ECSServiceParameters, provider *aws.Provider, response *ECSServiceComponent) (valueFrom pulumi.StringMap, err error) {
+	valueFrom = pulumi.StringMap{}
+	for _, container := range params.Containers {
+		for _, secret := range container.Secrets {
+			key := secretKey(container.Name, secret.Name)
+			if secret.Arn != "" {
+				valueFrom[key] = pulumi.String(secret.Arn)
+				continue
+			}
+			secretMeta := meta.Child("secret-" + container.Name + "-" + strings.ToLower(strings.ReplaceAll(secret.Name, "_", "-")))
+			err = CreateSecretComponent(secretMeta, secret, provider).GetAndThen(ctx, func(s *pgocomp.GetComponentWithMetaResponse[*SecretComponent]) error {
+				response.Secrets[key] = s
+				valueFrom[key] = s.Component.Arn()
+				return nil
+			})
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// CreateSecretComponent stores the value of a secret in a Secrets Manager secret or in a SecureString parameter,
+// encrypted with its KMS key
+func CreateSecretComponent(meta pgocomp.Meta, params ContainerSecret, provider *aws.Provider) *pgocomp.ComponentWithMeta[*SecretComponent] {
+	return pgocomp.NewComponentWithMeta(meta, func(ctx *pulumi.Context, name string) (response *SecretComponent, err error) {
+		response = &SecretComponent{}
+		value := pulumi.ToSecret(params.Value).(pulumi.StringOutput)
+		if params.Source == ParameterStore {
+			err = awsc.NewParameter(meta, &ssm.ParameterArgs{
+				Type:  pulumi.String("SecureString"),
+				Value: value,
+				KeyId: optionalString(params.KmsKeyArn),
+			}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(p *pgocomp.GetComponentWithMetaResponse[*ssm.Parameter]) error {
+				response.Parameter = p
+				return nil
+			})
+			return
+		}
+		err = awsc.NewSecret(meta, &secretsmanager.SecretArgs{
+			KmsKeyId: optionalString(params.KmsKeyArn),
+		}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(secret *pgocomp.GetComponentWithMetaResponse[*secretsmanager.Secret]) error {
+			response.Secret = secret
+			return awsc.NewSecretVersion(meta.Child("version"), &secretsmanager.SecretVersionArgs{
+				SecretId:     secret.Component.ID(),
+				SecretString: value,
+			}, pulumi.Provider(provider), pulumi.Protect(meta.Protect)).GetAndThen(ctx, func(version *pgocomp.GetComponentWithMetaResponse[*secretsmanager.SecretVersion]) error {
+				response.SecretVersion = version
+				return nil
+			})
+		})
+		return
+	})
+}
+
+// secretKey identifies a secret of a container, like app/DB_PASSWORD
+func secretKey(container, name string) string {
+	return container + "/" + name
+}
+
+// secretStatements let the execution role read exactly the secrets of the containers, and decrypt them with their keys
+func (p *ECSServiceParameters) secretStatements(created map[string]*pgocomp.GetComponentWithMetaResponse[*SecretComponent]) (statements pulumi.Array) {
+	resources := map[SecretSource]pulumi.StringArray{}
+	var keys []string
+	added := make(map[string]bool)
+	for _, container := range p.Containers {
+		for _, secret := range container.Secrets {
+			if secret.Arn != "" {
+				arn := secretResourceArn(secret.Arn)
+				if !added[arn] {
+					added[arn] = true
+					resources[secret.Source] = append(resources[secret.Source], pulumi.String(arn))
+				}
+			} else if s, ok := created[secretKey(container.Name, secret.Name)]; ok {
+				resources[secret.Source] = append(resources[secret.Source], s.Component.Arn())
+			}
+			if secret.KmsKeyArn != "" && !added[secret.KmsKeyArn] {
+				added[secret.KmsKeyArn] = true
+				keys = append(keys, secret.KmsKeyArn)
+			}
+		}
+	}
+	if arns := resources[SecretsManager]; len(arns) > 0 {
+		statements = append(statements, policyStatement(Allow, []string{"secretsmanager:GetSecretValue"}, arns))
+	}
+	if arns := resources[ParameterStore]; len(arns) > 0 {
+		statements = append(statements, policyStatement(Allow, []string{"ssm:GetParameters"}, arns))
+	}
+	if len(keys) > 0 {
+		statements = append(statements, policyStatement(Allow, []string{"kms:Decrypt"}, pulumi.ToStringArray(keys)))
+	}
+	return
+}
+
+// secretResourceArn returns the arn of the secret itself, without the json key, version stage and version id
+// that the arn of a Secrets Manager secret can select
+func secretResourceArn(arn string) string {
+	if parts := strings.Split(arn, ":"); len(parts) > 7 && parts[2] == string(SecretsManager) {
+		return strings.Join(parts[:7], ":")
+	}
+	return arn
+}
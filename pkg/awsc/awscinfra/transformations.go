@@ -1,8 +1,11 @@
 package awscinfra
 
 import (
+	"strings"
+
 	jsoniter "github.com/json-iterator/go"
 	ecsn "github.com/pulumi/pulumi-aws-native/sdk/go/aws/ecs"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/cloudwatch"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -20,6 +23,7 @@ func (c *ECSServiceParameters) ECSNativeTaskDefinitionContainerDefinitionArray()
 func (c *ContainerDefinition) ECSNativeTaskDefinitionPortMappingArray() (array ecsn.TaskDefinitionPortMappingArray) {
 	for _, port := range c.PortMappings {
 		array = append(array, ecsn.TaskDefinitionPortMappingArgs{
+			Name:          optionalString(port.Name),
 			ContainerPort: pulumi.Int(port.ContainerPort),
 			AppProtocol:   port.ECSNativeTaskDefinitionPortMappingAppProtocol(),
 		})
@@ -30,6 +34,7 @@ func (c *ContainerDefinition) ECSNativeTaskDefinitionPortMappingArray() (array e
 // ECSNativeTaskDefinitionPortMappingArgs transforms this configuration into a ECS Native Port Mapping Args
 func (p *ContainerPortMapping) ECSNativeTaskDefinitionPortMappingArgs() (array ecsn.TaskDefinitionPortMappingArgs) {
 	return ecsn.TaskDefinitionPortMappingArgs{
+		Name:          optionalString(p.Name),
 		ContainerPort: pulumi.Int(p.ContainerPort),
 		AppProtocol:   p.ECSNativeTaskDefinitionPortMappingAppProtocol(),
 	}
@@ -37,9 +42,11 @@ func (p *ContainerPortMapping) ECSNativeTaskDefinitionPortMappingArgs() (array e
 
 // ECSNativeTaskDefinitionPortMappingAppProtocol transforms ContainerPortMapping into a ecs native TaskDefinitionPortMappings
 func (p *ContainerPortMapping) ECSNativeTaskDefinitionPortMappingAppProtocol() ecsn.TaskDefinitionPortMappingAppProtocol {
-	if matchOrPanic("(?i)http2", string(p.Protocol)) {
+	if p.AppProtocol != "" {
+		return ecsn.TaskDefinitionPortMappingAppProtocol(p.AppProtocol)
+	} else if strings.Contains(strings.ToLower(string(p.Protocol)), "http2") {
 		return ecsn.TaskDefinitionPortMappingAppProtocolHttp2
-	} else if matchOrPanic("(?i)grpc", string(p.Protocol)) {
+	} else if strings.Contains(strings.ToLower(string(p.Protocol)), "grpc") {
 		return ecsn.TaskDefinitionPortMappingAppProtocolGrpc
 	} else {
 		return ecsn.TaskDefinitionPortMappingAppProtocolHttp
@@ -77,3 +84,89 @@ func (c *ECSServiceParameters) ECSTaskDefinitionContainerDefinitionArray() (stri
 	}
 	return string(jsonData), nil
 }
+
+// taskContainerDefinition is a container of the task definition, with the settings filled in by the service
+type taskContainerDefinition struct {
+	ContainerDefinition
+	Secrets               []containerSecretReference `json:"secrets,omitempty"`
+	LogConfiguration      *containerLogConfiguration `json:"logConfiguration,omitempty"`
+	FirelensConfiguration *firelensConfiguration     `json:"firelensConfiguration,omitempty"`
+}
+
+// containerSecretReference is an environment variable that ECS reads from a secret or a parameter
+type containerSecretReference struct {
+	Name      string `json:"name"`
+	ValueFrom string `json:"valueFrom"`
+}
+
+// containerLogConfiguration is the log driver of a container and its options
+type containerLogConfiguration struct {
+	LogDriver string            `json:"logDriver"`
+	Options   map[string]string `json:"options,omitempty"`
+}
+
+// firelensConfiguration makes a container the FireLens log router of the task
+type firelensConfiguration struct {
+	Type    string            `json:"type"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// containerDefinitions returns the json definition of the containers, with the arns ECS reads their secrets from, by container and
+// variable name. When the log group is set, the containers log to it with the awslogs driver or, with FireLens, through the
+// log router sidecar, which logs to the group itself
+func (c *ECSServiceParameters) containerDefinitions(name string, logGroup *cloudwatch.LogGroup, region pulumi.StringInput, valueFrom pulumi.StringMap) pulumi.StringOutput {
+	var logGroupName pulumi.StringInput = pulumi.String("")
+	if logGroup != nil {
+		logGroupName = logGroup.Name
+	}
+	return pulumi.All(logGroupName, region, valueFrom).ApplyT(func(values []any) (string, error) {
+		var awslogs *containerLogConfiguration
+		if logGroup != nil {
+			awslogs = &containerLogConfiguration{
+				LogDriver: "awslogs",
+				Options: map[string]string{
+					"awslogs-group":         values[0].(string),
+					"awslogs-region":        values[1].(string),
+					"awslogs-stream-prefix": valueOrDefault(c.Logs.StreamPrefix, name),
+				},
+			}
+		}
+		arns := values[2].(map[string]string)
+		var containers []taskContainerDefinition
+		if fireLens := c.Logs.FireLens; fireLens != nil && awslogs != nil {
+			essential := true
+			containers = append(containers, taskContainerDefinition{
+				ContainerDefinition: ContainerDefinition{
+					Name:      FireLensContainerName,
+					Image:     valueOrDefault(fireLens.Image, DefaultFireLensImage),
+					Essential: &essential,
+					CPU:       fireLens.CPU,
+					Memory:    fireLens.Memory,
+				},
+				LogConfiguration: awslogs,
+				FirelensConfiguration: &firelensConfiguration{
+					Type:    "fluentbit",
+					Options: map[string]string{"enable-ecs-log-metadata": "true"},
+				},
+			})
+		}
+		for _, container := range c.Containers {
+			definition := taskContainerDefinition{ContainerDefinition: container, LogConfiguration: awslogs}
+			if c.Logs.FireLens != nil && awslogs != nil {
+				definition.LogConfiguration = &containerLogConfiguration{LogDriver: "awsfirelens", Options: c.Logs.FireLens.Options}
+			}
+			for _, secret := range container.Secrets {
+				definition.Secrets = append(definition.Secrets, containerSecretReference{
+					Name:      secret.Name,
+					ValueFrom: arns[secretKey(container.Name, secret.Name)],
+				})
+			}
+			containers = append(containers, definition)
+		}
+		jsonData, err := json.Marshal(containers)
+		if err != nil {
+			return "", err
+		}
+		return string(jsonData), nil
+	}).(pulumi.StringOutput)
+}
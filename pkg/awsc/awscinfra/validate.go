@@ -0,0 +1,1814 @@
+package awscinfra
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is a problem found in the parameters, at a path like Vpcs[0].Partitions[1].LoadBalancers[0].Listeners[2]
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// fargateMemory lists the memory values, in MiB, allowed by Fargate for each CPU value
+var fargateMemory = map[int][]int{
+	256:   {512, 1024, 2048},
+	512:   steps(1024, 4096, 1024),
+	1024:  steps(2048, 8192, 1024),
+	2048:  steps(4096, 16384, 1024),
+	4096:  steps(8192, 30720, 1024),
+	8192:  steps(16384, 61440, 4096),
+	16384: steps(32768, 122880, 8192),
+}
+
+// Validate returns every problem found in the parameters, joined in one error, or nil
+func (p *InfraParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the Vpc, joined in one error, or nil
+func (p *VpcParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the partition, joined in one error, or nil.
+// Subnet cidrs and certificate lookup names are checked by the Vpc
+func (p *NetworkPartitionParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the subnet, joined in one error, or nil
+func (p *SubnetParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the certificate, joined in one error, or nil
+func (p *CertificateParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the hosted zone, joined in one error, or nil
+func (p *HostedZoneParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the alias record, joined in one error, or nil
+func (p *AliasRecordParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the load balancer, joined in one error, or nil.
+// Target group lookup names are checked by the partition
+func (p *LoadBalancerParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the listener, joined in one error, or nil
+func (p *LBListenerParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the rule, joined in one error, or nil
+func (p *LBRuleParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the action, joined in one error, or nil
+func (a *LBActionParameters) Validate() error {
+	return join(a.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the target group, joined in one error, or nil
+func (p *LBTargetGroupParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the cluster, joined in one error, or nil
+func (p *ECSClusterParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the parameters of the service, joined in one error, or nil
+func (p *ECSServiceParameters) Validate() error {
+	return join(p.validate(""))
+}
+
+// Validate returns every problem found in the definition of the container, joined in one error, or nil
+func (c *ContainerDefinition) Validate() error {
+	return join(c.validate(""))
+}
+
+func (p *InfraParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if p.Workers < 0 {
+		errs = append(errs, invalid(field(path, "Workers"), "workers cannot be negative"))
+	}
+	var names []string
+	for i := range p.Vpcs {
+		names = append(names, p.Vpcs[i].Name)
+		errs = append(errs, p.Vpcs[i].validate(index(path, "Vpcs", i))...)
+	}
+	return append(errs, duplicates(path, "Vpcs", names)...)
+}
+
+func (p *VpcParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	errs = append(errs, requireName(field(path, "Provider"), p.Provider.Name)...)
+	if p.Provider.Region == "" {
+		errs = append(errs, invalid(field(path, "Provider.Region"), "region is required"))
+	}
+	_, vpcNet, err := net.ParseCIDR(p.CidrBlock)
+	if err != nil {
+		errs = append(errs, invalid(field(path, "CidrBlock"), "invalid cidr block %q", p.CidrBlock))
+	}
+
+	zones := make(map[string]*HostedZoneParameters)
+	var zoneNames, recordNames []string
+	for i := range p.HostedZones {
+		zones[p.HostedZones[i].Name] = &p.HostedZones[i]
+		zoneNames = append(zoneNames, p.HostedZones[i].Name)
+		for _, record := range p.HostedZones[i].Records {
+			recordNames = append(recordNames, record.Name)
+		}
+		errs = append(errs, p.HostedZones[i].validate(index(path, "HostedZones", i))...)
+	}
+	errs = append(errs, duplicates(path, "HostedZones", zoneNames)...)
+	errs = append(errs, duplicates(path, "HostedZones.Records", recordNames)...)
+
+	certificates := make(map[string]bool)
+	var certNames []string
+	for i := range p.Certificates {
+		cpath := index(path, "Certificates", i)
+		certificates[p.Certificates[i].Name] = true
+		certNames = append(certNames, p.Certificates[i].Name)
+		errs = append(errs, p.Certificates[i].validate(cpath)...)
+		if name := p.Certificates[i].HostedZoneLookupName; name != "" {
+			if zone, ok := zones[name]; !ok {
+				errs = append(errs, invalid(field(cpath, "HostedZoneLookupName"), "hosted zone %q not found in the vpc", name))
+			} else if zone.Private {
+				errs = append(errs, invalid(field(cpath, "HostedZoneLookupName"), "hosted zone %q is private, certificates are validated in public zones", name))
+			}
+		}
+	}
+	errs = append(errs, duplicates(path, "Certificates", certNames)...)
+
+	type subnet struct {
+		path string
+		net  *net.IPNet
+	}
+	var subnets []subnet
+	var partitionNames []string
+	loadBalancers := make(map[string]bool)
+	for i := range p.Partitions {
+		partition := &p.Partitions[i]
+		for _, loadBalancer := range partition.LoadBalancers {
+			loadBalancers[loadBalancer.Name] = true
+		}
+		ppath := index(path, "Partitions", i)
+		partitionNames = append(partitionNames, partition.Name)
+		errs = append(errs, partition.validate(ppath)...)
+		for j, s := range partition.Subnets {
+			spath := index(ppath, "Subnets", j)
+			_, subnetNet, err := net.ParseCIDR(s.CidrBlock)
+			if err != nil {
+				continue
+			}
+			if vpcNet != nil && !contains(vpcNet, subnetNet) {
+				errs = append(errs, invalid(field(spath, "CidrBlock"), "%s is outside the cidr block of the vpc %s", s.CidrBlock, p.CidrBlock))
+			}
+			for _, other := range subnets {
+				if overlaps(other.net, subnetNet) {
+					errs = append(errs, invalid(field(spath, "CidrBlock"), "%s overlaps %s of %s", s.CidrBlock, other.net, other.path))
+				}
+			}
+			subnets = append(subnets, subnet{path: spath, net: subnetNet})
+		}
+		for j, loadBalancer := range partition.LoadBalancers {
+			for k, listener := range loadBalancer.Listeners {
+				lpath := index(index(ppath, "LoadBalancers", j), "Listeners", k)
+				if listener.CertificateLookupName != "" && !certificates[listener.CertificateLookupName] {
+					errs = append(errs, invalid(field(lpath, "CertificateLookupName"), "certificate %q not found in the vpc", listener.CertificateLookupName))
+				}
+				for l, name := range listener.SniCertificateLookupNames {
+					if !certificates[name] {
+						errs = append(errs, invalid(index(lpath, "SniCertificateLookupNames", l), "certificate %q not found in the vpc", name))
+					}
+				}
+			}
+		}
+	}
+	for i := range p.HostedZones {
+		for j, record := range p.HostedZones[i].Records {
+			if !loadBalancers[record.LoadBalancerLookupName] {
+				errs = append(errs, invalid(field(index(index(path, "HostedZones", i), "Records", j), "LoadBalancerLookupName"), "load balancer %q not found in the vpc", record.LoadBalancerLookupName))
+			}
+		}
+	}
+	errs = append(errs, p.validateGatewayEndpoints(path)...)
+	errs = append(errs, p.validateNamespaces(path)...)
+	switch p.NatMode {
+	case NatSingle, NatPerAZ:
+		if hasPrivateSubnets(*p) && !hasPublicSubnets(*p) {
+			errs = append(errs, invalid(field(path, "NatMode"), "the NAT gateways of the private partitions need a public partition with subnets, or NatNone"))
+		}
+	case "", NatNone:
+	default:
+		errs = append(errs, invalid(field(path, "NatMode"), "unknown NAT mode %q", p.NatMode))
+	}
+	return append(errs, duplicates(path, "Partitions", partitionNames)...)
+}
+
+// validateGatewayEndpoints checks that the gateway endpoints use gateway load balancers, subnets and partitions of the Vpc,
+// and that their routes do not replace the default route to the internet or NAT gateways
+func (p *VpcParameters) validateGatewayEndpoints(path string) (errs []error) {
+	partitions := make(map[string]*NetworkPartitionParameters)
+	loadBalancers := make(map[string]*LoadBalancerParameters)
+	for i := range p.Partitions {
+		partitions[p.Partitions[i].Name] = &p.Partitions[i]
+		for j := range p.Partitions[i].LoadBalancers {
+			loadBalancers[p.Partitions[i].LoadBalancers[j].Name] = &p.Partitions[i].LoadBalancers[j]
+		}
+	}
+	var names []string
+	for i, endpoint := range p.GatewayEndpoints {
+		epath := index(path, "GatewayEndpoints", i)
+		names = append(names, endpoint.Name)
+		errs = append(errs, requireName(epath, endpoint.Name)...)
+		if loadBalancer, ok := loadBalancers[endpoint.LoadBalancerLookupName]; !ok {
+			errs = append(errs, invalid(field(epath, "LoadBalancerLookupName"), "load balancer %q not found in the vpc", endpoint.LoadBalancerLookupName))
+		} else if loadBalancer.Type != Gateway {
+			errs = append(errs, invalid(field(epath, "LoadBalancerLookupName"), "gateway endpoints use %s load balancers, %q has the %s type", Gateway, loadBalancer.Name, loadBalancer.Type))
+		}
+		if partition, ok := partitions[endpoint.PartitionLookupName]; !ok {
+			errs = append(errs, invalid(field(epath, "PartitionLookupName"), "partition %q not found in the vpc", endpoint.PartitionLookupName))
+		} else if !partition.hasSubnet(endpoint.SubnetLookupName) {
+			errs = append(errs, invalid(field(epath, "SubnetLookupName"), "subnet %q not found in the partition %q", endpoint.SubnetLookupName, partition.Name))
+		}
+		routes := make(map[string]int)
+		for j, route := range endpoint.Routes {
+			rpath := index(epath, "Routes", j)
+			partition, ok := partitions[route.PartitionLookupName]
+			if !ok {
+				errs = append(errs, invalid(field(rpath, "PartitionLookupName"), "partition %q not found in the vpc", route.PartitionLookupName))
+			} else if partition.IsPublic {
+				errs = append(errs, invalid(field(rpath, "PartitionLookupName"), "the partition %q is public and shares the route table of the internet gateway", route.PartitionLookupName))
+			}
+			_, destination, err := net.ParseCIDR(route.DestinationCidrBlock)
+			if err != nil {
+				errs = append(errs, invalid(field(rpath, "DestinationCidrBlock"), "invalid cidr block %q", route.DestinationCidrBlock))
+				continue
+			}
+			if ok && !partition.IsPublic && destination.String() == "0.0.0.0/0" && valueOrDefault(p.NatMode, NatNone) != NatNone && hasPrivateSubnets(*p) {
+				errs = append(errs, invalid(field(rpath, "DestinationCidrBlock"), "the partition %q already routes 0.0.0.0/0 to its NAT gateway", route.PartitionLookupName))
+			}
+			if first, ok := routes[route.PartitionLookupName+" "+destination.String()]; ok {
+				errs = append(errs, invalid(rpath, "the route is already set by Routes[%d]", first))
+			} else {
+				routes[route.PartitionLookupName+" "+destination.String()] = j
+			}
+		}
+	}
+	return append(errs, duplicates(path, "GatewayEndpoints", names)...)
+}
+
+// hasSubnet tells if the partition has a subnet of the name
+func (p *NetworkPartitionParameters) hasSubnet(name string) bool {
+	for _, subnet := range p.Subnets {
+		if subnet.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *NetworkPartitionParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	var subnetNames []string
+	for i := range p.Subnets {
+		subnetNames = append(subnetNames, p.Subnets[i].Name)
+		errs = append(errs, p.Subnets[i].validate(index(path, "Subnets", i))...)
+	}
+	errs = append(errs, duplicates(path, "Subnets", subnetNames)...)
+
+	targetGroups := make(map[string]*LBTargetGroupParameters)
+	var tgNames []string
+	for i := range p.LBTargetGroups {
+		targetGroups[p.LBTargetGroups[i].Name] = &p.LBTargetGroups[i]
+		tgNames = append(tgNames, p.LBTargetGroups[i].Name)
+		errs = append(errs, p.LBTargetGroups[i].validate(index(path, "LBTargetGroups", i))...)
+	}
+	errs = append(errs, duplicates(path, "LBTargetGroups", tgNames)...)
+	lookup := func(path, name string) []error {
+		if _, ok := targetGroups[name]; name != "" && !ok {
+			return []error{invalid(field(path, "TargetGroupLookupName"), "target group %q not found in the partition", name)}
+		}
+		return nil
+	}
+
+	var lbNames []string
+	for i := range p.LoadBalancers {
+		loadBalancer := &p.LoadBalancers[i]
+		lbpath := index(path, "LoadBalancers", i)
+		lbNames = append(lbNames, loadBalancer.Name)
+		errs = append(errs, loadBalancer.validate(lbpath)...)
+		if loadBalancer.Type == Application && len(p.Subnets) < 2 {
+			errs = append(errs, invalid(lbpath, "application load balancers need at least two subnets"))
+		}
+		lookupActions := func(path string, actions []LBActionParameters) (errs []error) {
+			for i, action := range actions {
+				for j, name := range action.lookupNames() {
+					errs = append(errs, lookup(index(field(index(path, "Actions", i), "Forward"), "TargetGroups", j), name)...)
+				}
+			}
+			return
+		}
+		for j, listener := range loadBalancer.Listeners {
+			lpath := index(lbpath, "Listeners", j)
+			errs = append(errs, lookup(lpath, listener.TargetGroupLookupName)...)
+			errs = append(errs, lookupActions(lpath, listener.Actions)...)
+			for k, rule := range listener.Rules {
+				rpath := index(lpath, "Rules", k)
+				errs = append(errs, lookup(rpath, rule.TargetGroupLookupName)...)
+				errs = append(errs, lookupActions(rpath, rule.Actions)...)
+			}
+			for _, name := range listener.targetGroupLookupNames() {
+				if tg, ok := targetGroups[name]; ok && !listener.Protocol.accepts(tg) {
+					errs = append(errs, invalid(lpath, "%s listeners can't forward to the target group %q of protocol %s and target type %s", listener.Protocol, name, tg.Protocol, valueOrDefault(tg.TargetType, TGIp)))
+				}
+			}
+		}
+	}
+	errs = append(errs, duplicates(path, "LoadBalancers", lbNames)...)
+	for i, tg := range p.LBTargetGroups {
+		if tg.TargetType != TGAlb || tg.LoadBalancerLookupName == "" {
+			continue
+		}
+		tpath := field(index(path, "LBTargetGroups", i), "LoadBalancerLookupName")
+		loadBalancer := p.loadBalancer(tg.LoadBalancerLookupName)
+		switch {
+		case loadBalancer == nil:
+			errs = append(errs, invalid(tpath, "load balancer %q not found in the partition", tg.LoadBalancerLookupName))
+		case loadBalancer.Type != Application:
+			errs = append(errs, invalid(tpath, "alb target groups register %s load balancers, %q is a %s load balancer", Application, loadBalancer.Name, loadBalancer.Type))
+		case !loadBalancer.listensOn(tg.Port):
+			errs = append(errs, invalid(tpath, "load balancer %q has no listener on the port %d of the target group", loadBalancer.Name, tg.Port))
+		}
+	}
+
+	var clusterNames []string
+	for i := range p.ECSClusters {
+		cluster := &p.ECSClusters[i]
+		cpath := index(path, "ECSClusters", i)
+		clusterNames = append(clusterNames, cluster.Name)
+		errs = append(errs, cluster.validate(cpath)...)
+		for j, service := range cluster.Services {
+			if len(p.Subnets) == 0 {
+				errs = append(errs, invalid(index(cpath, "Services", j), "services need at least one subnet in the partition"))
+			}
+			for k, container := range service.Containers {
+				for l, mapping := range container.PortMappings {
+					mpath := index(index(index(cpath, "Services", j), "Containers", k), "PortMappings", l)
+					errs = append(errs, lookup(mpath, mapping.TargetGroupLookupName)...)
+					if tg, ok := targetGroups[mapping.TargetGroupLookupName]; ok && valueOrDefault(tg.TargetType, TGIp) != TGIp {
+						errs = append(errs, invalid(field(mpath, "TargetGroupLookupName"), "fargate tasks are registered in %s target groups, %q has the %s target type", TGIp, tg.Name, tg.TargetType))
+					}
+				}
+			}
+			if bg := service.Deployment.BlueGreen; bg != nil {
+				errs = append(errs, p.validateBlueGreen(field(field(index(cpath, "Services", j), "Deployment"), "BlueGreen"), bg, targetGroups)...)
+			}
+			if service.AutoScaling == nil {
+				continue
+			}
+			for k, policy := range service.AutoScaling.TargetTracking {
+				if policy.Metric != ScaleOnRequestCount {
+					continue
+				}
+				tpath := index(field(index(cpath, "Services", j), "AutoScaling"), "TargetTracking", k)
+				errs = append(errs, lookup(tpath, policy.TargetGroupLookupName)...)
+				if _, ok := targetGroups[policy.TargetGroupLookupName]; ok && !p.forwardedByApplicationLB(policy.TargetGroupLookupName) {
+					errs = append(errs, invalid(field(tpath, "TargetGroupLookupName"), "no %s load balancer of the partition forwards to the target group %q", Application, policy.TargetGroupLookupName))
+				}
+			}
+		}
+	}
+	return append(errs, duplicates(path, "ECSClusters", clusterNames)...)
+}
+
+// forwardedByApplicationLB tells if an application load balancer of the partition forwards to the target group
+func (p *NetworkPartitionParameters) forwardedByApplicationLB(lookupName string) bool {
+	for _, loadBalancer := range p.LoadBalancers {
+		if loadBalancer.Type != Application {
+			continue
+		}
+		for _, listener := range loadBalancer.Listeners {
+			for _, name := range listener.targetGroupLookupNames() {
+				if name == lookupName {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (p *SubnetParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if _, _, err := net.ParseCIDR(p.CidrBlock); err != nil {
+		errs = append(errs, invalid(field(path, "CidrBlock"), "invalid cidr block %q", p.CidrBlock))
+	}
+	return
+}
+
+func (p *CertificateParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if p.Domain == "" {
+		errs = append(errs, invalid(field(path, "Domain"), "domain is required"))
+	}
+	if p.ValidationMethod != ValidationByDNS && p.ValidationMethod != ValidationByEmail {
+		errs = append(errs, invalid(field(path, "ValidationMethod"), "validation method must be %s or %s, got %q", ValidationByDNS, ValidationByEmail, p.ValidationMethod))
+	}
+	for i, name := range p.SubjectAlternativeNames {
+		if name == "" {
+			errs = append(errs, invalid(index(path, "SubjectAlternativeNames", i), "the name is blank"))
+		}
+	}
+	if p.HostedZoneLookupName != "" || p.HostedZoneName != "" {
+		if p.HostedZoneLookupName != "" && p.HostedZoneName != "" {
+			errs = append(errs, invalid(field(path, "HostedZoneLookupName"), "set either a hosted zone lookup name or a hosted zone name"))
+		}
+		if p.ValidationMethod != ValidationByDNS {
+			errs = append(errs, invalid(field(path, "ValidationMethod"), "hosted zones are only used by the %s validation method", ValidationByDNS))
+		}
+	}
+	return
+}
+
+func (p *HostedZoneParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if p.Domain == "" {
+		errs = append(errs, invalid(field(path, "Domain"), "domain is required"))
+	}
+	for i := range p.Records {
+		rpath := index(path, "Records", i)
+		errs = append(errs, p.Records[i].validate(rpath)...)
+		if name := strings.TrimSuffix(p.Records[i].RecordName, "."); p.Domain != "" && name != p.Domain && !strings.HasSuffix(name, "."+p.Domain) {
+			errs = append(errs, invalid(field(rpath, "RecordName"), "%q is outside the domain of the zone %q", p.Records[i].RecordName, p.Domain))
+		}
+	}
+	return
+}
+
+func (p *AliasRecordParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if p.RecordName == "" {
+		errs = append(errs, invalid(field(path, "RecordName"), "record name is required"))
+	}
+	if p.LoadBalancerLookupName == "" {
+		errs = append(errs, invalid(field(path, "LoadBalancerLookupName"), "a load balancer is required"))
+	}
+	for i, typ := range p.Types {
+		if typ != RecordA && typ != RecordAAAA {
+			errs = append(errs, invalid(index(path, "Types", i), "record type must be %s or %s, got %q", RecordA, RecordAAAA, typ))
+		}
+	}
+	switch p.RoutingPolicy {
+	case "", SimpleRouting:
+		if p.SetIdentifier != "" {
+			errs = append(errs, invalid(field(path, "SetIdentifier"), "set identifiers are only used by the weighted and failover routing policies"))
+		}
+	case WeightedRouting:
+		if p.Weight < 0 || p.Weight > 255 {
+			errs = append(errs, invalid(field(path, "Weight"), "weight must be between 0 and 255, got %d", p.Weight))
+		}
+	case FailoverRouting:
+		if p.Failover != FailoverPrimary && p.Failover != FailoverSecondary {
+			errs = append(errs, invalid(field(path, "Failover"), "failover must be %s or %s, got %q", FailoverPrimary, FailoverSecondary, p.Failover))
+		}
+	default:
+		errs = append(errs, invalid(field(path, "RoutingPolicy"), "unknown routing policy %q", p.RoutingPolicy))
+	}
+	if (p.RoutingPolicy == WeightedRouting || p.RoutingPolicy == FailoverRouting) && p.SetIdentifier == "" {
+		errs = append(errs, invalid(field(path, "SetIdentifier"), "the %s routing policy needs a set identifier", p.RoutingPolicy))
+	}
+	if p.RoutingPolicy != WeightedRouting && p.Weight != 0 {
+		errs = append(errs, invalid(field(path, "Weight"), "weights are only used by the weighted routing policy"))
+	}
+	if p.RoutingPolicy != FailoverRouting && p.Failover != "" {
+		errs = append(errs, invalid(field(path, "Failover"), "failover roles are only used by the failover routing policy"))
+	}
+	return
+}
+
+func (p *LoadBalancerParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	switch p.Type {
+	case Application, Network, Gateway:
+	case Classic:
+		errs = append(errs, invalid(field(path, "Type"), "classic load balancers are not supported, use %s or %s load balancers", Application, Network))
+	default:
+		errs = append(errs, invalid(field(path, "Type"), "unsupported load balancer type %q", p.Type))
+	}
+	if p.Type != Network && (p.CrossZone || p.ElasticIPs) {
+		errs = append(errs, invalid(path, "cross zone load balancing and elastic ips are only set on %s load balancers", Network))
+	}
+	if p.ElasticIPs && p.IsInternal {
+		errs = append(errs, invalid(field(path, "ElasticIPs"), "internal load balancers have no elastic ips"))
+	}
+	if p.Type != Gateway && (p.EndpointService.AcceptanceRequired || len(p.EndpointService.AllowedPrincipals) > 0) {
+		errs = append(errs, invalid(field(path, "EndpointService"), "endpoint services are only created for %s load balancers", Gateway))
+	}
+	if p.Type == Gateway {
+		return append(errs, p.validateGatewayListeners(path)...)
+	}
+	var names []string
+	ports := make(map[int]int)
+	for i := range p.Listeners {
+		listener := &p.Listeners[i]
+		lpath := index(path, "Listeners", i)
+		names = append(names, listener.Name)
+		errs = append(errs, listener.validate(lpath)...)
+		if (p.Type == Application && listener.Protocol.isLayer4()) || (p.Type == Network && !listener.Protocol.isLayer4()) {
+			errs = append(errs, invalid(field(lpath, "Protocol"), "%s listeners are not supported by %s load balancers", listener.Protocol, p.Type))
+		}
+		if p.Type == Network {
+			if len(listener.Rules) > 0 {
+				errs = append(errs, invalid(field(lpath, "Rules"), "%s load balancers have no listener rules", Network))
+			}
+			for j, action := range listener.Actions {
+				if action.Type != ActionForward || len(action.Forward.TargetGroups) > 1 || action.Forward.StickinessDuration > 0 {
+					errs = append(errs, invalid(index(lpath, "Actions", j), "%s load balancers only forward to one target group", Network))
+				}
+			}
+		}
+		if first, ok := ports[listener.Port]; ok {
+			errs = append(errs, invalid(field(lpath, "Port"), "port %d is already used by Listeners[%d]", listener.Port, first))
+		} else {
+			ports[listener.Port] = i
+		}
+	}
+	return append(errs, duplicates(path, "Listeners", names)...)
+}
+
+// validateGatewayListeners checks the only listener of a gateway load balancer, which has no port or protocol and forwards to one GENEVE target group
+func (p *LoadBalancerParameters) validateGatewayListeners(path string) (errs []error) {
+	if len(p.Listeners) > 1 {
+		errs = append(errs, invalid(field(path, "Listeners"), "%s load balancers have one listener", Gateway))
+	}
+	for i := range p.Listeners {
+		listener := &p.Listeners[i]
+		lpath := index(path, "Listeners", i)
+		errs = append(errs, requireName(lpath, listener.Name)...)
+		if listener.Port != 0 || listener.Protocol != "" {
+			errs = append(errs, invalid(lpath, "the listeners of %s load balancers have no port or protocol", Gateway))
+		}
+		if listener.CertificateLookupName != "" || len(listener.SniCertificateLookupNames) > 0 || listener.SslPolicy != "" || listener.AlpnPolicy != "" ||
+			listener.RedirectToHTTPS || len(listener.Rules) > 0 {
+			errs = append(errs, invalid(lpath, "the listeners of %s load balancers only forward to a target group", Gateway))
+		}
+		switch {
+		case len(listener.Actions) > 0:
+			if listener.TargetGroupLookupName != "" {
+				errs = append(errs, invalid(field(lpath, "Actions"), "actions replace the target group lookup name, set only one of them"))
+			}
+			for j, action := range listener.Actions {
+				if action.Type != ActionForward || len(action.Forward.TargetGroups) != 1 || action.Forward.StickinessDuration > 0 {
+					errs = append(errs, invalid(index(lpath, "Actions", j), "%s load balancers only forward to one target group", Gateway))
+				}
+			}
+		case listener.TargetGroupLookupName == "":
+			errs = append(errs, invalid(field(lpath, "TargetGroupLookupName"), "target group lookup name is required"))
+		}
+	}
+	return
+}
+
+func (p *LBListenerParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	errs = append(errs, validatePort(field(path, "Port"), p.Port)...)
+	secure := p.Protocol == HTTPS || p.Protocol == TLS
+	switch p.Protocol {
+	case HTTP, HTTP2, GRPC, HTTPS, TLS, TCP, UDP, TCPUDP:
+	default:
+		errs = append(errs, invalid(field(path, "Protocol"), "unsupported protocol %q", p.Protocol))
+	}
+	switch p.AlpnPolicy {
+	case "", AlpnHTTP1Only, AlpnHTTP2Only, AlpnHTTP2Optional, AlpnHTTP2Preferred, AlpnNone:
+	default:
+		errs = append(errs, invalid(field(path, "AlpnPolicy"), "unsupported alpn policy %q", p.AlpnPolicy))
+	}
+	if p.AlpnPolicy != "" && p.Protocol != TLS {
+		errs = append(errs, invalid(field(path, "AlpnPolicy"), "alpn policies are only used by %s listeners", TLS))
+	}
+	if secure && p.CertificateLookupName == "" {
+		errs = append(errs, invalid(field(path, "CertificateLookupName"), "%s listeners need a certificate", p.Protocol))
+	}
+	if !secure && (p.CertificateLookupName != "" || len(p.SniCertificateLookupNames) > 0 || p.SslPolicy != "") {
+		errs = append(errs, invalid(field(path, "Protocol"), "certificates and ssl policies need the %s or %s protocol", HTTPS, TLS))
+	}
+	switch {
+	case len(p.Actions) > 0:
+		if p.RedirectToHTTPS || p.TargetGroupLookupName != "" {
+			errs = append(errs, invalid(field(path, "Actions"), "actions replace the target group lookup name and the redirect to HTTPS, set only one of them"))
+		}
+	case p.RedirectToHTTPS:
+		if p.Protocol != HTTP || p.Port == 443 {
+			errs = append(errs, invalid(field(path, "RedirectToHTTPS"), "only HTTP listeners on a port other than 443 can redirect to HTTPS"))
+		}
+	case p.TargetGroupLookupName == "":
+		errs = append(errs, invalid(field(path, "TargetGroupLookupName"), "target group lookup name is required"))
+	}
+	errs = append(errs, validateActions(path, p.Actions)...)
+	authenticates := func(actions []LBActionParameters) bool {
+		for _, action := range actions {
+			if action.Type == ActionAuthenticateOidc || action.Type == ActionAuthenticateCognito {
+				return true
+			}
+		}
+		return false
+	}
+	if p.Protocol != HTTPS {
+		if authenticates(p.Actions) {
+			errs = append(errs, invalid(field(path, "Actions"), "authenticate actions need an HTTPS listener"))
+		}
+		for i, rule := range p.Rules {
+			if authenticates(rule.Actions) {
+				errs = append(errs, invalid(field(index(path, "Rules", i), "Actions"), "authenticate actions need an HTTPS listener"))
+			}
+		}
+	}
+	var names []string
+	priorities := make(map[int]int)
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		rpath := index(path, "Rules", i)
+		names = append(names, rule.Name)
+		errs = append(errs, rule.validate(rpath)...)
+		if first, ok := priorities[rule.Priority]; ok {
+			errs = append(errs, invalid(field(rpath, "Priority"), "priority %d is already used by Rules[%d]", rule.Priority, first))
+		} else {
+			priorities[rule.Priority] = i
+		}
+	}
+	return append(errs, duplicates(path, "Rules", names)...)
+}
+
+func (p *LBRuleParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if p.Priority < 1 || p.Priority > 50000 {
+		errs = append(errs, invalid(field(path, "Priority"), "priority must be between 1 and 50000, got %d", p.Priority))
+	}
+	if len(p.Actions) > 0 && p.TargetGroupLookupName != "" {
+		errs = append(errs, invalid(field(path, "Actions"), "actions replace the target group lookup name, set only one of them"))
+	} else if len(p.Actions) == 0 && p.TargetGroupLookupName == "" {
+		errs = append(errs, invalid(field(path, "TargetGroupLookupName"), "target group lookup name is required"))
+	}
+	errs = append(errs, validateActions(path, p.Actions)...)
+	if len(p.Conditions) == 0 {
+		errs = append(errs, invalid(field(path, "Conditions"), "at least one condition is required"))
+	}
+	for i, condition := range p.Conditions {
+		cpath := index(path, "Conditions", i)
+		var empty bool
+		switch condition.RuleConditionType {
+		case PathPattern:
+			empty = len(condition.PathPatterns) == 0
+		case HostHeader:
+			empty = len(condition.HostHeaders) == 0
+		case HTTPHeader:
+			empty = condition.HTTPHeader.Name == "" || len(condition.HTTPHeader.Values) == 0
+		case QueryString:
+			empty = len(condition.QueryString) == 0
+		case SourceIP:
+			empty = len(condition.SourceIPs) == 0
+			for j, ip := range condition.SourceIPs {
+				if _, _, err := net.ParseCIDR(ip); err != nil {
+					errs = append(errs, invalid(index(cpath, "SourceIPs", j), "invalid cidr block %q", ip))
+				}
+			}
+		default:
+			errs = append(errs, invalid(field(cpath, "RuleConditionType"), "unknown condition type %d", condition.RuleConditionType))
+			continue
+		}
+		if empty {
+			errs = append(errs, invalid(cpath, "the condition has no values"))
+		}
+	}
+	return
+}
+
+// validateActions checks the actions of a listener or a rule, and that only the last one forwards, redirects or answers
+func validateActions(path string, actions []LBActionParameters) (errs []error) {
+	for i := range actions {
+		apath := index(path, "Actions", i)
+		errs = append(errs, actions[i].validate(apath)...)
+		switch actions[i].Type {
+		case ActionAuthenticateOidc, ActionAuthenticateCognito:
+			if i == len(actions)-1 {
+				errs = append(errs, invalid(field(apath, "Type"), "the last action must forward, redirect or answer a fixed response"))
+			}
+		default:
+			if i < len(actions)-1 {
+				errs = append(errs, invalid(field(apath, "Type"), "only the last action can be a %s action", actions[i].Type))
+			}
+		}
+	}
+	return
+}
+
+func (a *LBActionParameters) validate(path string) (errs []error) {
+	required := func(path, name, value string) {
+		if value == "" {
+			errs = append(errs, invalid(field(path, name), "%s is required", name))
+		}
+	}
+	onUnauthenticated := func(path, value string) {
+		switch value {
+		case "", "deny", "allow", "authenticate":
+		default:
+			errs = append(errs, invalid(field(path, "OnUnauthenticatedRequest"), "must be deny, allow or authenticate, got %q", value))
+		}
+	}
+	switch a.Type {
+	case ActionForward:
+		fpath := field(path, "Forward")
+		if n := len(a.Forward.TargetGroups); n < 1 || n > 5 {
+			errs = append(errs, invalid(field(fpath, "TargetGroups"), "forward actions need between 1 and 5 target groups, got %d", n))
+		}
+		var weights int
+		for i, tg := range a.Forward.TargetGroups {
+			tpath := index(fpath, "TargetGroups", i)
+			required(tpath, "TargetGroupLookupName", tg.TargetGroupLookupName)
+			if tg.Weight < 0 || tg.Weight > 999 {
+				errs = append(errs, invalid(field(tpath, "Weight"), "weight must be between 0 and 999, got %d", tg.Weight))
+			}
+			weights += tg.Weight
+		}
+		if len(a.Forward.TargetGroups) > 1 && weights == 0 {
+			errs = append(errs, invalid(field(fpath, "TargetGroups"), "at least one target group needs a weight"))
+		}
+		if a.Forward.StickinessDuration < 0 || a.Forward.StickinessDuration > 604800 {
+			errs = append(errs, invalid(field(fpath, "StickinessDuration"), "stickiness must last between 1 second and 7 days, got %d seconds", a.Forward.StickinessDuration))
+		}
+	case ActionFixedResponse:
+		fpath := field(path, "FixedResponse")
+		if code := a.FixedResponse.StatusCode; code < 200 || code > 599 || (code >= 300 && code < 400) {
+			errs = append(errs, invalid(field(fpath, "StatusCode"), "status code must be 2XX, 4XX or 5XX, got %d", code))
+		}
+		switch a.FixedResponse.ContentType {
+		case "", "text/plain", "text/css", "text/html", "application/javascript", "application/json":
+		default:
+			errs = append(errs, invalid(field(fpath, "ContentType"), "unsupported content type %q", a.FixedResponse.ContentType))
+		}
+		if len(a.FixedResponse.MessageBody) > 1024 {
+			errs = append(errs, invalid(field(fpath, "MessageBody"), "the message body is longer than 1024 characters"))
+		}
+	case ActionRedirect:
+		r := a.Redirect
+		if r.Protocol == "" && r.Host == "" && r.Port == "" && r.Path == "" && r.Query == "" {
+			errs = append(errs, invalid(field(path, "Redirect"), "a redirect needs a protocol, a host, a port, a path or a query"))
+		}
+		switch r.Protocol {
+		case "", "HTTP", "HTTPS", "#{protocol}":
+		default:
+			errs = append(errs, invalid(field(path, "Redirect.Protocol"), "must be HTTP, HTTPS or #{protocol}, got %q", r.Protocol))
+		}
+	case ActionAuthenticateOidc:
+		opath := field(path, "AuthenticateOidc")
+		required(opath, "Issuer", a.AuthenticateOidc.Issuer)
+		required(opath, "AuthorizationEndpoint", a.AuthenticateOidc.AuthorizationEndpoint)
+		required(opath, "TokenEndpoint", a.AuthenticateOidc.TokenEndpoint)
+		required(opath, "UserInfoEndpoint", a.AuthenticateOidc.UserInfoEndpoint)
+		required(opath, "ClientID", a.AuthenticateOidc.ClientID)
+		if a.AuthenticateOidc.ClientSecret == nil {
+			errs = append(errs, invalid(field(opath, "ClientSecret"), "ClientSecret is required"))
+		}
+		onUnauthenticated(opath, a.AuthenticateOidc.OnUnauthenticatedRequest)
+	case ActionAuthenticateCognito:
+		cpath := field(path, "AuthenticateCognito")
+		required(cpath, "UserPoolArn", a.AuthenticateCognito.UserPoolArn)
+		required(cpath, "UserPoolClientID", a.AuthenticateCognito.UserPoolClientID)
+		required(cpath, "UserPoolDomain", a.AuthenticateCognito.UserPoolDomain)
+		onUnauthenticated(cpath, a.AuthenticateCognito.OnUnauthenticatedRequest)
+	default:
+		errs = append(errs, invalid(field(path, "Type"), "unknown action type %q", a.Type))
+	}
+	return
+}
+
+func (p *LBTargetGroupParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	switch p.TargetType {
+	case "", TGIp, TGInstance, TGAlb:
+		errs = append(errs, validatePort(field(path, "Port"), p.Port)...)
+		switch p.Protocol {
+		case TGProtoHTTP, TGProtoHTTPS, TGProtoTCP, TGProtoUDP, TGProtoTCPUDP, TGProtoTLS:
+		case TGProtoGENEVE:
+			if p.Port != 6081 {
+				errs = append(errs, invalid(field(path, "Port"), "%s target groups use the port 6081, got %d", TGProtoGENEVE, p.Port))
+			}
+			if p.TargetType == TGAlb {
+				errs = append(errs, invalid(field(path, "TargetType"), "%s target groups register ip addresses or instances", TGProtoGENEVE))
+			}
+		default:
+			errs = append(errs, invalid(field(path, "Protocol"), "unsupported protocol %q", p.Protocol))
+		}
+		if p.TargetType == TGAlb && p.Protocol != TGProtoTCP {
+			errs = append(errs, invalid(field(path, "Protocol"), "alb target groups use the %s protocol", TGProtoTCP))
+		}
+		if (p.TargetType == TGAlb) != (p.LoadBalancerLookupName != "") {
+			errs = append(errs, invalid(field(path, "LoadBalancerLookupName"), "alb target groups need a load balancer lookup name, and only them"))
+		}
+	case TGLambda:
+		if p.Port != 0 || p.Protocol != "" {
+			errs = append(errs, invalid(path, "lambda target groups have no port or protocol"))
+		}
+		if p.DeregistrationDelay != 0 || p.HealthCheck.Port != "" || p.HealthCheck.Protocol != "" {
+			errs = append(errs, invalid(path, "lambda target groups have no deregistration delay, health check port or health check protocol"))
+		}
+	default:
+		errs = append(errs, invalid(field(path, "TargetType"), "unsupported target type %q", p.TargetType))
+	}
+
+	//Protocol versions, algorithms, slow start and cookies belong to application load balancers
+	switch p.ProtocolVersion {
+	case "", TGHTTP1, TGHTTP2, TGGRPC:
+	default:
+		errs = append(errs, invalid(field(path, "ProtocolVersion"), "unsupported protocol version %q", p.ProtocolVersion))
+	}
+	if p.ProtocolVersion != "" && !p.isHTTP() {
+		errs = append(errs, invalid(field(path, "ProtocolVersion"), "protocol versions are only used by %s and %s target groups", TGProtoHTTP, TGProtoHTTPS))
+	}
+	switch p.Algorithm {
+	case "", RoundRobin, LeastOutstandingRequests:
+	default:
+		errs = append(errs, invalid(field(path, "Algorithm"), "unsupported algorithm %q", p.Algorithm))
+	}
+	if p.Algorithm != "" && !p.isHTTP() {
+		errs = append(errs, invalid(field(path, "Algorithm"), "algorithms are only used by %s and %s target groups", TGProtoHTTP, TGProtoHTTPS))
+	}
+	if p.SlowStart != 0 {
+		if p.SlowStart < 30 || p.SlowStart > 900 {
+			errs = append(errs, invalid(field(path, "SlowStart"), "slow start must be between 30 and 900 seconds, got %d", p.SlowStart))
+		}
+		if !p.isHTTP() {
+			errs = append(errs, invalid(field(path, "SlowStart"), "slow start is only used by %s and %s target groups", TGProtoHTTP, TGProtoHTTPS))
+		}
+		if p.Algorithm == LeastOutstandingRequests {
+			errs = append(errs, invalid(field(path, "SlowStart"), "slow start can't be used with the %s algorithm", LeastOutstandingRequests))
+		}
+	}
+	if p.DeregistrationDelay < 0 || p.DeregistrationDelay > 3600 {
+		errs = append(errs, invalid(field(path, "DeregistrationDelay"), "deregistration delay must be between 0 and 3600 seconds, got %d", p.DeregistrationDelay))
+	}
+	errs = append(errs, p.validateStickiness(field(path, "Stickiness"))...)
+	return append(errs, p.validateHealthCheck(field(path, "HealthCheck"))...)
+}
+
+func (p *LBTargetGroupParameters) validateStickiness(path string) (errs []error) {
+	stickiness := p.Stickiness
+	switch stickiness.Type {
+	case "":
+		if stickiness.Duration != 0 || stickiness.CookieName != "" {
+			errs = append(errs, invalid(field(path, "Type"), "a stickiness type is required"))
+		}
+		return
+	case StickyLBCookie, StickyAppCookie:
+		if !p.isHTTP() {
+			errs = append(errs, invalid(field(path, "Type"), "%s stickiness is only used by %s and %s target groups", stickiness.Type, TGProtoHTTP, TGProtoHTTPS))
+		}
+	case StickySourceIP:
+		if p.isHTTP() || p.TargetType == TGLambda {
+			errs = append(errs, invalid(field(path, "Type"), "%s stickiness is only used by TCP, UDP and TLS target groups", stickiness.Type))
+		}
+	default:
+		return append(errs, invalid(field(path, "Type"), "unsupported stickiness type %q", stickiness.Type))
+	}
+	if stickiness.Duration < 0 || stickiness.Duration > 604800 {
+		errs = append(errs, invalid(field(path, "Duration"), "duration must be between 1 and 604800 seconds, got %d", stickiness.Duration))
+	}
+	if stickiness.Duration != 0 && stickiness.Type == StickySourceIP {
+		errs = append(errs, invalid(field(path, "Duration"), "durations are only used by cookies"))
+	}
+	if (stickiness.CookieName != "") != (stickiness.Type == StickyAppCookie) {
+		errs = append(errs, invalid(field(path, "CookieName"), "a cookie name is required by %s stickiness, and only by it", StickyAppCookie))
+	}
+	return
+}
+
+func (p *LBTargetGroupParameters) validateHealthCheck(path string) (errs []error) {
+	hc := p.HealthCheck
+	switch hc.Protocol {
+	case "", TGProtoHTTP, TGProtoHTTPS, TGProtoTCP:
+	default:
+		errs = append(errs, invalid(field(path, "Protocol"), "health checks use HTTP, HTTPS or TCP, got %q", hc.Protocol))
+	}
+	if hc.Protocol == TGProtoTCP && (p.isHTTP() || p.TargetType == TGAlb) {
+		errs = append(errs, invalid(field(path, "Protocol"), "the targets of %s target groups are checked with HTTP or HTTPS", valueOrDefault(string(p.TargetType), string(p.Protocol))))
+	}
+	if p.healthCheckProtocol() == TGProtoTCP && (hc.Path != "" || hc.StatusCodeRange != "") {
+		errs = append(errs, invalid(path, "paths and status codes are only used by HTTP and HTTPS health checks"))
+	}
+	if hc.Port != "" && hc.Port != "traffic-port" {
+		if port, err := strconv.Atoi(hc.Port); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, invalid(field(path, "Port"), "port must be traffic-port or a number between 1 and 65535, got %q", hc.Port))
+		}
+	}
+	if hc.Interval != 0 && (hc.Interval < 5 || hc.Interval > 300) {
+		errs = append(errs, invalid(field(path, "Interval"), "interval must be between 5 and 300 seconds, got %d", hc.Interval))
+	}
+	if hc.Timeout != 0 && (hc.Timeout < 2 || hc.Timeout > 120) {
+		errs = append(errs, invalid(field(path, "Timeout"), "timeout must be between 2 and 120 seconds, got %d", hc.Timeout))
+	}
+	if valueOrDefault(hc.Timeout, 6) >= valueOrDefault(hc.Interval, 30) {
+		errs = append(errs, invalid(field(path, "Timeout"), "timeout must be shorter than the interval"))
+	}
+	for _, threshold := range []struct {
+		name  string
+		value int
+	}{{"HealthyThreshold", hc.HealthyThreshold}, {"UnhealthyThreshold", hc.UnhealthyThreshold}} {
+		if threshold.value != 0 && (threshold.value < 2 || threshold.value > 10) {
+			errs = append(errs, invalid(field(path, threshold.name), "threshold must be between 2 and 10, got %d", threshold.value))
+		}
+	}
+	return
+}
+
+func (p *ECSClusterParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	var names, providerNames []string
+	types := make(map[string]CapacityProviderType)
+	seenTypes := make(map[CapacityProviderType]bool)
+	for i := range p.CapacityProviders {
+		cp := &p.CapacityProviders[i]
+		providerNames = append(providerNames, cp.Name)
+		if _, ok := types[cp.Name]; !ok {
+			types[cp.Name] = cp.Type
+		}
+		errs = append(errs, cp.validate(index(path, "CapacityProviders", i))...)
+		//The cluster associates the Fargate capacity providers by their type
+		if cp.Type != EC2Capacity && seenTypes[cp.Type] {
+			errs = append(errs, invalid(field(index(path, "CapacityProviders", i), "Type"), "the cluster already has a %s capacity provider", cp.Type))
+		}
+		seenTypes[cp.Type] = true
+	}
+	for i := range p.Services {
+		names = append(names, p.Services[i].Name)
+		errs = append(errs, p.Services[i].validate(index(path, "Services", i))...)
+		errs = append(errs, p.Services[i].validateStrategy(index(path, "Services", i), types)...)
+	}
+	if p.Namespace != nil {
+		errs = append(errs, p.Namespace.validate(field(path, "Namespace"))...)
+	}
+	errs = append(errs, p.validateDiscoveryNames(path)...)
+	errs = append(errs, duplicates(path, "CapacityProviders", providerNames)...)
+	return append(errs, duplicates(path, "Services", names)...)
+}
+
+// reservedCapacityProviderPrefixes are the prefixes that the names of EC2 capacity providers cannot have
+var reservedCapacityProviderPrefixes = []string{"aws", "ecs", "fargate"}
+
+func (p *CapacityProviderParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	switch p.Type {
+	case FargateCapacity, FargateSpotCapacity:
+		if !p.EC2.isEmpty() {
+			errs = append(errs, invalid(field(path, "EC2"), "only EC2 capacity providers have an Auto Scaling group"))
+		}
+	case EC2Capacity:
+		for _, prefix := range reservedCapacityProviderPrefixes {
+			if strings.HasPrefix(strings.ToLower(p.Name), prefix) {
+				errs = append(errs, invalid(field(path, "Name"), "the name of an EC2 capacity provider cannot start with %q", prefix))
+			}
+		}
+		errs = append(errs, p.EC2.validate(field(path, "EC2"))...)
+	default:
+		errs = append(errs, invalid(field(path, "Type"), "unknown capacity provider type %q", p.Type))
+	}
+	return
+}
+
+// isEmpty is true when no parameter of the Auto Scaling group is set
+func (p *EC2CapacityParameters) isEmpty() bool {
+	return len(p.InstanceTypes) == 0 && p.MinSize == 0 && p.MaxSize == 0 && p.ImageID == "" &&
+		p.ManagedScaling == EC2ManagedScaling{} && !p.ManagedTerminationProtection
+}
+
+func (p *EC2CapacityParameters) validate(path string) (errs []error) {
+	if len(p.InstanceTypes) == 0 {
+		errs = append(errs, invalid(field(path, "InstanceTypes"), "at least one instance type is required"))
+	}
+	for i, instanceType := range p.InstanceTypes {
+		if instanceType == "" {
+			errs = append(errs, invalid(fmt.Sprintf("%s[%d]", field(path, "InstanceTypes"), i), "instance type is required"))
+		}
+	}
+	if p.MinSize < 0 {
+		errs = append(errs, invalid(field(path, "MinSize"), "minimum size cannot be negative"))
+	}
+	if p.MaxSize < 1 || p.MaxSize < p.MinSize {
+		errs = append(errs, invalid(field(path, "MaxSize"), "maximum size must be at least 1 and the minimum size"))
+	}
+	scaling := p.ManagedScaling
+	if scaling.TargetCapacity < 0 || scaling.TargetCapacity > 100 {
+		errs = append(errs, invalid(field(path, "ManagedScaling.TargetCapacity"), "target capacity must be between 1 and 100, got %d", scaling.TargetCapacity))
+	}
+	if scaling.MinimumScalingStepSize < 0 || scaling.MinimumScalingStepSize > 10000 {
+		errs = append(errs, invalid(field(path, "ManagedScaling.MinimumScalingStepSize"), "minimum scaling step size must be between 1 and 10000"))
+	}
+	if scaling.MaximumScalingStepSize < 0 || scaling.MaximumScalingStepSize > 10000 {
+		errs = append(errs, invalid(field(path, "ManagedScaling.MaximumScalingStepSize"), "maximum scaling step size must be between 1 and 10000"))
+	}
+	if scaling.MaximumScalingStepSize != 0 && scaling.MaximumScalingStepSize < scaling.MinimumScalingStepSize {
+		errs = append(errs, invalid(field(path, "ManagedScaling.MaximumScalingStepSize"), "maximum scaling step size cannot be less than the minimum scaling step size"))
+	}
+	if p.ManagedTerminationProtection && scaling.Disabled {
+		errs = append(errs, invalid(field(path, "ManagedTerminationProtection"), "managed termination protection requires managed scaling"))
+	}
+	return
+}
+
+// validateStrategy validates the capacity provider strategy of the service against the capacity providers of its cluster,
+// by name, and the cpu and memory of the service against the capacity it runs on
+func (p *ECSServiceParameters) validateStrategy(path string, types map[string]CapacityProviderType) (errs []error) {
+	var fargate, ec2 bool
+	bases := 0
+	weights := 0
+	for i, item := range p.CapacityProviderStrategy {
+		itemPath := index(path, "CapacityProviderStrategy", i)
+		if t, ok := types[item.CapacityProviderLookupName]; !ok {
+			errs = append(errs, invalid(field(itemPath, "CapacityProviderLookupName"), "the cluster has no capacity provider %q", item.CapacityProviderLookupName))
+		} else if t == EC2Capacity {
+			ec2 = true
+		} else {
+			fargate = true
+		}
+		if item.Weight < 0 || item.Weight > 1000 {
+			errs = append(errs, invalid(field(itemPath, "Weight"), "weight must be between 0 and 1000, got %d", item.Weight))
+		}
+		if item.Base < 0 || item.Base > 100000 {
+			errs = append(errs, invalid(field(itemPath, "Base"), "base must be between 0 and 100000, got %d", item.Base))
+		}
+		if item.Base > 0 {
+			bases++
+		}
+		weights += item.Weight
+	}
+	if len(p.CapacityProviderStrategy) == 0 {
+		return
+	}
+	var lookupNames []string
+	for _, item := range p.CapacityProviderStrategy {
+		lookupNames = append(lookupNames, item.CapacityProviderLookupName)
+	}
+	errs = append(errs, duplicates(path, "CapacityProviderStrategy", lookupNames)...)
+	if bases > 1 {
+		errs = append(errs, invalid(field(path, "CapacityProviderStrategy"), "only one capacity provider of the strategy can have a base"))
+	}
+	if weights == 0 {
+		errs = append(errs, invalid(field(path, "CapacityProviderStrategy"), "at least one capacity provider of the strategy must have a weight"))
+	}
+	if fargate && ec2 {
+		errs = append(errs, invalid(field(path, "CapacityProviderStrategy"), "a strategy cannot mix Fargate and EC2 capacity providers"))
+	}
+	if fargate {
+		errs = append(errs, p.validateFargateSize(path)...)
+	}
+	if ec2 && p.AssignPublicIP {
+		errs = append(errs, invalid(field(path, "AssignPublicIP"), "tasks on EC2 instances cannot have a public IP"))
+	}
+	return
+}
+
+// validateFargateSize validates the cpu and memory of a service that runs on Fargate
+func (p *ECSServiceParameters) validateFargateSize(path string) (errs []error) {
+	if memories, ok := fargateMemory[p.CPU]; !ok {
+		errs = append(errs, invalid(field(path, "CPU"), "%d is not a fargate cpu value", p.CPU))
+	} else if !containsInt(memories, p.Memory) {
+		errs = append(errs, invalid(field(path, "Memory"), "%d MiB is not a fargate memory value for %d cpu units", p.Memory, p.CPU))
+	}
+	return
+}
+
+func (p *ECSServiceParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if p.DesiredCount < 0 {
+		errs = append(errs, invalid(field(path, "DesiredCount"), "desired count cannot be negative"))
+	}
+	//The cluster validates the size of the services that use capacity providers, since it depends on the providers
+	if !p.usesCapacityProviders() {
+		errs = append(errs, p.validateFargateSize(path)...)
+	} else if p.CPU < 128 || p.Memory < 128 {
+		errs = append(errs, invalid(path, "the service needs at least 128 cpu units and 128 MiB"))
+	}
+	if len(p.Containers) == 0 {
+		errs = append(errs, invalid(field(path, "Containers"), "at least one container is required"))
+	}
+	var names []string
+	var cpu, memory int64
+	if fireLens := p.Logs.FireLens; fireLens != nil && p.Logs.Enabled {
+		cpu += fireLens.CPU
+		memory += fireLens.Memory
+	}
+	for i := range p.Containers {
+		names = append(names, p.Containers[i].Name)
+		cpu += p.Containers[i].CPU
+		memory += p.Containers[i].Memory
+		errs = append(errs, p.Containers[i].validate(index(path, "Containers", i))...)
+		if p.Logs.FireLens != nil && p.Containers[i].Name == FireLensContainerName {
+			errs = append(errs, invalid(field(index(path, "Containers", i), "Name"), "%s is the name of the FireLens sidecar", FireLensContainerName))
+		}
+	}
+	errs = append(errs, p.Logs.validate(field(path, "Logs"))...)
+	if p.AutoScaling != nil {
+		errs = append(errs, p.AutoScaling.validate(field(path, "AutoScaling"))...)
+		if p.DesiredCount != 0 && (p.DesiredCount < p.AutoScaling.MinCapacity || p.DesiredCount > p.AutoScaling.MaxCapacity) {
+			errs = append(errs, invalid(field(path, "DesiredCount"), "the desired count must be between the capacities of the auto scaling"))
+		}
+	}
+	if cpu > int64(p.CPU) {
+		errs = append(errs, invalid(field(path, "Containers"), "the containers use %d cpu units, more than the %d of the service", cpu, p.CPU))
+	}
+	if memory > int64(p.Memory) {
+		errs = append(errs, invalid(field(path, "Containers"), "the containers use %d MiB, more than the %d of the service", memory, p.Memory))
+	}
+	errs = append(errs, p.TaskRole.validate(field(path, "TaskRole"))...)
+	errs = append(errs, p.validatePortNames(path)...)
+	if p.ServiceDiscovery != nil {
+		errs = append(errs, p.ServiceDiscovery.validate(field(path, "ServiceDiscovery"), p)...)
+	}
+	if p.ServiceConnect != nil {
+		errs = append(errs, p.ServiceConnect.validate(field(path, "ServiceConnect"), p)...)
+	}
+	errs = append(errs, p.Deployment.validate(field(path, "Deployment"), p)...)
+	return append(errs, duplicates(path, "Containers", names)...)
+}
+
+func (p *DeploymentParameters) validate(path string, service *ECSServiceParameters) (errs []error) {
+	if p.CircuitBreaker && p.BlueGreen != nil {
+		errs = append(errs, invalid(field(path, "CircuitBreaker"), "the circuit breaker only stops rolling deployments, not blue/green ones"))
+	}
+	if p.Rollback && !p.CircuitBreaker && p.BlueGreen == nil {
+		errs = append(errs, invalid(field(path, "Rollback"), "rollback needs the circuit breaker or the blue/green mode"))
+	}
+	minimum := 100
+	if p.MinimumHealthyPercent != nil {
+		minimum = *p.MinimumHealthyPercent
+		if minimum < 0 || minimum > 100 {
+			errs = append(errs, invalid(field(path, "MinimumHealthyPercent"), "minimum healthy percent must be between 0 and 100"))
+		}
+	}
+	if p.MaximumPercent != 0 && (p.MaximumPercent < 100 || p.MaximumPercent < minimum) {
+		errs = append(errs, invalid(field(path, "MaximumPercent"), "maximum percent must be at least 100 and the minimum healthy percent"))
+	}
+	if p.HealthCheckGracePeriod < 0 {
+		errs = append(errs, invalid(field(path, "HealthCheckGracePeriod"), "health check grace period cannot be negative"))
+	} else if p.HealthCheckGracePeriod > 0 && len(service.targetGroupLookupNames()) == 0 {
+		errs = append(errs, invalid(field(path, "HealthCheckGracePeriod"), "the service has no port mapping with a target group"))
+	}
+	if p.BlueGreen == nil {
+		return
+	}
+	bpath := field(path, "BlueGreen")
+	if service.ServiceDiscovery != nil || service.ServiceConnect != nil {
+		errs = append(errs, invalid(bpath, "blue/green deployments don't support service discovery or Service Connect"))
+	}
+	switch names := service.targetGroupLookupNames(); {
+	case p.BlueGreen.TargetGroupLookupName == "":
+		errs = append(errs, invalid(field(bpath, "TargetGroupLookupName"), "target group is required"))
+	case len(names) != 1 || names[0] != p.BlueGreen.TargetGroupLookupName:
+		errs = append(errs, invalid(field(bpath, "TargetGroupLookupName"), "the port mappings of the service must forward only from the target group %q", p.BlueGreen.TargetGroupLookupName))
+	}
+	if p.BlueGreen.LoadBalancerLookupName == "" {
+		errs = append(errs, invalid(field(bpath, "LoadBalancerLookupName"), "load balancer is required"))
+	}
+	if p.BlueGreen.ListenerLookupName == "" {
+		errs = append(errs, invalid(field(bpath, "ListenerLookupName"), "listener is required"))
+	}
+	errs = append(errs, validatePort(field(bpath, "TestListenerPort"), p.BlueGreen.TestListenerPort)...)
+	if p.BlueGreen.TerminationWaitTime < 0 || p.BlueGreen.TerminationWaitTime > 2880 {
+		errs = append(errs, invalid(field(bpath, "TerminationWaitTime"), "termination wait time must be between 0 and 2880 minutes"))
+	}
+	return
+}
+
+// validateBlueGreen checks the production listener and the test listener port of a service deployed in the blue/green mode,
+// and that the green target group can be named after the blue one
+func (p *NetworkPartitionParameters) validateBlueGreen(path string, bg *BlueGreenParameters, targetGroups map[string]*LBTargetGroupParameters) (errs []error) {
+	if _, ok := targetGroups[bg.greenTargetGroupName()]; ok {
+		errs = append(errs, invalid(field(path, "TargetGroupLookupName"), "the green target group %q is already a target group of the partition", bg.greenTargetGroupName()))
+	}
+	loadBalancer := p.loadBalancer(bg.LoadBalancerLookupName)
+	if loadBalancer == nil {
+		return append(errs, invalid(field(path, "LoadBalancerLookupName"), "load balancer %q not found in the partition", bg.LoadBalancerLookupName))
+	}
+	if loadBalancer.Type == Gateway {
+		errs = append(errs, invalid(field(path, "LoadBalancerLookupName"), "blue/green deployments need an %s or %s load balancer", Application, Network))
+	}
+	if loadBalancer.listensOn(bg.TestListenerPort) {
+		errs = append(errs, invalid(field(path, "TestListenerPort"), "load balancer %q already listens on the port %d", loadBalancer.Name, bg.TestListenerPort))
+	}
+	for _, listener := range loadBalancer.Listeners {
+		if listener.Name != bg.ListenerLookupName {
+			continue
+		}
+		for _, name := range listener.targetGroupLookupNames() {
+			if name == bg.TargetGroupLookupName {
+				return
+			}
+		}
+		return append(errs, invalid(field(path, "ListenerLookupName"), "listener %q doesn't forward to the target group %q", listener.Name, bg.TargetGroupLookupName))
+	}
+	return append(errs, invalid(field(path, "ListenerLookupName"), "listener %q not found in the load balancer %q", bg.ListenerLookupName, loadBalancer.Name))
+}
+
+// targetGroupLookupNames returns the target groups of the port mappings of the service, once each
+func (p *ECSServiceParameters) targetGroupLookupNames() (names []string) {
+	seen := make(map[string]bool)
+	for _, c := range p.Containers {
+		for _, mapping := range c.PortMappings {
+			if mapping.TargetGroupLookupName != "" && !seen[mapping.TargetGroupLookupName] {
+				seen[mapping.TargetGroupLookupName] = true
+				names = append(names, mapping.TargetGroupLookupName)
+			}
+		}
+	}
+	return
+}
+
+// alarmComparisonOperators are the comparison operators of the alarms of step scaling policies
+var alarmComparisonOperators = []string{"GreaterThanOrEqualToThreshold", "GreaterThanThreshold", "LessThanThreshold", "LessThanOrEqualToThreshold"}
+
+// alarmStatistics are the statistics of the alarms of step scaling policies
+var alarmStatistics = []string{"Average", "Minimum", "Maximum", "Sum", "SampleCount"}
+
+func (p *ServiceAutoScalingParameters) validate(path string) (errs []error) {
+	errs = append(errs, validateCapacities(path, p.MinCapacity, p.MaxCapacity)...)
+	var trackingNames, stepNames, actionNames []string
+	tracking := make(map[string]bool)
+	for i := range p.TargetTracking {
+		trackingNames = append(trackingNames, p.TargetTracking[i].Name)
+		tracking[p.TargetTracking[i].Name] = true
+		errs = append(errs, p.TargetTracking[i].validate(index(path, "TargetTracking", i))...)
+	}
+	for i := range p.StepScaling {
+		stepNames = append(stepNames, p.StepScaling[i].Name)
+		errs = append(errs, p.StepScaling[i].validate(index(path, "StepScaling", i))...)
+		//Both kinds of policies are indexed by name in the response
+		if tracking[p.StepScaling[i].Name] {
+			errs = append(errs, invalid(index(path, "StepScaling", i), "name %q is already used by a target tracking policy", p.StepScaling[i].Name))
+		}
+	}
+	for i := range p.ScheduledActions {
+		actionNames = append(actionNames, p.ScheduledActions[i].Name)
+		errs = append(errs, p.ScheduledActions[i].validate(index(path, "ScheduledActions", i))...)
+	}
+	errs = append(errs, duplicates(path, "TargetTracking", trackingNames)...)
+	errs = append(errs, duplicates(path, "StepScaling", stepNames)...)
+	return append(errs, duplicates(path, "ScheduledActions", actionNames)...)
+}
+
+func validateCapacities(path string, min, max int) (errs []error) {
+	if min < 0 {
+		errs = append(errs, invalid(field(path, "MinCapacity"), "minimum capacity cannot be negative"))
+	}
+	if max < 1 || max < min {
+		errs = append(errs, invalid(field(path, "MaxCapacity"), "maximum capacity must be at least 1 and the minimum capacity"))
+	}
+	return
+}
+
+func (p *TargetTrackingScalingParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	switch p.Metric {
+	case ScaleOnCPU, ScaleOnMemory:
+		if p.TargetValue <= 0 || p.TargetValue > 100 {
+			errs = append(errs, invalid(field(path, "TargetValue"), "the target utilization must be a percentage above 0"))
+		}
+		if p.TargetGroupLookupName != "" {
+			errs = append(errs, invalid(field(path, "TargetGroupLookupName"), "only %s policies count the requests of a target group", ScaleOnRequestCount))
+		}
+	case ScaleOnRequestCount:
+		if p.TargetValue <= 0 {
+			errs = append(errs, invalid(field(path, "TargetValue"), "the target request count must be above 0"))
+		}
+		if p.TargetGroupLookupName == "" {
+			errs = append(errs, invalid(field(path, "TargetGroupLookupName"), "the target group whose requests are counted is required"))
+		}
+	default:
+		errs = append(errs, invalid(field(path, "Metric"), "metric must be %s, %s or %s", ScaleOnCPU, ScaleOnMemory, ScaleOnRequestCount))
+	}
+	if p.ScaleInCooldown < 0 {
+		errs = append(errs, invalid(field(path, "ScaleInCooldown"), "cooldown cannot be negative"))
+	}
+	if p.ScaleOutCooldown < 0 {
+		errs = append(errs, invalid(field(path, "ScaleOutCooldown"), "cooldown cannot be negative"))
+	}
+	return
+}
+
+func (p *StepScalingParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	errs = append(errs, p.Alarm.validate(field(path, "Alarm"))...)
+	switch p.AdjustmentType {
+	case "", ChangeInCapacity, PercentChangeInCapacity, ExactCapacity:
+	default:
+		errs = append(errs, invalid(field(path, "AdjustmentType"), "adjustment type must be %s, %s or %s", ChangeInCapacity, PercentChangeInCapacity, ExactCapacity))
+	}
+	if p.Cooldown < 0 {
+		errs = append(errs, invalid(field(path, "Cooldown"), "cooldown cannot be negative"))
+	}
+	if len(p.Steps) == 0 {
+		errs = append(errs, invalid(field(path, "Steps"), "at least one step is required"))
+	}
+	var unboundedBelow, unboundedAbove int
+	for i, step := range p.Steps {
+		spath := index(path, "Steps", i)
+		if step.LowerBound == nil {
+			unboundedBelow++
+		}
+		if step.UpperBound == nil {
+			unboundedAbove++
+		}
+		if step.LowerBound != nil && step.UpperBound != nil && *step.LowerBound >= *step.UpperBound {
+			errs = append(errs, invalid(spath, "the lower bound must be below the upper bound"))
+		}
+		if p.AdjustmentType == ExactCapacity && step.Adjustment < 0 {
+			errs = append(errs, invalid(field(spath, "Adjustment"), "an exact capacity cannot be negative"))
+		}
+	}
+	if unboundedBelow > 1 || unboundedAbove > 1 {
+		errs = append(errs, invalid(field(path, "Steps"), "only one step can have no lower bound and only one no upper bound"))
+	}
+	return
+}
+
+func (p *ScalingAlarmParameters) validate(path string) (errs []error) {
+	if p.Namespace == "" {
+		errs = append(errs, invalid(field(path, "Namespace"), "namespace is required"))
+	}
+	if p.MetricName == "" {
+		errs = append(errs, invalid(field(path, "MetricName"), "metric name is required"))
+	}
+	if !containsString(alarmComparisonOperators, p.ComparisonOperator) {
+		errs = append(errs, invalid(field(path, "ComparisonOperator"), "comparison operator must be one of %s", strings.Join(alarmComparisonOperators, ", ")))
+	}
+	if p.Statistic != "" && !containsString(alarmStatistics, p.Statistic) {
+		errs = append(errs, invalid(field(path, "Statistic"), "statistic must be one of %s", strings.Join(alarmStatistics, ", ")))
+	}
+	if p.Period != 0 && p.Period != 10 && p.Period != 30 && (p.Period < 0 || p.Period%60 != 0) {
+		errs = append(errs, invalid(field(path, "Period"), "period must be 10, 30 or a multiple of 60 seconds"))
+	}
+	if p.EvaluationPeriods < 0 {
+		errs = append(errs, invalid(field(path, "EvaluationPeriods"), "evaluation periods cannot be negative"))
+	}
+	return
+}
+
+func (p *ScheduledScalingParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if !strings.HasSuffix(p.Schedule, ")") || !(strings.HasPrefix(p.Schedule, "cron(") || strings.HasPrefix(p.Schedule, "rate(") || strings.HasPrefix(p.Schedule, "at(")) {
+		errs = append(errs, invalid(field(path, "Schedule"), "%q is not a cron(...), rate(...) or at(...) expression", p.Schedule))
+	}
+	return append(errs, validateCapacities(path, p.MinCapacity, p.MaxCapacity)...)
+}
+
+// logRetentionDays are the retention periods accepted by CloudWatch Logs
+var logRetentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// kmsKeyArnPattern matches the arns of KMS keys, like arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab
+var kmsKeyArnPattern = regexp.MustCompile(`^arn:aws[a-z-]*:kms:[a-z0-9-]+:\d{12}:key/[\w-]+$`)
+
+func (p *LogParameters) validate(path string) (errs []error) {
+	if !p.Enabled {
+		if p.FireLens != nil || p.RetentionInDays != 0 || p.KmsKeyArn != "" || p.StreamPrefix != "" {
+			errs = append(errs, invalid(field(path, "Enabled"), "the logs are configured but not enabled"))
+		}
+		return
+	}
+	if p.RetentionInDays != 0 && !containsInt(logRetentionDays, p.RetentionInDays) {
+		errs = append(errs, invalid(field(path, "RetentionInDays"), "%d days is not a CloudWatch retention period", p.RetentionInDays))
+	}
+	if p.KmsKeyArn != "" && !kmsKeyArnPattern.MatchString(p.KmsKeyArn) {
+		errs = append(errs, invalid(field(path, "KmsKeyArn"), "%q is not the arn of a KMS key", p.KmsKeyArn))
+	}
+	if strings.ContainsAny(p.StreamPrefix, ":*") {
+		errs = append(errs, invalid(field(path, "StreamPrefix"), "the stream prefix cannot contain : or *"))
+	}
+	if fireLens := p.FireLens; fireLens != nil {
+		fpath := field(path, "FireLens")
+		if fireLens.Options["Name"] == "" {
+			errs = append(errs, invalid(field(fpath, "Options"), "the Name option is required to choose the Fluent Bit output"))
+		}
+		if fireLens.CPU < 0 {
+			errs = append(errs, invalid(field(fpath, "CPU"), "cpu cannot be negative"))
+		}
+		if fireLens.Memory < 0 {
+			errs = append(errs, invalid(field(fpath, "Memory"), "memory cannot be negative"))
+		}
+	}
+	return
+}
+
+// managedPolicyArnPattern matches the arns of managed policies, like arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess
+var managedPolicyArnPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::(aws|\d{12}):policy/([\w+=,.@-]+/)*[\w+=,.@-]+$`)
+
+func (p *TaskRoleParameters) validate(path string) (errs []error) {
+	for i := range p.Statements {
+		errs = append(errs, p.Statements[i].validate(index(path, "Statements", i))...)
+	}
+	var names []string
+	for i, arn := range p.ManagedPolicyArns {
+		if !managedPolicyArnPattern.MatchString(arn) {
+			errs = append(errs, invalid(index(path, "ManagedPolicyArns", i), "%q is not the arn of a managed policy", arn))
+		}
+		//The attachments are named after the policies, so two policies can't share a name
+		names = append(names, policyNameOf(arn))
+	}
+	return append(errs, duplicates(path, "ManagedPolicyArns", names)...)
+}
+
+func (s *PolicyStatement) validate(path string) (errs []error) {
+	if s.Effect != "" && s.Effect != Allow && s.Effect != Deny {
+		errs = append(errs, invalid(field(path, "Effect"), "effect must be %s or %s", Allow, Deny))
+	}
+	if len(s.Actions) == 0 {
+		errs = append(errs, invalid(field(path, "Actions"), "at least one action is required"))
+	}
+	for i, action := range s.Actions {
+		if action != "*" && !strings.Contains(action, ":") {
+			errs = append(errs, invalid(index(path, "Actions", i), "%q is not an action like s3:GetObject", action))
+		}
+	}
+	if len(s.Resources) == 0 {
+		errs = append(errs, invalid(field(path, "Resources"), "at least one resource is required"))
+	}
+	for i, resource := range s.Resources {
+		if resource != "*" && !strings.HasPrefix(resource, "arn:") {
+			errs = append(errs, invalid(index(path, "Resources", i), "%q is not an arn", resource))
+		}
+	}
+	return
+}
+
+func (c *ContainerDefinition) validate(path string) (errs []error) {
+	if c.Name == "" {
+		errs = append(errs, invalid(field(path, "Name"), "name is required"))
+	}
+	if c.Image == "" {
+		errs = append(errs, invalid(field(path, "Image"), "image is required"))
+	}
+	if c.CPU < 0 {
+		errs = append(errs, invalid(field(path, "CPU"), "cpu cannot be negative"))
+	}
+	if c.Memory < 0 {
+		errs = append(errs, invalid(field(path, "Memory"), "memory cannot be negative"))
+	}
+	for i, mapping := range c.PortMappings {
+		mpath := index(path, "PortMappings", i)
+		errs = append(errs, validatePort(field(mpath, "ContainerPort"), mapping.ContainerPort)...)
+		if mapping.HostPort != 0 && mapping.HostPort != mapping.ContainerPort {
+			errs = append(errs, invalid(field(mpath, "HostPort"), "the host port must be the container port in the awsvpc network mode"))
+		}
+		if mapping.Name != "" && !portNamePattern.MatchString(mapping.Name) {
+			errs = append(errs, invalid(field(mpath, "Name"), "%q is not a port name of lowercase letters, digits, _ and -", mapping.Name))
+		}
+		switch mapping.AppProtocol {
+		case "", AppHTTP, AppHTTP2, AppGRPC:
+		default:
+			errs = append(errs, invalid(field(mpath, "AppProtocol"), "app protocol must be %s, %s or %s, got %q", AppHTTP, AppHTTP2, AppGRPC, mapping.AppProtocol))
+		}
+	}
+	environment := make(map[string]bool)
+	for _, variable := range c.Environment {
+		environment[variable.Name] = true
+	}
+	var names []string
+	for i := range c.Secrets {
+		spath := index(path, "Secrets", i)
+		names = append(names, c.Secrets[i].Name)
+		errs = append(errs, c.Secrets[i].validate(spath)...)
+		if environment[c.Secrets[i].Name] {
+			errs = append(errs, invalid(field(spath, "Name"), "%s is also a plain environment variable", c.Secrets[i].Name))
+		}
+	}
+	return append(errs, duplicates(path, "Secrets", names)...)
+}
+
+// environmentVariablePattern matches the names of environment variables
+var environmentVariablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// secretArnPatterns match the arns of Secrets Manager secrets, with an optional json key, and of Parameter Store parameters
+var secretArnPatterns = map[SecretSource]*regexp.Regexp{
+	SecretsManager: regexp.MustCompile(`^arn:aws[a-z-]*:secretsmanager:[a-z0-9-]+:\d{12}:secret:[\w/+=.@-]+(:[^:]*:[^:]*:[^:]*)?$`),
+	ParameterStore: regexp.MustCompile(`^arn:aws[a-z-]*:ssm:[a-z0-9-]+:\d{12}:parameter/[\w/.-]+$`),
+}
+
+func (s *ContainerSecret) validate(path string) (errs []error) {
+	if !environmentVariablePattern.MatchString(s.Name) {
+		errs = append(errs, invalid(field(path, "Name"), "%q is not the name of an environment variable", s.Name))
+	}
+	pattern, ok := secretArnPatterns[s.Source]
+	if !ok {
+		errs = append(errs, invalid(field(path, "Source"), "source must be %s or %s", SecretsManager, ParameterStore))
+	}
+	switch {
+	case s.Arn == "" && s.Value == nil:
+		errs = append(errs, invalid(path, "either the arn of an existing secret or a value is required"))
+	case s.Arn != "" && s.Value != nil:
+		errs = append(errs, invalid(field(path, "Value"), "a secret with an arn already exists and cannot have a value"))
+	case s.Arn != "" && ok && !pattern.MatchString(s.Arn):
+		errs = append(errs, invalid(field(path, "Arn"), "%q is not the arn of a secret stored in %s", s.Arn, s.Source))
+	}
+	if s.KmsKeyArn != "" && !kmsKeyArnPattern.MatchString(s.KmsKeyArn) {
+		errs = append(errs, invalid(field(path, "KmsKeyArn"), "%q is not the arn of a KMS key", s.KmsKeyArn))
+	}
+	return
+}
+
+// isLayer4 tells if the protocol is balanced by network load balancers
+// namespaceDomainPattern matches the domains of private DNS namespaces, like internal.local
+var namespaceDomainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// discoveryNamePattern matches the names of the services of a namespace, like api
+var discoveryNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// portNamePattern matches the names of the port mappings, like grpc
+var portNamePattern = regexp.MustCompile(`^[a-z0-9_][a-z0-9_-]{0,63}$`)
+
+func (p *NamespaceParameters) validate(path string) (errs []error) {
+	errs = append(errs, requireName(path, p.Name)...)
+	if p.Domain == "" {
+		errs = append(errs, invalid(field(path, "Domain"), "domain is required"))
+	} else if len(p.Domain) > 253 || !namespaceDomainPattern.MatchString(p.Domain) {
+		errs = append(errs, invalid(field(path, "Domain"), "%q is not a lowercase domain name", p.Domain))
+	}
+	return
+}
+
+// validateNamespaces checks that the services that register in a namespace, or use Service Connect, have one in their cluster
+// or in the Vpc
+func (p *VpcParameters) validateNamespaces(path string) (errs []error) {
+	if p.Namespace != nil {
+		errs = append(errs, p.Namespace.validate(field(path, "Namespace"))...)
+		return
+	}
+	for i, partition := range p.Partitions {
+		for j, cluster := range partition.ECSClusters {
+			if cluster.Namespace != nil {
+				continue
+			}
+			for k, service := range cluster.Services {
+				spath := index(index(index(path, "Partitions", i), "ECSClusters", j), "Services", k)
+				if service.ServiceDiscovery != nil {
+					errs = append(errs, invalid(field(spath, "ServiceDiscovery"), "neither the cluster nor the vpc has a namespace"))
+				}
+				if service.ServiceConnect != nil {
+					errs = append(errs, invalid(field(spath, "ServiceConnect"), "neither the cluster nor the vpc has a namespace"))
+				}
+			}
+		}
+	}
+	return
+}
+
+// validateDiscoveryNames reports the services of the cluster that take the same name in the namespace, with Cloud Map
+// or Service Connect
+func (p *ECSClusterParameters) validateDiscoveryNames(path string) (errs []error) {
+	seen := make(map[string]string)
+	check := func(path, name string) {
+		if name == "" {
+			return
+		}
+		if first, ok := seen[name]; ok {
+			errs = append(errs, invalid(path, "the name %q is already used in the namespace by %s", name, first))
+			return
+		}
+		seen[name] = path
+	}
+	for i, service := range p.Services {
+		spath := index(path, "Services", i)
+		if service.ServiceDiscovery != nil {
+			check(field(spath, "ServiceDiscovery"), valueOrDefault(service.ServiceDiscovery.Name, service.Name))
+		}
+		if service.ServiceConnect != nil {
+			for j, s := range service.ServiceConnect.Services {
+				check(index(field(spath, "ServiceConnect"), "Services", j), valueOrDefault(s.DiscoveryName, s.PortName))
+			}
+		}
+	}
+	return
+}
+
+// validatePortNames reports the port mappings of the containers of the service that have the same name
+func (p *ECSServiceParameters) validatePortNames(path string) (errs []error) {
+	seen := make(map[string]string)
+	for i, c := range p.Containers {
+		for j, mapping := range c.PortMappings {
+			if mapping.Name == "" {
+				continue
+			}
+			mpath := index(index(path, "Containers", i), "PortMappings", j)
+			if first, ok := seen[mapping.Name]; ok {
+				errs = append(errs, invalid(field(mpath, "Name"), "port name %q is already used by %s", mapping.Name, first))
+				continue
+			}
+			seen[mapping.Name] = mpath
+		}
+	}
+	return
+}
+
+func (p *ServiceDiscoveryParameters) validate(path string, service *ECSServiceParameters) (errs []error) {
+	if p.Name != "" && !discoveryNamePattern.MatchString(p.Name) {
+		errs = append(errs, invalid(field(path, "Name"), "%q is not a dns label", p.Name))
+	}
+	if p.TTL < 0 {
+		errs = append(errs, invalid(field(path, "TTL"), "ttl cannot be negative"))
+	}
+	srv := false
+	seen := make(map[DNSRecordType]bool)
+	for i, typ := range p.RecordTypes {
+		if typ != RecordA && typ != RecordSRV {
+			errs = append(errs, invalid(index(path, "RecordTypes", i), "record type must be %s or %s, got %q", RecordA, RecordSRV, typ))
+		} else if seen[typ] {
+			errs = append(errs, invalid(index(path, "RecordTypes", i), "record type %s is already used", typ))
+		}
+		seen[typ] = true
+		srv = srv || typ == RecordSRV
+	}
+	switch {
+	case srv && p.PortName == "":
+		errs = append(errs, invalid(field(path, "PortName"), "SRV records need a port name"))
+	case !srv && p.PortName != "":
+		errs = append(errs, invalid(field(path, "PortName"), "only SRV records have a port"))
+	case p.PortName != "":
+		if _, _, ok := service.namedPort(p.PortName); !ok {
+			errs = append(errs, invalid(field(path, "PortName"), "no port mapping of the service is named %q", p.PortName))
+		}
+	}
+	return
+}
+
+func (p *ServiceConnectParameters) validate(path string, service *ECSServiceParameters) (errs []error) {
+	var portNames []string
+	for i, s := range p.Services {
+		spath := index(path, "Services", i)
+		portNames = append(portNames, s.PortName)
+		if s.PortName == "" {
+			errs = append(errs, invalid(field(spath, "PortName"), "port name is required"))
+		} else if _, _, ok := service.namedPort(s.PortName); !ok {
+			errs = append(errs, invalid(field(spath, "PortName"), "no port mapping of the service is named %q", s.PortName))
+		}
+		if s.DiscoveryName != "" && !discoveryNamePattern.MatchString(s.DiscoveryName) {
+			errs = append(errs, invalid(field(spath, "DiscoveryName"), "%q is not a dns label", s.DiscoveryName))
+		}
+		if s.DNSName != "" && !namespaceDomainPattern.MatchString(s.DNSName) {
+			errs = append(errs, invalid(field(spath, "DNSName"), "%q is not a lowercase dns name", s.DNSName))
+		}
+		if s.ClientPort != 0 {
+			errs = append(errs, validatePort(field(spath, "ClientPort"), s.ClientPort)...)
+		}
+	}
+	return append(errs, duplicates(path, "Services", portNames)...)
+}
+
+func (p LBProtocol) isLayer4() bool {
+	return p == TCP || p == UDP || p == TCPUDP || p == TLS
+}
+
+// accepts tells if a listener of the protocol can forward to the target group
+func (p LBProtocol) accepts(tg *LBTargetGroupParameters) bool {
+	switch p {
+	case TCP:
+		return tg.Protocol == TGProtoTCP || tg.Protocol == TGProtoTCPUDP
+	case TLS:
+		return (tg.Protocol == TGProtoTCP || tg.Protocol == TGProtoTLS) && tg.TargetType != TGAlb
+	case UDP:
+		return tg.Protocol == TGProtoUDP || tg.Protocol == TGProtoTCPUDP
+	case TCPUDP:
+		return tg.Protocol == TGProtoTCPUDP
+	case "":
+		return tg.Protocol == TGProtoGENEVE
+	default:
+		return tg.isHTTP() || tg.TargetType == TGLambda
+	}
+}
+
+// loadBalancer returns the load balancer of a lookup name in the partition, or nil
+func (p *NetworkPartitionParameters) loadBalancer(lookupName string) *LoadBalancerParameters {
+	for i := range p.LoadBalancers {
+		if p.LoadBalancers[i].Name == lookupName {
+			return &p.LoadBalancers[i]
+		}
+	}
+	return nil
+}
+
+// listensOn tells if the load balancer has a listener on the port
+func (p *LoadBalancerParameters) listensOn(port int) bool {
+	for _, listener := range p.Listeners {
+		if listener.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+func join(errs []error) error {
+	return errors.Join(errs...)
+}
+
+func invalid(path string, format string, args ...any) error {
+	return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// field returns the path of a field, like Vpcs[0].CidrBlock
+func field(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// index returns the path of an element of a list, like Vpcs[0]
+func index(path, name string, i int) string {
+	return fmt.Sprintf("%s[%d]", field(path, name), i)
+}
+
+func requireName(path, name string) []error {
+	if name == "" {
+		return []error{invalid(field(path, "Name"), "name is required")}
+	}
+	return nil
+}
+
+// duplicates reports the names that appear more than once in a list, since responses are indexed by name
+func duplicates(path, list string, names []string) (errs []error) {
+	seen := make(map[string]int)
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		if first, ok := seen[name]; ok {
+			errs = append(errs, invalid(index(path, list, i), "name %q is already used by %s[%d]", name, list, first))
+			continue
+		}
+		seen[name] = i
+	}
+	return
+}
+
+func validatePort(path string, port int) []error {
+	if port < 1 || port > 65535 {
+		return []error{invalid(path, "port must be between 1 and 65535, got %d", port)}
+	}
+	return nil
+}
+
+func contains(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+func overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func steps(from, to, step int) (values []int) {
+	for v := from; v <= to; v += step {
+		values = append(values, v)
+	}
+	return
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
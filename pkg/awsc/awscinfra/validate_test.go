@@ -0,0 +1,1027 @@
+package awscinfra
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fpco-internal/pgocomp"
+	"github.com/fpco-internal/pgocomp/pgotest"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	vpcPath       = "Vpcs[0]"
+	partitionPath = vpcPath + ".Partitions[0]"
+	lbPath        = partitionPath + ".LoadBalancers[0]"
+	listenerPath  = lbPath + ".Listeners[0]"
+	rulePath      = listenerPath + ".Rules[0]"
+	tgPath        = partitionPath + ".LBTargetGroups[0]"
+	clusterPath   = partitionPath + ".ECSClusters[0]"
+	servicePath   = clusterPath + ".Services[0]"
+	containerPath = servicePath + ".Containers[0]"
+)
+
+func meta(name string) pgocomp.Meta {
+	return pgocomp.Meta{Name: name}
+}
+
+// validInfra returns parameters that pass the validation: a public partition with an application load balancer
+// that forwards to the target group of a fargate service
+func validInfra() InfraParameters {
+	return InfraParameters{
+		Meta: meta("infra"),
+		Vpcs: []VpcParameters{{
+			Meta:      meta("vpc"),
+			Provider:  ProviderParameters{Meta: meta("aws"), Region: "eu-west-1"},
+			CidrBlock: "10.0.0.0/16",
+			Partitions: []NetworkPartitionParameters{{
+				Meta:     meta("public"),
+				IsPublic: true,
+				Subnets: []SubnetParameters{
+					{Meta: meta("a"), CidrBlock: "10.0.0.0/24"},
+					{Meta: meta("b"), CidrBlock: "10.0.1.0/24"},
+				},
+				LoadBalancers: []LoadBalancerParameters{{
+					Meta: meta("lb"),
+					Type: Application,
+					Listeners: []LBListenerParameters{{
+						Meta:                  meta("http"),
+						Port:                  80,
+						Protocol:              HTTP,
+						TargetGroupLookupName: "web",
+						Rules: []LBRuleParameters{{
+							Meta:                  meta("api"),
+							Priority:              10,
+							TargetGroupLookupName: "web",
+							Conditions:            []LBRuleConditionParameters{{RuleConditionType: PathPattern, PathPatterns: []string{"/api/*"}}},
+						}},
+					}},
+				}},
+				LBTargetGroups: []LBTargetGroupParameters{{Meta: meta("web"), Port: 80, Protocol: TGProtoHTTP, TargetType: TGIp}},
+				ECSClusters: []ECSClusterParameters{{
+					Meta: meta("cluster"),
+					Services: []ECSServiceParameters{{
+						Meta:         meta("svc"),
+						DesiredCount: 1,
+						CPU:          256,
+						Memory:       512,
+						Containers: []ContainerDefinition{{
+							Name:         "app",
+							Image:        "nginx",
+							PortMappings: []ContainerPortMapping{{ContainerPort: 80, TargetGroupLookupName: "web"}},
+						}},
+					}},
+				}},
+			}},
+		}},
+	}
+}
+
+func vpc(p *InfraParameters) *VpcParameters { return &p.Vpcs[0] }
+
+func partition(p *InfraParameters) *NetworkPartitionParameters { return &p.Vpcs[0].Partitions[0] }
+
+func loadBalancer(p *InfraParameters) *LoadBalancerParameters { return &partition(p).LoadBalancers[0] }
+
+func listener(p *InfraParameters) *LBListenerParameters { return &loadBalancer(p).Listeners[0] }
+
+func rule(p *InfraParameters) *LBRuleParameters { return &listener(p).Rules[0] }
+
+func targetGroup(p *InfraParameters) *LBTargetGroupParameters { return &partition(p).LBTargetGroups[0] }
+
+func cluster(p *InfraParameters) *ECSClusterParameters { return &partition(p).ECSClusters[0] }
+
+func service(p *InfraParameters) *ECSServiceParameters { return &cluster(p).Services[0] }
+
+func container(p *InfraParameters) *ContainerDefinition { return &service(p).Containers[0] }
+
+// privatePartition adds a private partition with one subnet to the Vpc
+func privatePartition(p *InfraParameters) {
+	vpc(p).Partitions = append(vpc(p).Partitions, NetworkPartitionParameters{
+		Meta:    meta("private"),
+		Subnets: []SubnetParameters{{Meta: meta("c"), CidrBlock: "10.0.2.0/24"}},
+	})
+}
+
+// gatewayLB adds a gateway load balancer and its GENEVE target group to the partition
+func gatewayLB(p *InfraParameters) {
+	partition(p).LoadBalancers = append(partition(p).LoadBalancers, LoadBalancerParameters{
+		Meta:      meta("gwlb"),
+		Type:      Gateway,
+		Listeners: []LBListenerParameters{{Meta: meta("geneve"), TargetGroupLookupName: "appliances"}},
+	})
+	partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{
+		Meta: meta("appliances"), Port: 6081, Protocol: TGProtoGENEVE, TargetType: TGIp,
+	})
+}
+
+// gatewayEndpoint adds a valid gateway endpoint of the gateway load balancer to the Vpc
+func gatewayEndpoint(p *InfraParameters) *GatewayEndpointParameters {
+	gatewayLB(p)
+	privatePartition(p)
+	vpc(p).GatewayEndpoints = []GatewayEndpointParameters{{
+		Meta:                   meta("inspection"),
+		LoadBalancerLookupName: "gwlb",
+		PartitionLookupName:    "public",
+		SubnetLookupName:       "a",
+		Routes:                 []GatewayEndpointRoute{{PartitionLookupName: "private", DestinationCidrBlock: "10.1.0.0/16"}},
+	}}
+	return &vpc(p).GatewayEndpoints[0]
+}
+
+// zone adds a public hosted zone with an alias record of the load balancer to the Vpc
+func zone(p *InfraParameters) *HostedZoneParameters {
+	vpc(p).HostedZones = []HostedZoneParameters{{
+		Meta:    meta("zone"),
+		Domain:  "example.com",
+		Records: []AliasRecordParameters{{Meta: meta("api"), RecordName: "api.example.com", LoadBalancerLookupName: "lb"}},
+	}}
+	return &vpc(p).HostedZones[0]
+}
+
+// certificate adds a certificate validated by DNS in the hosted zone of the Vpc
+func certificate(p *InfraParameters) *CertificateParameters {
+	zone(p)
+	vpc(p).Certificates = []CertificateParameters{{
+		Meta: meta("cert"), Domain: "example.com", ValidationMethod: ValidationByDNS, HostedZoneLookupName: "zone",
+	}}
+	return &vpc(p).Certificates[0]
+}
+
+// https turns the listener of the load balancer into an HTTPS listener with a certificate
+func https(p *InfraParameters) *LBListenerParameters {
+	certificate(p)
+	listener(p).Port = 443
+	listener(p).Protocol = HTTPS
+	listener(p).CertificateLookupName = "cert"
+	return listener(p)
+}
+
+// oidc is a valid OpenID Connect authentication action
+func oidc() LBActionParameters {
+	return LBActionParameters{Type: ActionAuthenticateOidc, AuthenticateOidc: LBAuthenticateOidcAction{
+		Issuer:                "https://idp.example.com",
+		AuthorizationEndpoint: "https://idp.example.com/authorize",
+		TokenEndpoint:         "https://idp.example.com/token",
+		UserInfoEndpoint:      "https://idp.example.com/userinfo",
+		ClientID:              "client",
+		ClientSecret:          pulumi.String("secret"),
+	}}
+}
+
+func forward(names ...string) LBActionParameters {
+	action := LBActionParameters{Type: ActionForward}
+	for _, name := range names {
+		action.Forward.TargetGroups = append(action.Forward.TargetGroups, LBWeightedTargetGroup{TargetGroupLookupName: name, Weight: 1})
+	}
+	return action
+}
+
+// ec2Capacity adds an EC2 capacity provider to the cluster and runs the service on it
+func ec2Capacity(p *InfraParameters) *CapacityProviderParameters {
+	cluster(p).CapacityProviders = []CapacityProviderParameters{{
+		Meta: meta("instances"),
+		Type: EC2Capacity,
+		EC2:  EC2CapacityParameters{InstanceTypes: []string{"m6i.large"}, MaxSize: 2},
+	}}
+	service(p).CapacityProviderStrategy = []CapacityProviderStrategyItem{{CapacityProviderLookupName: "instances", Weight: 1}}
+	return &cluster(p).CapacityProviders[0]
+}
+
+// autoScaling scales the service between 1 and 4 tasks
+func autoScaling(p *InfraParameters) *ServiceAutoScalingParameters {
+	service(p).AutoScaling = &ServiceAutoScalingParameters{MinCapacity: 1, MaxCapacity: 4}
+	return service(p).AutoScaling
+}
+
+// stepScaling adds a valid step scaling policy to the auto scaling of the service
+func stepScaling(p *InfraParameters) *StepScalingParameters {
+	zero := 0.0
+	autoScaling(p).StepScaling = []StepScalingParameters{{
+		Name: "queue",
+		Alarm: ScalingAlarmParameters{
+			Namespace: "AWS/SQS", MetricName: "ApproximateNumberOfMessagesVisible", ComparisonOperator: "GreaterThanThreshold", Threshold: 100,
+		},
+		Steps: []ScalingStep{{LowerBound: &zero, Adjustment: 1}},
+	}}
+	return &service(p).AutoScaling.StepScaling[0]
+}
+
+// blueGreen deploys the service in the blue/green mode behind the listener of the load balancer
+func blueGreen(p *InfraParameters) *BlueGreenParameters {
+	service(p).Deployment.BlueGreen = &BlueGreenParameters{
+		TargetGroupLookupName: "web", LoadBalancerLookupName: "lb", ListenerLookupName: "http", TestListenerPort: 8080,
+	}
+	return service(p).Deployment.BlueGreen
+}
+
+// namespace gives the cluster a namespace
+func namespace(p *InfraParameters) {
+	cluster(p).Namespace = &NamespaceParameters{Meta: meta("ns"), Domain: "internal.local"}
+}
+
+// secret adds a secret of Secrets Manager to the container
+func secret(p *InfraParameters) *ContainerSecret {
+	container(p).Secrets = []ContainerSecret{{Name: "DB_PASSWORD", Source: SecretsManager, Value: pulumi.String("password")}}
+	return &container(p).Secrets[0]
+}
+
+func intPtr(i int) *int { return &i }
+
+// validationErrors returns the validation errors joined in err
+func validationErrors(t *testing.T, err error) (errs []*ValidationError) {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected joined errors, got %T: %v", err, err)
+	}
+	for _, err := range joined.Unwrap() {
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected a validation error, got %T: %v", err, err)
+		}
+		errs = append(errs, validationErr)
+	}
+	return
+}
+
+func TestValidInfra(t *testing.T) {
+	valid := []struct {
+		name   string
+		change func(p *InfraParameters)
+	}{
+		{name: "base", change: func(p *InfraParameters) {}},
+		{name: "https listener", change: func(p *InfraParameters) { https(p) }},
+		{name: "gateway endpoint", change: func(p *InfraParameters) { gatewayEndpoint(p) }},
+		{name: "private partition with a NAT gateway", change: func(p *InfraParameters) {
+			privatePartition(p)
+			vpc(p).NatMode = NatSingle
+		}},
+		{name: "authenticated listener", change: func(p *InfraParameters) {
+			https(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{oidc(), forward("web")}
+		}},
+		{name: "ec2 capacity", change: func(p *InfraParameters) { ec2Capacity(p) }},
+		{name: "step scaling", change: func(p *InfraParameters) { stepScaling(p) }},
+		{name: "blue/green", change: func(p *InfraParameters) { blueGreen(p) }},
+		{name: "secret", change: func(p *InfraParameters) { secret(p) }},
+		{name: "logs", change: func(p *InfraParameters) {
+			service(p).Logs = LogParameters{Enabled: true, RetentionInDays: 7, FireLens: &FireLensParameters{Options: map[string]string{"Name": "cloudwatch_logs"}}}
+		}},
+		{name: "service discovery", change: func(p *InfraParameters) {
+			namespace(p)
+			container(p).PortMappings[0].Name = "http"
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{RecordTypes: []DNSRecordType{RecordSRV}, PortName: "http"}
+			service(p).ServiceConnect = &ServiceConnectParameters{Services: []ServiceConnectService{{PortName: "http"}}}
+		}},
+	}
+	for _, test := range valid {
+		p := validInfra()
+		test.change(&p)
+		if err := p.Validate(); err != nil {
+			t.Errorf("%s: expected valid parameters, got %v", test.name, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(p *InfraParameters)
+		path    string
+		message string
+	}{
+		//Infra
+		{"infra name", func(p *InfraParameters) { p.Name = "" }, "Name", "name is required"},
+		{"workers", func(p *InfraParameters) { p.Workers = -1 }, "Workers", "workers cannot be negative"},
+		{"duplicate vpc", func(p *InfraParameters) { p.Vpcs = append(p.Vpcs, p.Vpcs[0]) }, "Vpcs[1]", `name "vpc" is already used by Vpcs[0]`},
+
+		//Vpc
+		{"vpc name", func(p *InfraParameters) { vpc(p).Name = "" }, vpcPath + ".Name", "name is required"},
+		{"provider name", func(p *InfraParameters) { vpc(p).Provider.Name = "" }, vpcPath + ".Provider.Name", "name is required"},
+		{"region", func(p *InfraParameters) { vpc(p).Provider.Region = "" }, vpcPath + ".Provider.Region", "region is required"},
+		{"vpc cidr", func(p *InfraParameters) { vpc(p).CidrBlock = "10.0.0.0" }, vpcPath + ".CidrBlock", `invalid cidr block "10.0.0.0"`},
+		{"nat without public subnets", func(p *InfraParameters) {
+			privatePartition(p)
+			partition(p).Subnets = nil
+			partition(p).LoadBalancers = nil
+			partition(p).ECSClusters = nil
+			vpc(p).NatMode = NatPerAZ
+		}, vpcPath + ".NatMode", "need a public partition with subnets"},
+		{"nat mode", func(p *InfraParameters) { vpc(p).NatMode = "many" }, vpcPath + ".NatMode", `unknown NAT mode "many"`},
+		{"duplicate partition", func(p *InfraParameters) {
+			privatePartition(p)
+			vpc(p).Partitions[1].Name = "public"
+		}, vpcPath + ".Partitions[1]", `name "public" is already used by Partitions[0]`},
+		{"subnet outside the vpc", func(p *InfraParameters) { partition(p).Subnets[1].CidrBlock = "10.1.0.0/24" }, partitionPath + ".Subnets[1].CidrBlock", "is outside the cidr block of the vpc"},
+		{"overlapping subnets", func(p *InfraParameters) { partition(p).Subnets[1].CidrBlock = "10.0.0.128/25" }, partitionPath + ".Subnets[1].CidrBlock", "overlaps 10.0.0.0/24 of " + partitionPath + ".Subnets[0]"},
+		{"subnet cidr", func(p *InfraParameters) { partition(p).Subnets[1].CidrBlock = "subnet" }, partitionPath + ".Subnets[1].CidrBlock", `invalid cidr block "subnet"`},
+		{"duplicate subnet", func(p *InfraParameters) { partition(p).Subnets[1].Name = "a" }, partitionPath + ".Subnets[1]", `name "a" is already used by Subnets[0]`},
+
+		//Certificates and hosted zones
+		{"certificate zone not found", func(p *InfraParameters) { certificate(p).HostedZoneLookupName = "other" }, vpcPath + ".Certificates[0].HostedZoneLookupName", `hosted zone "other" not found in the vpc`},
+		{"certificate private zone", func(p *InfraParameters) {
+			certificate(p)
+			vpc(p).HostedZones[0].Private = true
+		}, vpcPath + ".Certificates[0].HostedZoneLookupName", `hosted zone "zone" is private`},
+		{"certificate domain", func(p *InfraParameters) { certificate(p).Domain = "" }, vpcPath + ".Certificates[0].Domain", "domain is required"},
+		{"certificate validation method", func(p *InfraParameters) { certificate(p).ValidationMethod = "HTTP" }, vpcPath + ".Certificates[0].ValidationMethod", `validation method must be DNS or EMAIL, got "HTTP"`},
+		{"certificate blank name", func(p *InfraParameters) { certificate(p).SubjectAlternativeNames = []string{""} }, vpcPath + ".Certificates[0].SubjectAlternativeNames[0]", "the name is blank"},
+		{"certificate both zones", func(p *InfraParameters) { certificate(p).HostedZoneName = "example.com" }, vpcPath + ".Certificates[0].HostedZoneLookupName", "set either a hosted zone lookup name or a hosted zone name"},
+		{"certificate zone by email", func(p *InfraParameters) { certificate(p).ValidationMethod = ValidationByEmail }, vpcPath + ".Certificates[0].ValidationMethod", "hosted zones are only used by the DNS validation method"},
+		{"duplicate certificate", func(p *InfraParameters) { vpc(p).Certificates = append(vpc(p).Certificates, *certificate(p)) }, vpcPath + ".Certificates[1]", `name "cert" is already used by Certificates[0]`},
+		{"zone domain", func(p *InfraParameters) { zone(p).Domain = "" }, vpcPath + ".HostedZones[0].Domain", "domain is required"},
+		{"record outside the zone", func(p *InfraParameters) { zone(p).Records[0].RecordName = "api.example.org" }, vpcPath + ".HostedZones[0].Records[0].RecordName", `"api.example.org" is outside the domain of the zone "example.com"`},
+		{"record load balancer not found", func(p *InfraParameters) { zone(p).Records[0].LoadBalancerLookupName = "other" }, vpcPath + ".HostedZones[0].Records[0].LoadBalancerLookupName", `load balancer "other" not found in the vpc`},
+		{"record name", func(p *InfraParameters) { zone(p).Records[0].RecordName = "" }, vpcPath + ".HostedZones[0].Records[0].RecordName", "record name is required"},
+		{"record load balancer", func(p *InfraParameters) { zone(p).Records[0].LoadBalancerLookupName = "" }, vpcPath + ".HostedZones[0].Records[0].LoadBalancerLookupName", "a load balancer is required"},
+		{"record type", func(p *InfraParameters) { zone(p).Records[0].Types = []DNSRecordType{RecordSRV} }, vpcPath + ".HostedZones[0].Records[0].Types[0]", `record type must be A or AAAA, got "SRV"`},
+		{"record set identifier", func(p *InfraParameters) { zone(p).Records[0].SetIdentifier = "blue" }, vpcPath + ".HostedZones[0].Records[0].SetIdentifier", "set identifiers are only used by the weighted and failover routing policies"},
+		{"record weight range", func(p *InfraParameters) {
+			record := &zone(p).Records[0]
+			record.RoutingPolicy, record.SetIdentifier, record.Weight = WeightedRouting, "blue", 256
+		}, vpcPath + ".HostedZones[0].Records[0].Weight", "weight must be between 0 and 255, got 256"},
+		{"record failover role", func(p *InfraParameters) {
+			record := &zone(p).Records[0]
+			record.RoutingPolicy, record.SetIdentifier, record.Failover = FailoverRouting, "blue", "TERTIARY"
+		}, vpcPath + ".HostedZones[0].Records[0].Failover", `failover must be PRIMARY or SECONDARY, got "TERTIARY"`},
+		{"record routing policy", func(p *InfraParameters) { zone(p).Records[0].RoutingPolicy = "latency" }, vpcPath + ".HostedZones[0].Records[0].RoutingPolicy", `unknown routing policy "latency"`},
+		{"record without set identifier", func(p *InfraParameters) { zone(p).Records[0].RoutingPolicy = WeightedRouting }, vpcPath + ".HostedZones[0].Records[0].SetIdentifier", "the weighted routing policy needs a set identifier"},
+		{"record weight without weighted routing", func(p *InfraParameters) { zone(p).Records[0].Weight = 10 }, vpcPath + ".HostedZones[0].Records[0].Weight", "weights are only used by the weighted routing policy"},
+		{"record failover without failover routing", func(p *InfraParameters) { zone(p).Records[0].Failover = FailoverPrimary }, vpcPath + ".HostedZones[0].Records[0].Failover", "failover roles are only used by the failover routing policy"},
+
+		//Gateway endpoints
+		{"endpoint load balancer not found", func(p *InfraParameters) { gatewayEndpoint(p).LoadBalancerLookupName = "other" }, vpcPath + ".GatewayEndpoints[0].LoadBalancerLookupName", `load balancer "other" not found in the vpc`},
+		{"endpoint load balancer type", func(p *InfraParameters) { gatewayEndpoint(p).LoadBalancerLookupName = "lb" }, vpcPath + ".GatewayEndpoints[0].LoadBalancerLookupName", `gateway endpoints use gateway load balancers, "lb" has the application type`},
+		{"endpoint partition", func(p *InfraParameters) { gatewayEndpoint(p).PartitionLookupName = "other" }, vpcPath + ".GatewayEndpoints[0].PartitionLookupName", `partition "other" not found in the vpc`},
+		{"endpoint subnet", func(p *InfraParameters) { gatewayEndpoint(p).SubnetLookupName = "other" }, vpcPath + ".GatewayEndpoints[0].SubnetLookupName", `subnet "other" not found in the partition "public"`},
+		{"route partition", func(p *InfraParameters) { gatewayEndpoint(p).Routes[0].PartitionLookupName = "other" }, vpcPath + ".GatewayEndpoints[0].Routes[0].PartitionLookupName", `partition "other" not found in the vpc`},
+		{"route destination", func(p *InfraParameters) { gatewayEndpoint(p).Routes[0].DestinationCidrBlock = "anywhere" }, vpcPath + ".GatewayEndpoints[0].Routes[0].DestinationCidrBlock", `invalid cidr block "anywhere"`},
+		{"route public partition", func(p *InfraParameters) { gatewayEndpoint(p).Routes[0].PartitionLookupName = "public" }, vpcPath + ".GatewayEndpoints[0].Routes[0].PartitionLookupName", `the partition "public" is public and shares the route table of the internet gateway`},
+		{"route default", func(p *InfraParameters) {
+			gatewayEndpoint(p).Routes[0].DestinationCidrBlock = "0.0.0.0/0"
+			vpc(p).NatMode = NatSingle
+		}, vpcPath + ".GatewayEndpoints[0].Routes[0].DestinationCidrBlock", `the partition "private" already routes 0.0.0.0/0 to its NAT gateway`},
+		{"duplicate route", func(p *InfraParameters) {
+			endpoint := gatewayEndpoint(p)
+			endpoint.Routes = append(endpoint.Routes, endpoint.Routes[0])
+		}, vpcPath + ".GatewayEndpoints[0].Routes[1]", "the route is already set by Routes[0]"},
+
+		//Partition
+		{"partition name", func(p *InfraParameters) { partition(p).Name = "" }, partitionPath + ".Name", "name is required"},
+		{"listener target group not found", func(p *InfraParameters) { listener(p).TargetGroupLookupName = "other" }, listenerPath + ".TargetGroupLookupName", `target group "other" not found in the partition`},
+		{"rule target group not found", func(p *InfraParameters) { rule(p).TargetGroupLookupName = "other" }, rulePath + ".TargetGroupLookupName", `target group "other" not found in the partition`},
+		{"action target group not found", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{forward("other")}
+		}, listenerPath + ".Actions[0].Forward.TargetGroups[0].TargetGroupLookupName", `target group "other" not found in the partition`},
+		{"application load balancer subnets", func(p *InfraParameters) { partition(p).Subnets = partition(p).Subnets[:1] }, lbPath, "application load balancers need at least two subnets"},
+		{"listener protocol and target group", func(p *InfraParameters) { targetGroup(p).Protocol = TGProtoTCP }, listenerPath, `HTTP listeners can't forward to the target group "web" of protocol TCP and target type ip`},
+		{"alb target group load balancer not found", func(p *InfraParameters) {
+			*targetGroup(p) = LBTargetGroupParameters{Meta: meta("web"), Port: 80, Protocol: TGProtoTCP, TargetType: TGAlb, LoadBalancerLookupName: "other"}
+			partition(p).LoadBalancers = nil
+			partition(p).ECSClusters = nil
+		}, tgPath + ".LoadBalancerLookupName", `load balancer "other" not found in the partition`},
+		{"alb target group load balancer type", func(p *InfraParameters) {
+			gatewayLB(p)
+			*targetGroup(p) = LBTargetGroupParameters{Meta: meta("web"), Port: 80, Protocol: TGProtoTCP, TargetType: TGAlb, LoadBalancerLookupName: "gwlb"}
+			partition(p).LoadBalancers = partition(p).LoadBalancers[1:]
+			partition(p).ECSClusters = nil
+		}, tgPath + ".LoadBalancerLookupName", `alb target groups register application load balancers, "gwlb" is a gateway load balancer`},
+		{"alb target group port", func(p *InfraParameters) {
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{
+				Meta: meta("alb"), Port: 443, Protocol: TGProtoTCP, TargetType: TGAlb, LoadBalancerLookupName: "lb",
+			})
+		}, partitionPath + ".LBTargetGroups[1].LoadBalancerLookupName", `load balancer "lb" has no listener on the port 443 of the target group`},
+		{"service subnets", func(p *InfraParameters) {
+			partition(p).Subnets = nil
+			partition(p).LoadBalancers = nil
+		}, servicePath, "services need at least one subnet in the partition"},
+		{"port mapping target group not found", func(p *InfraParameters) { container(p).PortMappings[0].TargetGroupLookupName = "other" }, containerPath + ".PortMappings[0].TargetGroupLookupName", `target group "other" not found in the partition`},
+		{"fargate target type", func(p *InfraParameters) { targetGroup(p).TargetType = TGInstance }, containerPath + ".PortMappings[0].TargetGroupLookupName", `fargate tasks are registered in ip target groups, "web" has the instance target type`},
+		{"request count target group not forwarded", func(p *InfraParameters) {
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{Meta: meta("idle"), Port: 80, Protocol: TGProtoHTTP})
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "requests", Metric: ScaleOnRequestCount, TargetValue: 100, TargetGroupLookupName: "idle"}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].TargetGroupLookupName", `no application load balancer of the partition forwards to the target group "idle"`},
+		{"duplicate target group", func(p *InfraParameters) {
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, *targetGroup(p))
+		}, partitionPath + ".LBTargetGroups[1]", `name "web" is already used by LBTargetGroups[0]`},
+		{"duplicate load balancer", func(p *InfraParameters) {
+			partition(p).LoadBalancers = append(partition(p).LoadBalancers, *loadBalancer(p))
+		}, partitionPath + ".LoadBalancers[1]", `name "lb" is already used by LoadBalancers[0]`},
+		{"duplicate cluster", func(p *InfraParameters) {
+			partition(p).ECSClusters = append(partition(p).ECSClusters, ECSClusterParameters{Meta: meta("cluster")})
+		}, partitionPath + ".ECSClusters[1]", `name "cluster" is already used by ECSClusters[0]`},
+
+		//Load balancers
+		{"load balancer name", func(p *InfraParameters) { loadBalancer(p).Name = "" }, lbPath + ".Name", "name is required"},
+		{"classic load balancer", func(p *InfraParameters) { loadBalancer(p).Type = Classic }, lbPath + ".Type", "classic load balancers are not supported"},
+		{"load balancer type", func(p *InfraParameters) { loadBalancer(p).Type = "magic" }, lbPath + ".Type", `unsupported load balancer type "magic"`},
+		{"cross zone", func(p *InfraParameters) { loadBalancer(p).CrossZone = true }, lbPath, "cross zone load balancing and elastic ips are only set on network load balancers"},
+		{"internal elastic ips", func(p *InfraParameters) {
+			loadBalancer(p).ElasticIPs = true
+			loadBalancer(p).IsInternal = true
+		}, lbPath + ".ElasticIPs", "internal load balancers have no elastic ips"},
+		{"endpoint service", func(p *InfraParameters) { loadBalancer(p).EndpointService.AcceptanceRequired = true }, lbPath + ".EndpointService", "endpoint services are only created for gateway load balancers"},
+		{"layer 4 listener on application load balancer", func(p *InfraParameters) {
+			listener(p).Protocol = TCP
+			listener(p).Rules = nil
+			targetGroup(p).Protocol = TGProtoTCP
+		}, listenerPath + ".Protocol", "TCP listeners are not supported by application load balancers"},
+		{"network load balancer rules", func(p *InfraParameters) {
+			loadBalancer(p).Type = Network
+			listener(p).Protocol = TCP
+			targetGroup(p).Protocol = TGProtoTCP
+		}, listenerPath + ".Rules", "network load balancers have no listener rules"},
+		{"network load balancer actions", func(p *InfraParameters) {
+			loadBalancer(p).Type = Network
+			listener(p).Protocol = TCP
+			listener(p).Rules = nil
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionFixedResponse, FixedResponse: LBFixedResponseAction{StatusCode: 200}}}
+		}, listenerPath + ".Actions[0]", "network load balancers only forward to one target group"},
+		{"duplicate port", func(p *InfraParameters) {
+			lb := loadBalancer(p)
+			lb.Listeners = append(lb.Listeners, LBListenerParameters{Meta: meta("other"), Port: 80, Protocol: HTTP, TargetGroupLookupName: "web"})
+		}, lbPath + ".Listeners[1].Port", "port 80 is already used by Listeners[0]"},
+		{"duplicate listener", func(p *InfraParameters) {
+			lb := loadBalancer(p)
+			lb.Listeners = append(lb.Listeners, LBListenerParameters{Meta: meta("http"), Port: 8080, Protocol: HTTP, TargetGroupLookupName: "web"})
+		}, lbPath + ".Listeners[1]", `name "http" is already used by Listeners[0]`},
+
+		//Gateway load balancers
+		{"gateway listeners", func(p *InfraParameters) {
+			gatewayLB(p)
+			lb := &partition(p).LoadBalancers[1]
+			lb.Listeners = append(lb.Listeners, LBListenerParameters{Meta: meta("other"), TargetGroupLookupName: "appliances"})
+		}, partitionPath + ".LoadBalancers[1].Listeners", "gateway load balancers have one listener"},
+		{"gateway listener port", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LoadBalancers[1].Listeners[0].Port = 6081
+		}, partitionPath + ".LoadBalancers[1].Listeners[0]", "the listeners of gateway load balancers have no port or protocol"},
+		{"gateway listener rules", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LoadBalancers[1].Listeners[0].RedirectToHTTPS = true
+		}, partitionPath + ".LoadBalancers[1].Listeners[0]", "the listeners of gateway load balancers only forward to a target group"},
+		{"gateway listener actions and target group", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LoadBalancers[1].Listeners[0].Actions = []LBActionParameters{forward("appliances")}
+		}, partitionPath + ".LoadBalancers[1].Listeners[0].Actions", "actions replace the target group lookup name, set only one of them"},
+		{"gateway listener forward", func(p *InfraParameters) {
+			gatewayLB(p)
+			gateway := &partition(p).LoadBalancers[1].Listeners[0]
+			gateway.TargetGroupLookupName = ""
+			gateway.Actions = []LBActionParameters{forward("appliances", "appliances")}
+		}, partitionPath + ".LoadBalancers[1].Listeners[0].Actions[0]", "gateway load balancers only forward to one target group"},
+		{"gateway listener target group", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LoadBalancers[1].Listeners[0].TargetGroupLookupName = ""
+		}, partitionPath + ".LoadBalancers[1].Listeners[0].TargetGroupLookupName", "target group lookup name is required"},
+
+		//Listeners
+		{"listener name", func(p *InfraParameters) { listener(p).Name = "" }, listenerPath + ".Name", "name is required"},
+		{"listener port", func(p *InfraParameters) { listener(p).Port = 0 }, listenerPath + ".Port", "port must be between 1 and 65535, got 0"},
+		{"listener protocol", func(p *InfraParameters) { listener(p).Protocol = "FTP" }, listenerPath + ".Protocol", `unsupported protocol "FTP"`},
+		{"alpn policy", func(p *InfraParameters) { listener(p).AlpnPolicy = "HTTP3" }, listenerPath + ".AlpnPolicy", `unsupported alpn policy "HTTP3"`},
+		{"alpn policy protocol", func(p *InfraParameters) { listener(p).AlpnPolicy = AlpnNone }, listenerPath + ".AlpnPolicy", "alpn policies are only used by TLS listeners"},
+		{"https certificate", func(p *InfraParameters) { https(p).CertificateLookupName = "" }, listenerPath + ".CertificateLookupName", "HTTPS listeners need a certificate"},
+		{"certificate on http", func(p *InfraParameters) { listener(p).SslPolicy = DefaultSslPolicy }, listenerPath + ".Protocol", "certificates and ssl policies need the HTTPS or TLS protocol"},
+		{"certificate not found", func(p *InfraParameters) { https(p).CertificateLookupName = "other" }, listenerPath + ".CertificateLookupName", `certificate "other" not found in the vpc`},
+		{"sni certificate not found", func(p *InfraParameters) { https(p).SniCertificateLookupNames = []string{"other"} }, listenerPath + ".SniCertificateLookupNames[0]", `certificate "other" not found in the vpc`},
+		{"actions and target group", func(p *InfraParameters) { listener(p).Actions = []LBActionParameters{forward("web")} }, listenerPath + ".Actions", "actions replace the target group lookup name and the redirect to HTTPS"},
+		{"redirect to https", func(p *InfraParameters) {
+			https(p).RedirectToHTTPS = true
+			listener(p).TargetGroupLookupName = ""
+		}, listenerPath + ".RedirectToHTTPS", "only HTTP listeners on a port other than 443 can redirect to HTTPS"},
+		{"listener target group", func(p *InfraParameters) { listener(p).TargetGroupLookupName = "" }, listenerPath + ".TargetGroupLookupName", "target group lookup name is required"},
+		{"authenticate on http", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{oidc(), forward("web")}
+		}, listenerPath + ".Actions", "authenticate actions need an HTTPS listener"},
+		{"authenticate rule on http", func(p *InfraParameters) {
+			rule(p).TargetGroupLookupName = ""
+			rule(p).Actions = []LBActionParameters{oidc(), forward("web")}
+		}, rulePath + ".Actions", "authenticate actions need an HTTPS listener"},
+		{"duplicate priority", func(p *InfraParameters) {
+			listener(p).Rules = append(listener(p).Rules, *rule(p))
+			listener(p).Rules[1].Name = "other"
+		}, listenerPath + ".Rules[1].Priority", "priority 10 is already used by Rules[0]"},
+		{"duplicate rule", func(p *InfraParameters) {
+			listener(p).Rules = append(listener(p).Rules, *rule(p))
+			listener(p).Rules[1].Priority = 20
+		}, listenerPath + ".Rules[1]", `name "api" is already used by Rules[0]`},
+
+		//Rules
+		{"rule name", func(p *InfraParameters) { rule(p).Name = "" }, rulePath + ".Name", "name is required"},
+		{"rule priority", func(p *InfraParameters) { rule(p).Priority = 0 }, rulePath + ".Priority", "priority must be between 1 and 50000, got 0"},
+		{"rule actions and target group", func(p *InfraParameters) { rule(p).Actions = []LBActionParameters{forward("web")} }, rulePath + ".Actions", "actions replace the target group lookup name, set only one of them"},
+		{"rule target group", func(p *InfraParameters) { rule(p).TargetGroupLookupName = "" }, rulePath + ".TargetGroupLookupName", "target group lookup name is required"},
+		{"rule conditions", func(p *InfraParameters) { rule(p).Conditions = nil }, rulePath + ".Conditions", "at least one condition is required"},
+		{"source ip", func(p *InfraParameters) {
+			rule(p).Conditions = []LBRuleConditionParameters{{RuleConditionType: SourceIP, SourceIPs: []string{"10.0.0.1"}}}
+		}, rulePath + ".Conditions[0].SourceIPs[0]", `invalid cidr block "10.0.0.1"`},
+		{"condition type", func(p *InfraParameters) { rule(p).Conditions[0].RuleConditionType = 42 }, rulePath + ".Conditions[0].RuleConditionType", "unknown condition type 42"},
+		{"empty condition", func(p *InfraParameters) {
+			rule(p).Conditions[0] = LBRuleConditionParameters{RuleConditionType: HTTPHeader}
+		}, rulePath + ".Conditions[0]", "the condition has no values"},
+
+		//Actions
+		{"last action", func(p *InfraParameters) {
+			https(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{oidc()}
+		}, listenerPath + ".Actions[0].Type", "the last action must forward, redirect or answer a fixed response"},
+		{"action before the last", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{forward("web"), forward("web")}
+		}, listenerPath + ".Actions[0].Type", "only the last action can be a forward action"},
+		{"forward target groups", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{forward()}
+		}, listenerPath + ".Actions[0].Forward.TargetGroups", "forward actions need between 1 and 5 target groups, got 0"},
+		{"forward target group", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{forward("")}
+		}, listenerPath + ".Actions[0].Forward.TargetGroups[0].TargetGroupLookupName", "TargetGroupLookupName is required"},
+		{"forward weight", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{forward("web")}
+			listener(p).Actions[0].Forward.TargetGroups[0].Weight = 1000
+		}, listenerPath + ".Actions[0].Forward.TargetGroups[0].Weight", "weight must be between 0 and 999, got 1000"},
+		{"forward weights", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{forward("web", "web")}
+			listener(p).Actions[0].Forward.TargetGroups[0].Weight = 0
+			listener(p).Actions[0].Forward.TargetGroups[1].Weight = 0
+		}, listenerPath + ".Actions[0].Forward.TargetGroups", "at least one target group needs a weight"},
+		{"forward stickiness", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{forward("web")}
+			listener(p).Actions[0].Forward.StickinessDuration = 604801
+		}, listenerPath + ".Actions[0].Forward.StickinessDuration", "stickiness must last between 1 second and 7 days, got 604801 seconds"},
+		{"fixed response status code", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionFixedResponse, FixedResponse: LBFixedResponseAction{StatusCode: 301}}}
+		}, listenerPath + ".Actions[0].FixedResponse.StatusCode", "status code must be 2XX, 4XX or 5XX, got 301"},
+		{"fixed response content type", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionFixedResponse, FixedResponse: LBFixedResponseAction{StatusCode: 503, ContentType: "image/png"}}}
+		}, listenerPath + ".Actions[0].FixedResponse.ContentType", `unsupported content type "image/png"`},
+		{"fixed response body", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionFixedResponse, FixedResponse: LBFixedResponseAction{StatusCode: 503, MessageBody: strings.Repeat("a", 1025)}}}
+		}, listenerPath + ".Actions[0].FixedResponse.MessageBody", "the message body is longer than 1024 characters"},
+		{"empty redirect", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionRedirect}}
+		}, listenerPath + ".Actions[0].Redirect", "a redirect needs a protocol, a host, a port, a path or a query"},
+		{"redirect protocol", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionRedirect, Redirect: LBRedirectAction{Protocol: "FTP"}}}
+		}, listenerPath + ".Actions[0].Redirect.Protocol", `must be HTTP, HTTPS or #{protocol}, got "FTP"`},
+		{"oidc issuer", func(p *InfraParameters) {
+			https(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{oidc(), forward("web")}
+			listener(p).Actions[0].AuthenticateOidc.Issuer = ""
+		}, listenerPath + ".Actions[0].AuthenticateOidc.Issuer", "Issuer is required"},
+		{"oidc client secret", func(p *InfraParameters) {
+			https(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{oidc(), forward("web")}
+			listener(p).Actions[0].AuthenticateOidc.ClientSecret = nil
+		}, listenerPath + ".Actions[0].AuthenticateOidc.ClientSecret", "ClientSecret is required"},
+		{"oidc unauthenticated request", func(p *InfraParameters) {
+			https(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{oidc(), forward("web")}
+			listener(p).Actions[0].AuthenticateOidc.OnUnauthenticatedRequest = "redirect"
+		}, listenerPath + ".Actions[0].AuthenticateOidc.OnUnauthenticatedRequest", `must be deny, allow or authenticate, got "redirect"`},
+		{"cognito user pool", func(p *InfraParameters) {
+			https(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionAuthenticateCognito}, forward("web")}
+		}, listenerPath + ".Actions[0].AuthenticateCognito.UserPoolArn", "UserPoolArn is required"},
+		{"action type", func(p *InfraParameters) {
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: "drop"}}
+		}, listenerPath + ".Actions[0].Type", `unknown action type "drop"`},
+
+		//Target groups
+		{"target group name", func(p *InfraParameters) { targetGroup(p).Name = "" }, tgPath + ".Name", "name is required"},
+		{"geneve port", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LBTargetGroups[1].Port = 6080
+		}, partitionPath + ".LBTargetGroups[1].Port", "GENEVE target groups use the port 6081, got 6080"},
+		{"geneve target type", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LBTargetGroups[1].TargetType = TGAlb
+		}, partitionPath + ".LBTargetGroups[1].TargetType", "GENEVE target groups register ip addresses or instances"},
+		{"target group protocol", func(p *InfraParameters) { targetGroup(p).Protocol = "FTP" }, tgPath + ".Protocol", `unsupported protocol "FTP"`},
+		{"alb target group protocol", func(p *InfraParameters) {
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{
+				Meta: meta("alb"), Port: 80, Protocol: TGProtoHTTP, TargetType: TGAlb, LoadBalancerLookupName: "lb",
+			})
+		}, partitionPath + ".LBTargetGroups[1].Protocol", "alb target groups use the TCP protocol"},
+		{"alb target group load balancer", func(p *InfraParameters) { targetGroup(p).LoadBalancerLookupName = "lb" }, tgPath + ".LoadBalancerLookupName", "alb target groups need a load balancer lookup name, and only them"},
+		{"lambda port", func(p *InfraParameters) {
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{Meta: meta("lambda"), Port: 80, TargetType: TGLambda})
+		}, partitionPath + ".LBTargetGroups[1]", "lambda target groups have no port or protocol"},
+		{"lambda deregistration delay", func(p *InfraParameters) {
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{Meta: meta("lambda"), TargetType: TGLambda, DeregistrationDelay: 10})
+		}, partitionPath + ".LBTargetGroups[1]", "lambda target groups have no deregistration delay, health check port or health check protocol"},
+		{"target type", func(p *InfraParameters) { targetGroup(p).TargetType = "pod" }, tgPath + ".TargetType", `unsupported target type "pod"`},
+		{"protocol version", func(p *InfraParameters) { targetGroup(p).ProtocolVersion = "HTTP3" }, tgPath + ".ProtocolVersion", `unsupported protocol version "HTTP3"`},
+		{"protocol version protocol", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LBTargetGroups[1].ProtocolVersion = TGHTTP2
+		}, partitionPath + ".LBTargetGroups[1].ProtocolVersion", "protocol versions are only used by HTTP and HTTPS target groups"},
+		{"algorithm", func(p *InfraParameters) { targetGroup(p).Algorithm = "random" }, tgPath + ".Algorithm", `unsupported algorithm "random"`},
+		{"algorithm protocol", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LBTargetGroups[1].Algorithm = RoundRobin
+		}, partitionPath + ".LBTargetGroups[1].Algorithm", "algorithms are only used by HTTP and HTTPS target groups"},
+		{"slow start range", func(p *InfraParameters) { targetGroup(p).SlowStart = 10 }, tgPath + ".SlowStart", "slow start must be between 30 and 900 seconds, got 10"},
+		{"slow start protocol", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LBTargetGroups[1].SlowStart = 30
+		}, partitionPath + ".LBTargetGroups[1].SlowStart", "slow start is only used by HTTP and HTTPS target groups"},
+		{"slow start algorithm", func(p *InfraParameters) {
+			targetGroup(p).SlowStart = 30
+			targetGroup(p).Algorithm = LeastOutstandingRequests
+		}, tgPath + ".SlowStart", "slow start can't be used with the least_outstanding_requests algorithm"},
+		{"deregistration delay", func(p *InfraParameters) { targetGroup(p).DeregistrationDelay = 3601 }, tgPath + ".DeregistrationDelay", "deregistration delay must be between 0 and 3600 seconds, got 3601"},
+
+		//Stickiness
+		{"stickiness type", func(p *InfraParameters) { targetGroup(p).Stickiness.Duration = 60 }, tgPath + ".Stickiness.Type", "a stickiness type is required"},
+		{"cookie stickiness protocol", func(p *InfraParameters) {
+			gatewayLB(p)
+			partition(p).LBTargetGroups[1].Stickiness.Type = StickyLBCookie
+		}, partitionPath + ".LBTargetGroups[1].Stickiness.Type", "lb_cookie stickiness is only used by HTTP and HTTPS target groups"},
+		{"source ip stickiness protocol", func(p *InfraParameters) { targetGroup(p).Stickiness.Type = StickySourceIP }, tgPath + ".Stickiness.Type", "source_ip stickiness is only used by TCP, UDP and TLS target groups"},
+		{"unsupported stickiness", func(p *InfraParameters) { targetGroup(p).Stickiness.Type = "header" }, tgPath + ".Stickiness.Type", `unsupported stickiness type "header"`},
+		{"stickiness duration", func(p *InfraParameters) {
+			targetGroup(p).Stickiness = LBTargetGroupStickiness{Type: StickyLBCookie, Duration: 604801}
+		}, tgPath + ".Stickiness.Duration", "duration must be between 1 and 604800 seconds, got 604801"},
+		{"stickiness duration without cookie", func(p *InfraParameters) {
+			targetGroup(p).Protocol = TGProtoTCP
+			targetGroup(p).Stickiness = LBTargetGroupStickiness{Type: StickySourceIP, Duration: 60}
+			listener(p).Protocol = TCP
+			loadBalancer(p).Type = Network
+			listener(p).Rules = nil
+		}, tgPath + ".Stickiness.Duration", "durations are only used by cookies"},
+		{"cookie name", func(p *InfraParameters) { targetGroup(p).Stickiness.Type = StickyAppCookie }, tgPath + ".Stickiness.CookieName", "a cookie name is required by app_cookie stickiness, and only by it"},
+
+		//Health checks
+		{"health check protocol", func(p *InfraParameters) { targetGroup(p).HealthCheck.Protocol = TGProtoUDP }, tgPath + ".HealthCheck.Protocol", `health checks use HTTP, HTTPS or TCP, got "UDP"`},
+		{"health check protocol of alb target groups", func(p *InfraParameters) {
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{
+				Meta: meta("alb"), Port: 80, Protocol: TGProtoTCP, TargetType: TGAlb, LoadBalancerLookupName: "lb",
+				HealthCheck: LBTargetGroupHealthCHeck{Protocol: TGProtoTCP},
+			})
+		}, partitionPath + ".LBTargetGroups[1].HealthCheck.Protocol", "the targets of alb target groups are checked with HTTP or HTTPS"},
+		{"health check path", func(p *InfraParameters) {
+			targetGroup(p).HealthCheck.Protocol = TGProtoTCP
+			targetGroup(p).HealthCheck.Path = "/health"
+		}, tgPath + ".HealthCheck", "paths and status codes are only used by HTTP and HTTPS health checks"},
+		{"health check port", func(p *InfraParameters) { targetGroup(p).HealthCheck.Port = "http" }, tgPath + ".HealthCheck.Port", `port must be traffic-port or a number between 1 and 65535, got "http"`},
+		{"health check interval", func(p *InfraParameters) { targetGroup(p).HealthCheck.Interval = 301 }, tgPath + ".HealthCheck.Interval", "interval must be between 5 and 300 seconds, got 301"},
+		{"health check timeout", func(p *InfraParameters) { targetGroup(p).HealthCheck.Timeout = 1 }, tgPath + ".HealthCheck.Timeout", "timeout must be between 2 and 120 seconds, got 1"},
+		{"health check timeout and interval", func(p *InfraParameters) {
+			targetGroup(p).HealthCheck.Interval = 10
+			targetGroup(p).HealthCheck.Timeout = 10
+		}, tgPath + ".HealthCheck.Timeout", "timeout must be shorter than the interval"},
+		{"health check threshold", func(p *InfraParameters) { targetGroup(p).HealthCheck.HealthyThreshold = 11 }, tgPath + ".HealthCheck.HealthyThreshold", "threshold must be between 2 and 10, got 11"},
+
+		//Clusters and capacity providers
+		{"cluster name", func(p *InfraParameters) { cluster(p).Name = "" }, clusterPath + ".Name", "name is required"},
+		{"duplicate fargate capacity provider", func(p *InfraParameters) {
+			cluster(p).CapacityProviders = []CapacityProviderParameters{{Meta: meta("fargate"), Type: FargateCapacity}, {Meta: meta("fargate2"), Type: FargateCapacity}}
+		}, clusterPath + ".CapacityProviders[1].Type", "the cluster already has a FARGATE capacity provider"},
+		{"duplicate capacity provider", func(p *InfraParameters) {
+			cluster(p).CapacityProviders = append(cluster(p).CapacityProviders, *ec2Capacity(p))
+		}, clusterPath + ".CapacityProviders[1]", `name "instances" is already used by CapacityProviders[0]`},
+		{"fargate with an auto scaling group", func(p *InfraParameters) {
+			cluster(p).CapacityProviders = []CapacityProviderParameters{{Meta: meta("spot"), Type: FargateSpotCapacity, EC2: EC2CapacityParameters{MaxSize: 1}}}
+		}, clusterPath + ".CapacityProviders[0].EC2", "only EC2 capacity providers have an Auto Scaling group"},
+		{"ec2 capacity provider name", func(p *InfraParameters) { ec2Capacity(p).Name = "ecs-instances" }, clusterPath + ".CapacityProviders[0].Name", `the name of an EC2 capacity provider cannot start with "ecs"`},
+		{"capacity provider type", func(p *InfraParameters) {
+			cluster(p).CapacityProviders = []CapacityProviderParameters{{Meta: meta("lambda"), Type: "LAMBDA"}}
+		}, clusterPath + ".CapacityProviders[0].Type", `unknown capacity provider type "LAMBDA"`},
+		{"instance types", func(p *InfraParameters) { ec2Capacity(p).EC2.InstanceTypes = nil }, clusterPath + ".CapacityProviders[0].EC2.InstanceTypes", "at least one instance type is required"},
+		{"instance type", func(p *InfraParameters) { ec2Capacity(p).EC2.InstanceTypes = []string{""} }, clusterPath + ".CapacityProviders[0].EC2.InstanceTypes[0]", "instance type is required"},
+		{"min size", func(p *InfraParameters) { ec2Capacity(p).EC2.MinSize = -1 }, clusterPath + ".CapacityProviders[0].EC2.MinSize", "minimum size cannot be negative"},
+		{"max size", func(p *InfraParameters) { ec2Capacity(p).EC2.MinSize = 3 }, clusterPath + ".CapacityProviders[0].EC2.MaxSize", "maximum size must be at least 1 and the minimum size"},
+		{"target capacity", func(p *InfraParameters) { ec2Capacity(p).EC2.ManagedScaling.TargetCapacity = 101 }, clusterPath + ".CapacityProviders[0].EC2.ManagedScaling.TargetCapacity", "target capacity must be between 1 and 100, got 101"},
+		{"minimum scaling step size", func(p *InfraParameters) { ec2Capacity(p).EC2.ManagedScaling.MinimumScalingStepSize = 10001 }, clusterPath + ".CapacityProviders[0].EC2.ManagedScaling.MinimumScalingStepSize", "minimum scaling step size must be between 1 and 10000"},
+		{"maximum scaling step size", func(p *InfraParameters) { ec2Capacity(p).EC2.ManagedScaling.MaximumScalingStepSize = -1 }, clusterPath + ".CapacityProviders[0].EC2.ManagedScaling.MaximumScalingStepSize", "maximum scaling step size must be between 1 and 10000"},
+		{"scaling step sizes", func(p *InfraParameters) {
+			ec2Capacity(p).EC2.ManagedScaling.MinimumScalingStepSize = 5
+			cluster(p).CapacityProviders[0].EC2.ManagedScaling.MaximumScalingStepSize = 2
+		}, clusterPath + ".CapacityProviders[0].EC2.ManagedScaling.MaximumScalingStepSize", "maximum scaling step size cannot be less than the minimum scaling step size"},
+		{"managed termination protection", func(p *InfraParameters) {
+			ec2Capacity(p).EC2.ManagedTerminationProtection = true
+			cluster(p).CapacityProviders[0].EC2.ManagedScaling.Disabled = true
+		}, clusterPath + ".CapacityProviders[0].EC2.ManagedTerminationProtection", "managed termination protection requires managed scaling"},
+
+		//Capacity provider strategies
+		{"strategy capacity provider", func(p *InfraParameters) {
+			ec2Capacity(p)
+			service(p).CapacityProviderStrategy[0].CapacityProviderLookupName = "other"
+		}, servicePath + ".CapacityProviderStrategy[0].CapacityProviderLookupName", `the cluster has no capacity provider "other"`},
+		{"strategy weight", func(p *InfraParameters) { ec2Capacity(p); service(p).CapacityProviderStrategy[0].Weight = 1001 }, servicePath + ".CapacityProviderStrategy[0].Weight", "weight must be between 0 and 1000, got 1001"},
+		{"strategy base", func(p *InfraParameters) { ec2Capacity(p); service(p).CapacityProviderStrategy[0].Base = -1 }, servicePath + ".CapacityProviderStrategy[0].Base", "base must be between 0 and 100000, got -1"},
+		{"duplicate strategy item", func(p *InfraParameters) {
+			ec2Capacity(p)
+			service(p).CapacityProviderStrategy = append(service(p).CapacityProviderStrategy, service(p).CapacityProviderStrategy[0])
+		}, servicePath + ".CapacityProviderStrategy[1]", `name "instances" is already used by CapacityProviderStrategy[0]`},
+		{"strategy bases", func(p *InfraParameters) {
+			cluster(p).CapacityProviders = []CapacityProviderParameters{{Meta: meta("fargate"), Type: FargateCapacity}, {Meta: meta("spot"), Type: FargateSpotCapacity}}
+			service(p).CapacityProviderStrategy = []CapacityProviderStrategyItem{{CapacityProviderLookupName: "fargate", Base: 1, Weight: 1}, {CapacityProviderLookupName: "spot", Base: 1}}
+		}, servicePath + ".CapacityProviderStrategy", "only one capacity provider of the strategy can have a base"},
+		{"strategy weights", func(p *InfraParameters) { ec2Capacity(p); service(p).CapacityProviderStrategy[0].Weight = 0 }, servicePath + ".CapacityProviderStrategy", "at least one capacity provider of the strategy must have a weight"},
+		{"strategy mix", func(p *InfraParameters) {
+			ec2Capacity(p)
+			cluster(p).CapacityProviders = append(cluster(p).CapacityProviders, CapacityProviderParameters{Meta: meta("fargate"), Type: FargateCapacity})
+			service(p).CapacityProviderStrategy = append(service(p).CapacityProviderStrategy, CapacityProviderStrategyItem{CapacityProviderLookupName: "fargate", Weight: 1})
+		}, servicePath + ".CapacityProviderStrategy", "a strategy cannot mix Fargate and EC2 capacity providers"},
+		{"strategy fargate size", func(p *InfraParameters) {
+			cluster(p).CapacityProviders = []CapacityProviderParameters{{Meta: meta("spot"), Type: FargateSpotCapacity}}
+			service(p).CapacityProviderStrategy = []CapacityProviderStrategyItem{{CapacityProviderLookupName: "spot", Weight: 1}}
+			service(p).CPU = 300
+		}, servicePath + ".CPU", "300 is not a fargate cpu value"},
+		{"ec2 public ip", func(p *InfraParameters) { ec2Capacity(p); service(p).AssignPublicIP = true }, servicePath + ".AssignPublicIP", "tasks on EC2 instances cannot have a public IP"},
+
+		//Services
+		{"service name", func(p *InfraParameters) { service(p).Name = "" }, servicePath + ".Name", "name is required"},
+		{"desired count", func(p *InfraParameters) { service(p).DesiredCount = -1 }, servicePath + ".DesiredCount", "desired count cannot be negative"},
+		{"fargate cpu", func(p *InfraParameters) { service(p).CPU = 100 }, servicePath + ".CPU", "100 is not a fargate cpu value"},
+		{"fargate memory", func(p *InfraParameters) { service(p).Memory = 4096 }, servicePath + ".Memory", "4096 MiB is not a fargate memory value for 256 cpu units"},
+		{"ec2 service size", func(p *InfraParameters) { ec2Capacity(p); service(p).Memory = 64 }, servicePath, "the service needs at least 128 cpu units and 128 MiB"},
+		{"containers", func(p *InfraParameters) { service(p).Containers = nil }, servicePath + ".Containers", "at least one container is required"},
+		{"firelens container name", func(p *InfraParameters) {
+			service(p).Logs = LogParameters{Enabled: true, FireLens: &FireLensParameters{Options: map[string]string{"Name": "cloudwatch_logs"}}}
+			container(p).Name = FireLensContainerName
+		}, containerPath + ".Name", "log_router is the name of the FireLens sidecar"},
+		{"desired count and auto scaling", func(p *InfraParameters) { autoScaling(p); service(p).DesiredCount = 5 }, servicePath + ".DesiredCount", "the desired count must be between the capacities of the auto scaling"},
+		{"containers cpu", func(p *InfraParameters) { container(p).CPU = 512 }, servicePath + ".Containers", "the containers use 512 cpu units, more than the 256 of the service"},
+		{"containers memory", func(p *InfraParameters) { container(p).Memory = 1024 }, servicePath + ".Containers", "the containers use 1024 MiB, more than the 512 of the service"},
+		{"duplicate container", func(p *InfraParameters) {
+			service(p).Containers = append(service(p).Containers, ContainerDefinition{Name: "app", Image: "nginx"})
+		}, servicePath + ".Containers[1]", `name "app" is already used by Containers[0]`},
+		{"duplicate service", func(p *InfraParameters) {
+			cluster(p).Services = append(cluster(p).Services, *service(p))
+		}, clusterPath + ".Services[1]", `name "svc" is already used by Services[0]`},
+
+		//Deployments
+		{"circuit breaker with blue/green", func(p *InfraParameters) { blueGreen(p); service(p).Deployment.CircuitBreaker = true }, servicePath + ".Deployment.CircuitBreaker", "the circuit breaker only stops rolling deployments, not blue/green ones"},
+		{"rollback", func(p *InfraParameters) { service(p).Deployment.Rollback = true }, servicePath + ".Deployment.Rollback", "rollback needs the circuit breaker or the blue/green mode"},
+		{"minimum healthy percent", func(p *InfraParameters) { service(p).Deployment.MinimumHealthyPercent = intPtr(101) }, servicePath + ".Deployment.MinimumHealthyPercent", "minimum healthy percent must be between 0 and 100"},
+		{"maximum percent", func(p *InfraParameters) { service(p).Deployment.MaximumPercent = 50 }, servicePath + ".Deployment.MaximumPercent", "maximum percent must be at least 100 and the minimum healthy percent"},
+		{"negative grace period", func(p *InfraParameters) { service(p).Deployment.HealthCheckGracePeriod = -1 }, servicePath + ".Deployment.HealthCheckGracePeriod", "health check grace period cannot be negative"},
+		{"grace period without target group", func(p *InfraParameters) {
+			service(p).Deployment.HealthCheckGracePeriod = 60
+			container(p).PortMappings[0].TargetGroupLookupName = ""
+		}, servicePath + ".Deployment.HealthCheckGracePeriod", "the service has no port mapping with a target group"},
+		{"blue/green service discovery", func(p *InfraParameters) {
+			blueGreen(p)
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{}
+		}, servicePath + ".Deployment.BlueGreen", "blue/green deployments don't support service discovery or Service Connect"},
+		{"blue/green target group", func(p *InfraParameters) { blueGreen(p).TargetGroupLookupName = "" }, servicePath + ".Deployment.BlueGreen.TargetGroupLookupName", "target group is required"},
+		{"blue/green port mappings", func(p *InfraParameters) {
+			blueGreen(p)
+			container(p).PortMappings[0].TargetGroupLookupName = ""
+		}, servicePath + ".Deployment.BlueGreen.TargetGroupLookupName", `the port mappings of the service must forward only from the target group "web"`},
+		{"blue/green load balancer", func(p *InfraParameters) { blueGreen(p).LoadBalancerLookupName = "" }, servicePath + ".Deployment.BlueGreen.LoadBalancerLookupName", "load balancer is required"},
+		{"blue/green listener", func(p *InfraParameters) { blueGreen(p).ListenerLookupName = "" }, servicePath + ".Deployment.BlueGreen.ListenerLookupName", "listener is required"},
+		{"blue/green test listener port", func(p *InfraParameters) { blueGreen(p).TestListenerPort = 0 }, servicePath + ".Deployment.BlueGreen.TestListenerPort", "port must be between 1 and 65535, got 0"},
+		{"blue/green termination wait time", func(p *InfraParameters) { blueGreen(p).TerminationWaitTime = 2881 }, servicePath + ".Deployment.BlueGreen.TerminationWaitTime", "termination wait time must be between 0 and 2880 minutes"},
+		{"green target group", func(p *InfraParameters) {
+			blueGreen(p)
+			partition(p).LBTargetGroups = append(partition(p).LBTargetGroups, LBTargetGroupParameters{Meta: meta(service(p).Deployment.BlueGreen.greenTargetGroupName()), Port: 80, Protocol: TGProtoHTTP})
+		}, servicePath + ".Deployment.BlueGreen.TargetGroupLookupName", "is already a target group of the partition"},
+		{"blue/green load balancer not found", func(p *InfraParameters) { blueGreen(p).LoadBalancerLookupName = "other" }, servicePath + ".Deployment.BlueGreen.LoadBalancerLookupName", `load balancer "other" not found in the partition`},
+		{"blue/green gateway load balancer", func(p *InfraParameters) {
+			gatewayLB(p)
+			blueGreen(p).LoadBalancerLookupName = "gwlb"
+		}, servicePath + ".Deployment.BlueGreen.LoadBalancerLookupName", "blue/green deployments need an application or network load balancer"},
+		{"blue/green test listener port used", func(p *InfraParameters) { blueGreen(p).TestListenerPort = 80 }, servicePath + ".Deployment.BlueGreen.TestListenerPort", `load balancer "lb" already listens on the port 80`},
+		{"blue/green listener target group", func(p *InfraParameters) {
+			blueGreen(p)
+			listener(p).Rules = nil
+			listener(p).TargetGroupLookupName = ""
+			listener(p).Actions = []LBActionParameters{{Type: ActionFixedResponse, FixedResponse: LBFixedResponseAction{StatusCode: 404}}}
+		}, servicePath + ".Deployment.BlueGreen.ListenerLookupName", `listener "http" doesn't forward to the target group "web"`},
+		{"blue/green listener not found", func(p *InfraParameters) { blueGreen(p).ListenerLookupName = "other" }, servicePath + ".Deployment.BlueGreen.ListenerLookupName", `listener "other" not found in the load balancer "lb"`},
+
+		//Auto scaling
+		{"min capacity", func(p *InfraParameters) { autoScaling(p).MinCapacity = -1 }, servicePath + ".AutoScaling.MinCapacity", "minimum capacity cannot be negative"},
+		{"max capacity", func(p *InfraParameters) { autoScaling(p).MaxCapacity = 0 }, servicePath + ".AutoScaling.MaxCapacity", "maximum capacity must be at least 1 and the minimum capacity"},
+		{"step scaling name used by target tracking", func(p *InfraParameters) {
+			stepScaling(p)
+			service(p).AutoScaling.TargetTracking = []TargetTrackingScalingParameters{{Name: "queue", Metric: ScaleOnCPU, TargetValue: 50}}
+		}, servicePath + ".AutoScaling.StepScaling[0]", `name "queue" is already used by a target tracking policy`},
+		{"target utilization", func(p *InfraParameters) {
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "cpu", Metric: ScaleOnCPU}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].TargetValue", "the target utilization must be a percentage above 0"},
+		{"utilization target group", func(p *InfraParameters) {
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "cpu", Metric: ScaleOnCPU, TargetValue: 50, TargetGroupLookupName: "web"}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].TargetGroupLookupName", "only ALBRequestCountPerTarget policies count the requests of a target group"},
+		{"target request count", func(p *InfraParameters) {
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "requests", Metric: ScaleOnRequestCount, TargetGroupLookupName: "web"}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].TargetValue", "the target request count must be above 0"},
+		{"request count target group", func(p *InfraParameters) {
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "requests", Metric: ScaleOnRequestCount, TargetValue: 100}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].TargetGroupLookupName", "the target group whose requests are counted is required"},
+		{"metric", func(p *InfraParameters) {
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "disk", Metric: "Disk", TargetValue: 50}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].Metric", "metric must be ECSServiceAverageCPUUtilization, ECSServiceAverageMemoryUtilization or ALBRequestCountPerTarget"},
+		{"scale in cooldown", func(p *InfraParameters) {
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "cpu", Metric: ScaleOnCPU, TargetValue: 50, ScaleInCooldown: -1}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].ScaleInCooldown", "cooldown cannot be negative"},
+		{"scale out cooldown", func(p *InfraParameters) {
+			autoScaling(p).TargetTracking = []TargetTrackingScalingParameters{{Name: "cpu", Metric: ScaleOnCPU, TargetValue: 50, ScaleOutCooldown: -1}}
+		}, servicePath + ".AutoScaling.TargetTracking[0].ScaleOutCooldown", "cooldown cannot be negative"},
+		{"adjustment type", func(p *InfraParameters) { stepScaling(p).AdjustmentType = "Double" }, servicePath + ".AutoScaling.StepScaling[0].AdjustmentType", "adjustment type must be ChangeInCapacity, PercentChangeInCapacity or ExactCapacity"},
+		{"step cooldown", func(p *InfraParameters) { stepScaling(p).Cooldown = -1 }, servicePath + ".AutoScaling.StepScaling[0].Cooldown", "cooldown cannot be negative"},
+		{"steps", func(p *InfraParameters) { stepScaling(p).Steps = nil }, servicePath + ".AutoScaling.StepScaling[0].Steps", "at least one step is required"},
+		{"step bounds", func(p *InfraParameters) {
+			lower, upper := 10.0, 5.0
+			stepScaling(p).Steps = []ScalingStep{{LowerBound: &lower, UpperBound: &upper, Adjustment: 1}}
+		}, servicePath + ".AutoScaling.StepScaling[0].Steps[0]", "the lower bound must be below the upper bound"},
+		{"exact capacity", func(p *InfraParameters) {
+			stepScaling(p).AdjustmentType = ExactCapacity
+			service(p).AutoScaling.StepScaling[0].Steps[0].Adjustment = -1
+		}, servicePath + ".AutoScaling.StepScaling[0].Steps[0].Adjustment", "an exact capacity cannot be negative"},
+		{"unbounded steps", func(p *InfraParameters) {
+			step := stepScaling(p)
+			step.Steps = append(step.Steps, step.Steps[0])
+		}, servicePath + ".AutoScaling.StepScaling[0].Steps", "only one step can have no lower bound and only one no upper bound"},
+		{"alarm namespace", func(p *InfraParameters) { stepScaling(p).Alarm.Namespace = "" }, servicePath + ".AutoScaling.StepScaling[0].Alarm.Namespace", "namespace is required"},
+		{"alarm metric", func(p *InfraParameters) { stepScaling(p).Alarm.MetricName = "" }, servicePath + ".AutoScaling.StepScaling[0].Alarm.MetricName", "metric name is required"},
+		{"alarm comparison operator", func(p *InfraParameters) { stepScaling(p).Alarm.ComparisonOperator = ">" }, servicePath + ".AutoScaling.StepScaling[0].Alarm.ComparisonOperator", "comparison operator must be one of GreaterThanOrEqualToThreshold"},
+		{"alarm statistic", func(p *InfraParameters) { stepScaling(p).Alarm.Statistic = "Median" }, servicePath + ".AutoScaling.StepScaling[0].Alarm.Statistic", "statistic must be one of Average"},
+		{"alarm period", func(p *InfraParameters) { stepScaling(p).Alarm.Period = 45 }, servicePath + ".AutoScaling.StepScaling[0].Alarm.Period", "period must be 10, 30 or a multiple of 60 seconds"},
+		{"alarm evaluation periods", func(p *InfraParameters) { stepScaling(p).Alarm.EvaluationPeriods = -1 }, servicePath + ".AutoScaling.StepScaling[0].Alarm.EvaluationPeriods", "evaluation periods cannot be negative"},
+		{"schedule", func(p *InfraParameters) {
+			autoScaling(p).ScheduledActions = []ScheduledScalingParameters{{Name: "night", Schedule: "every night", MaxCapacity: 1}}
+		}, servicePath + ".AutoScaling.ScheduledActions[0].Schedule", `"every night" is not a cron(...), rate(...) or at(...) expression`},
+
+		//Logs
+		{"logs not enabled", func(p *InfraParameters) { service(p).Logs.RetentionInDays = 7 }, servicePath + ".Logs.Enabled", "the logs are configured but not enabled"},
+		{"retention", func(p *InfraParameters) { service(p).Logs = LogParameters{Enabled: true, RetentionInDays: 2} }, servicePath + ".Logs.RetentionInDays", "2 days is not a CloudWatch retention period"},
+		{"logs kms key", func(p *InfraParameters) { service(p).Logs = LogParameters{Enabled: true, KmsKeyArn: "key"} }, servicePath + ".Logs.KmsKeyArn", `"key" is not the arn of a KMS key`},
+		{"stream prefix", func(p *InfraParameters) { service(p).Logs = LogParameters{Enabled: true, StreamPrefix: "a:b"} }, servicePath + ".Logs.StreamPrefix", "the stream prefix cannot contain : or *"},
+		{"firelens output", func(p *InfraParameters) {
+			service(p).Logs = LogParameters{Enabled: true, FireLens: &FireLensParameters{}}
+		}, servicePath + ".Logs.FireLens.Options", "the Name option is required to choose the Fluent Bit output"},
+		{"firelens cpu", func(p *InfraParameters) {
+			service(p).Logs = LogParameters{Enabled: true, FireLens: &FireLensParameters{Options: map[string]string{"Name": "cloudwatch_logs"}, CPU: -1}}
+		}, servicePath + ".Logs.FireLens.CPU", "cpu cannot be negative"},
+		{"firelens memory", func(p *InfraParameters) {
+			service(p).Logs = LogParameters{Enabled: true, FireLens: &FireLensParameters{Options: map[string]string{"Name": "cloudwatch_logs"}, Memory: -1}}
+		}, servicePath + ".Logs.FireLens.Memory", "memory cannot be negative"},
+
+		//Task roles
+		{"managed policy", func(p *InfraParameters) { service(p).TaskRole.ManagedPolicyArns = []string{"ReadOnly"} }, servicePath + ".TaskRole.ManagedPolicyArns[0]", `"ReadOnly" is not the arn of a managed policy`},
+		{"statement effect", func(p *InfraParameters) {
+			service(p).TaskRole.Statements = []PolicyStatement{{Effect: "Maybe", Actions: []string{"s3:GetObject"}, Resources: []string{"*"}}}
+		}, servicePath + ".TaskRole.Statements[0].Effect", "effect must be Allow or Deny"},
+		{"statement actions", func(p *InfraParameters) {
+			service(p).TaskRole.Statements = []PolicyStatement{{Resources: []string{"*"}}}
+		}, servicePath + ".TaskRole.Statements[0].Actions", "at least one action is required"},
+		{"statement action", func(p *InfraParameters) {
+			service(p).TaskRole.Statements = []PolicyStatement{{Actions: []string{"GetObject"}, Resources: []string{"*"}}}
+		}, servicePath + ".TaskRole.Statements[0].Actions[0]", `"GetObject" is not an action like s3:GetObject`},
+		{"statement resources", func(p *InfraParameters) {
+			service(p).TaskRole.Statements = []PolicyStatement{{Actions: []string{"s3:GetObject"}}}
+		}, servicePath + ".TaskRole.Statements[0].Resources", "at least one resource is required"},
+		{"statement resource", func(p *InfraParameters) {
+			service(p).TaskRole.Statements = []PolicyStatement{{Actions: []string{"s3:GetObject"}, Resources: []string{"bucket"}}}
+		}, servicePath + ".TaskRole.Statements[0].Resources[0]", `"bucket" is not an arn`},
+
+		//Containers
+		{"container name", func(p *InfraParameters) { container(p).Name = "" }, containerPath + ".Name", "name is required"},
+		{"container image", func(p *InfraParameters) { container(p).Image = "" }, containerPath + ".Image", "image is required"},
+		{"container cpu", func(p *InfraParameters) { container(p).CPU = -1 }, containerPath + ".CPU", "cpu cannot be negative"},
+		{"container memory", func(p *InfraParameters) { container(p).Memory = -1 }, containerPath + ".Memory", "memory cannot be negative"},
+		{"container port", func(p *InfraParameters) { container(p).PortMappings[0].ContainerPort = 0 }, containerPath + ".PortMappings[0].ContainerPort", "port must be between 1 and 65535, got 0"},
+		{"host port", func(p *InfraParameters) { container(p).PortMappings[0].HostPort = 8080 }, containerPath + ".PortMappings[0].HostPort", "the host port must be the container port in the awsvpc network mode"},
+		{"port name", func(p *InfraParameters) { container(p).PortMappings[0].Name = "HTTP" }, containerPath + ".PortMappings[0].Name", `"HTTP" is not a port name of lowercase letters, digits, _ and -`},
+		{"app protocol", func(p *InfraParameters) { container(p).PortMappings[0].AppProtocol = "tcp" }, containerPath + ".PortMappings[0].AppProtocol", `app protocol must be http, http2 or grpc, got "tcp"`},
+		{"duplicate port name", func(p *InfraParameters) {
+			container(p).PortMappings = []ContainerPortMapping{{Name: "http", ContainerPort: 80}, {Name: "http", ContainerPort: 8080}}
+		}, containerPath + ".PortMappings[1].Name", `port name "http" is already used by`},
+		{"secret and environment variable", func(p *InfraParameters) {
+			secret(p)
+			container(p).Environment = []ContainerEnvironmentVar{{Name: "DB_PASSWORD", Value: "password"}}
+		}, containerPath + ".Secrets[0].Name", "DB_PASSWORD is also a plain environment variable"},
+
+		//Secrets
+		{"secret name", func(p *InfraParameters) { secret(p).Name = "db-password" }, containerPath + ".Secrets[0].Name", `"db-password" is not the name of an environment variable`},
+		{"secret source", func(p *InfraParameters) { secret(p).Source = "vault" }, containerPath + ".Secrets[0].Source", "source must be secretsmanager or ssm"},
+		{"secret arn or value", func(p *InfraParameters) { secret(p).Value = nil }, containerPath + ".Secrets[0]", "either the arn of an existing secret or a value is required"},
+		{"secret arn and value", func(p *InfraParameters) {
+			secret(p).Arn = "arn:aws:secretsmanager:eu-west-1:123456789012:secret:db-AbCdEf"
+		}, containerPath + ".Secrets[0].Value", "a secret with an arn already exists and cannot have a value"},
+		{"secret arn", func(p *InfraParameters) {
+			secret(p).Value = nil
+			container(p).Secrets[0].Arn = "arn:aws:ssm:eu-west-1:123456789012:parameter/db"
+		}, containerPath + ".Secrets[0].Arn", "is not the arn of a secret stored in secretsmanager"},
+		{"secret kms key", func(p *InfraParameters) { secret(p).KmsKeyArn = "key" }, containerPath + ".Secrets[0].KmsKeyArn", `"key" is not the arn of a KMS key`},
+
+		//Namespaces, service discovery and Service Connect
+		{"namespace domain", func(p *InfraParameters) { namespace(p); cluster(p).Namespace.Domain = "" }, clusterPath + ".Namespace.Domain", "domain is required"},
+		{"namespace domain case", func(p *InfraParameters) { namespace(p); cluster(p).Namespace.Domain = "Internal.Local" }, clusterPath + ".Namespace.Domain", `"Internal.Local" is not a lowercase domain name`},
+		{"service discovery without namespace", func(p *InfraParameters) { service(p).ServiceDiscovery = &ServiceDiscoveryParameters{} }, servicePath + ".ServiceDiscovery", "neither the cluster nor the vpc has a namespace"},
+		{"service connect without namespace", func(p *InfraParameters) { service(p).ServiceConnect = &ServiceConnectParameters{} }, servicePath + ".ServiceConnect", "neither the cluster nor the vpc has a namespace"},
+		{"duplicate discovery name", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{}
+			other := *service(p)
+			other.Name = "other"
+			other.ServiceDiscovery = &ServiceDiscoveryParameters{Name: "svc"}
+			cluster(p).Services = append(cluster(p).Services, other)
+		}, clusterPath + ".Services[1].ServiceDiscovery", `the name "svc" is already used in the namespace by`},
+		{"discovery name", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{Name: "my_svc"}
+		}, servicePath + ".ServiceDiscovery.Name", `"my_svc" is not a dns label`},
+		{"discovery ttl", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{TTL: -1}
+		}, servicePath + ".ServiceDiscovery.TTL", "ttl cannot be negative"},
+		{"discovery record type", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{RecordTypes: []DNSRecordType{RecordAAAA}}
+		}, servicePath + ".ServiceDiscovery.RecordTypes[0]", `record type must be A or SRV, got "AAAA"`},
+		{"duplicate discovery record type", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{RecordTypes: []DNSRecordType{RecordA, RecordA}}
+		}, servicePath + ".ServiceDiscovery.RecordTypes[1]", "record type A is already used"},
+		{"srv port name", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{RecordTypes: []DNSRecordType{RecordSRV}}
+		}, servicePath + ".ServiceDiscovery.PortName", "SRV records need a port name"},
+		{"a record port name", func(p *InfraParameters) {
+			namespace(p)
+			container(p).PortMappings[0].Name = "http"
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{PortName: "http"}
+		}, servicePath + ".ServiceDiscovery.PortName", "only SRV records have a port"},
+		{"discovery port name not found", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceDiscovery = &ServiceDiscoveryParameters{RecordTypes: []DNSRecordType{RecordSRV}, PortName: "grpc"}
+		}, servicePath + ".ServiceDiscovery.PortName", `no port mapping of the service is named "grpc"`},
+		{"service connect port name", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceConnect = &ServiceConnectParameters{Services: []ServiceConnectService{{}}}
+		}, servicePath + ".ServiceConnect.Services[0].PortName", "port name is required"},
+		{"service connect port name not found", func(p *InfraParameters) {
+			namespace(p)
+			service(p).ServiceConnect = &ServiceConnectParameters{Services: []ServiceConnectService{{PortName: "grpc"}}}
+		}, servicePath + ".ServiceConnect.Services[0].PortName", `no port mapping of the service is named "grpc"`},
+		{"service connect discovery name", func(p *InfraParameters) {
+			namespace(p)
+			container(p).PortMappings[0].Name = "http"
+			service(p).ServiceConnect = &ServiceConnectParameters{Services: []ServiceConnectService{{PortName: "http", DiscoveryName: "my_svc"}}}
+		}, servicePath + ".ServiceConnect.Services[0].DiscoveryName", `"my_svc" is not a dns label`},
+		{"service connect dns name", func(p *InfraParameters) {
+			namespace(p)
+			container(p).PortMappings[0].Name = "http"
+			service(p).ServiceConnect = &ServiceConnectParameters{Services: []ServiceConnectService{{PortName: "http", DNSName: "Web"}}}
+		}, servicePath + ".ServiceConnect.Services[0].DNSName", `"Web" is not a lowercase dns name`},
+		{"service connect client port", func(p *InfraParameters) {
+			namespace(p)
+			container(p).PortMappings[0].Name = "http"
+			service(p).ServiceConnect = &ServiceConnectParameters{Services: []ServiceConnectService{{PortName: "http", ClientPort: 70000}}}
+		}, servicePath + ".ServiceConnect.Services[0].ClientPort", "port must be between 1 and 65535, got 70000"},
+	}
+	for _, test := range tests {
+		p := validInfra()
+		test.change(&p)
+		errs := validationErrors(t, p.Validate())
+		found := false
+		for _, err := range errs {
+			if err.Path == test.path && strings.Contains(err.Message, test.message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected %s: %s, got %v", test.name, test.path, test.message, errs)
+		}
+	}
+}
+
+func TestNewValidatesFirst(t *testing.T) {
+	p := validInfra()
+	p.RegisterComponent = true
+	vpc(&p).Provider.Region = ""
+	result, err := pgotest.Run(New(p))
+	if err == nil || !strings.Contains(err.Error(), "Vpcs[0].Provider.Region: region is required") {
+		t.Fatalf("expected the validation error, got %v", err)
+	}
+	if len(result.Resources) > 0 {
+		t.Errorf("expected no resource, got %d", len(result.Resources))
+	}
+}
@@ -0,0 +1,124 @@
+package pgocomp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrorMode tells what a component does when its apply function fails
+type ErrorMode int
+
+const (
+	//CacheError runs the apply function only once. Later calls to Get return the first error. It is the default mode
+	CacheError ErrorMode = iota
+	//Retry runs the apply function again, waiting between attempts, until it succeeds or the attempts are over.
+	//Use it for transient failures, like invokes, because resources registered by a failed attempt are registered again
+	Retry
+	//FailFast runs the apply function only once, and makes every component that was not applied yet on the same pulumi context fail immediately
+	FailFast
+)
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+// ErrorPolicy configures what a component does when its apply function fails
+type ErrorPolicy struct {
+	Mode ErrorMode
+	//Attempts is the number of times apply is called in the Retry mode. Defaults to 3
+	Attempts int
+	//Backoff is the wait before the second attempt in the Retry mode, doubled after each attempt. Defaults to 1 second
+	Backoff time.Duration
+	//MaxBackoff is the longest wait between two attempts in the Retry mode. Defaults to 30 seconds
+	MaxBackoff time.Duration
+}
+
+func (p ErrorPolicy) String() string {
+	switch p.Mode {
+	case CacheError:
+		return "cache-error"
+	case Retry:
+		return fmt.Sprintf("retry(attempts=%d, backoff=%s)", p.attempts(), p.backoff(1))
+	case FailFast:
+		return "fail-fast"
+	default:
+		return fmt.Sprintf("ErrorMode(%d)", p.Mode)
+	}
+}
+
+func (p ErrorPolicy) attempts() int {
+	if p.Mode != Retry {
+		return 1
+	}
+	if p.Attempts <= 0 {
+		return defaultRetryAttempts
+	}
+	return p.Attempts
+}
+
+// backoff returns the wait after the given failed attempt (starting at 1)
+func (p ErrorPolicy) backoff(attempt int) time.Duration {
+	wait := valueOrDefault(p.Backoff, defaultRetryBackoff)
+	max := valueOrDefault(p.MaxBackoff, defaultRetryMaxBackoff)
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
+
+// ComponentError is the error returned by a component whose apply function failed
+type ComponentError struct {
+	Name     string
+	Policy   ErrorPolicy
+	Attempts int
+	Err      error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("component %s failed (policy %s, %d attempt(s)): %v", e.Name, e.Policy, e.Attempts, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// applyWithPolicy calls apply following the error policy of the component.
+// Errors that already are ComponentErrors, from inner components, are returned as they are
+func (c *Component[T]) applyWithPolicy(ctx *pulumi.Context) (element T, err error) {
+	attempts := c.policy.attempts()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if element, err = c.apply(ctx, c.name); err == nil {
+			return
+		}
+		var cycle *CycleError
+		var inner *ComponentError
+		if errors.As(err, &cycle) || errors.As(err, &inner) {
+			return
+		}
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-time.After(c.policy.backoff(attempt)):
+		case <-ctx.Context().Done():
+			attempts = attempt
+		}
+	}
+	return element, &ComponentError{Name: c.name, Policy: c.policy, Attempts: attempts, Err: err}
+}
+
+func valueOrDefault[T comparable](value T, _default T) T {
+	var zero T
+	if value == zero {
+		return _default
+	}
+	return value
+}
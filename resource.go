@@ -0,0 +1,97 @@
+package pgocomp
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Outputer is implemented by components that want to register outputs in their pulumi ComponentResource
+type Outputer interface {
+	Outputs() pulumi.Map
+}
+
+// componentResource is the pulumi ComponentResource registered for a component
+type componentResource struct {
+	pulumi.ResourceState
+}
+
+// registerComponent wraps an apply function so it registers a ComponentResource before applying the component.
+// Resources and components created inside apply are parented to it, and the response of apply is registered as its outputs.
+// applying returns the Get that applies the component
+func registerComponent[T any](meta Meta, applying func() *visit, apply func(ctx *pulumi.Context, name string) (T, error)) func(ctx *pulumi.Context, name string) (T, error) {
+	var resource *componentResource
+	return func(ctx *pulumi.Context, name string) (element T, err error) {
+		t, v := trackerOf(ctx), applying()
+		if resource == nil {
+			resource = &componentResource{}
+			opts := append([]pulumi.ResourceOption{pulumi.Protect(meta.Protect)}, parentOptions(t.parent(v.parent))...)
+			if err = ctx.RegisterComponentResource(componentType[T](meta), name, resource, opts...); err != nil {
+				resource = nil
+				return
+			}
+		}
+		t.own(v, resource)
+		if element, err = apply(ctx, name); err != nil {
+			return
+		}
+		err = ctx.RegisterResourceOutputs(resource, outputsOf(element))
+		return
+	}
+}
+
+// withParent adds the pulumi ComponentResource of the closest registered component of the chain of the visit, if any,
+// to the options of a resource
+func withParent[O pulumi.ResourceOption](ctx *pulumi.Context, v *visit, opts []O) []O {
+	for _, opt := range parentOptions(trackerOf(ctx).parent(v)) {
+		if o, ok := opt.(O); ok {
+			opts = append(opts, o)
+		}
+	}
+	return opts
+}
+
+// parentOptions returns the options that parent a resource to a component. An alias to the root of the stack
+// keeps the resources created before the component was registered, instead of replacing them
+func parentOptions(parent pulumi.Resource) []pulumi.ResourceOption {
+	if parent == nil {
+		return nil
+	}
+	return []pulumi.ResourceOption{
+		pulumi.Parent(parent),
+		pulumi.Aliases([]pulumi.Alias{{NoParent: pulumi.Bool(true)}}),
+	}
+}
+
+// componentType returns the type token of the meta or builds one from the type of the component, like pgocomp:awscinfra:VpcComponent
+func componentType[T any](meta Meta) string {
+	if meta.ComponentType != "" {
+		return meta.ComponentType
+	}
+	module, typ := "index", strings.TrimLeft(typeName[T](), "*[]")
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		typ = typ[:i]
+	}
+	if i := strings.LastIndex(typ, "."); i >= 0 {
+		module, typ = typ[:i], typ[i+1:]
+	}
+	return "pgocomp:" + module + ":" + typ
+}
+
+// outputsOf returns the outputs to register for the response of a component
+func outputsOf(element any) pulumi.Map {
+	if v := reflect.ValueOf(element); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return pulumi.Map{}
+	}
+	switch e := element.(type) {
+	case Outputer:
+		return e.Outputs()
+	case pulumi.CustomResource:
+		return pulumi.Map{"urn": e.URN(), "id": e.ID()}
+	case pulumi.Resource:
+		return pulumi.Map{"urn": e.URN()}
+	default:
+		return pulumi.Map{}
+	}
+}
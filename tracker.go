@@ -0,0 +1,292 @@
+package pgocomp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// tracker follows the components that are being applied on a pulumi context
+type tracker struct {
+	lock  sync.Mutex
+	graph *Graph
+	//active are the Gets in progress, in the order they started
+	active []*visit
+	//concurrent are the calls to ApplyAllConcurrently in progress that run appliers side by side
+	concurrent []*section
+	//owners are the visits that hold the lock of a component, and waiting the visits that wait for it
+	owners  map[*GraphNode]*visit
+	waiting map[*visit]*GraphNode
+	failed  string
+	names   map[string]any
+	//resources are the pulumi ComponentResources registered for the components
+	resources map[*GraphNode]pulumi.Resource
+}
+
+// trackers are the trackers of the runs in progress, by the pulumi context of the run
+var trackers = struct {
+	lock  sync.Mutex
+	byCtx map[*pulumi.Context]*tracker
+}{byCtx: make(map[*pulumi.Context]*tracker)}
+
+// trackerOf returns the tracker of the run of a pulumi context, creating it on the first call
+func trackerOf(ctx *pulumi.Context) *tracker {
+	trackers.lock.Lock()
+	defer trackers.lock.Unlock()
+	t, ok := trackers.byCtx[ctx]
+	if !ok {
+		t = &tracker{
+			graph:     newGraph(),
+			owners:    make(map[*GraphNode]*visit),
+			waiting:   make(map[*visit]*GraphNode),
+			names:     make(map[string]any),
+			resources: make(map[*GraphNode]pulumi.Resource),
+		}
+		trackers.byCtx[ctx] = t
+	}
+	return t
+}
+
+// Release drops what pgocomp recorded during the run of a pulumi context, like its dependency graph and the names of its resources.
+// A program run by pulumi.Run ends with its run, but code that starts many runs in the same process, like tests, must release
+// each one once it is over. Applying components on the context again starts a new run
+func Release(ctx *pulumi.Context) {
+	trackers.lock.Lock()
+	defer trackers.lock.Unlock()
+	delete(trackers.byCtx, ctx)
+}
+
+// CycleError is returned by Get when a component depends on itself, directly or through other components.
+// Without it the program would wait forever for the lock of the component.
+// The appliers that ApplyAllConcurrently runs side by side cannot be told apart, so a cycle between two of them still blocks
+type CycleError struct {
+	Components []string
+}
+
+func (e *CycleError) Error() string {
+	return "pgocomp: dependency cycle detected: " + strings.Join(e.Components, " → ")
+}
+
+// visit is a Get of a component. Its parent is the visit of the component whose apply function made the Get
+type visit struct {
+	node   *GraphNode
+	parent *visit
+	//done is set when the Get returned
+	done bool
+}
+
+// section is a call to ApplyAllConcurrently that runs appliers side by side, made inside the apply function of caller
+type section struct {
+	caller *visit
+}
+
+// current returns the visit of the component whose apply function makes a new Get, nil at the root of the run.
+// A pulumi context does not tell which apply function uses it, so it is the innermost Get in progress. While appliers run
+// side by side, it could be any of theirs, so it is the component that called ApplyAllConcurrently, which is an ancestor of all of them
+func (t *tracker) current() *visit {
+	if len(t.concurrent) > 0 {
+		return t.concurrent[0].caller
+	}
+	for i := len(t.active) - 1; i >= 0; i-- {
+		if _, ok := t.waiting[t.active[i]]; !ok {
+			return t.active[i]
+		}
+	}
+	return nil
+}
+
+// chain returns the visits from the root of the run to v that are still in progress
+func (t *tracker) chain(v *visit) (chain []*visit) {
+	for ; v != nil; v = v.parent {
+		if !v.done {
+			chain = append([]*visit{v}, chain...)
+		}
+	}
+	return
+}
+
+// descendsFrom tells if the visit was made, directly or not, inside the apply function of the ancestor
+func (v *visit) descendsFrom(ancestor *visit) bool {
+	for p := v.parent; p != nil; p = p.parent {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// enter records that a Get started on the component identified by key, inside the apply function of the current component.
+// It fails with a CycleError when waiting for the component would never end.
+// leave must be called with the returned visit when the Get is done
+func (t *tracker) enter(key any, name, typ string) (*visit, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	parent := t.current()
+	node := t.graph.node(key, name, typ)
+	if parent != nil {
+		t.graph.edge(parent.node, node)
+	}
+	if cycle := t.cycle(parent, node); cycle != nil {
+		return nil, &CycleError{Components: cycle}
+	}
+	v := &visit{node: node, parent: parent}
+	t.active = append(t.active, v)
+	t.waiting[v] = node
+	return v, nil
+}
+
+// cycle returns the names of the components in a cycle, when a Get on node made under the parent visit would close one.
+// It looks for node in the chain of the parent itself, then follows the visit that holds the lock of node and the
+// visits made inside it that are waiting, in case one of them waits for a component locked by the chain
+func (t *tracker) cycle(parent *visit, node *GraphNode) []string {
+	chain := t.chain(parent)
+	names := func(visits []*visit, nodes []*GraphNode) (names []string) {
+		for _, v := range visits {
+			names = append(names, v.node.Name)
+		}
+		for _, n := range nodes {
+			names = append(names, n.Name)
+		}
+		return
+	}
+	for i, v := range chain {
+		if v.node == node {
+			return names(chain[i:], []*GraphNode{node})
+		}
+	}
+	seen := make(map[*GraphNode]bool)
+	var follow func(path []*GraphNode) []string
+	follow = func(path []*GraphNode) []string {
+		next := path[len(path)-1]
+		owner, ok := t.owners[next]
+		if !ok || seen[next] {
+			return nil
+		}
+		seen[next] = true
+		for i, v := range chain {
+			if v == owner {
+				return names(chain[i:], path)
+			}
+		}
+		for w, wanted := range t.waiting {
+			if w.descendsFrom(owner) {
+				if cycle := follow(append(path[:len(path):len(path)], wanted)); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return follow([]*GraphNode{node})
+}
+
+// locked records that the visit acquired the lock of its component
+func (t *tracker) locked(v *visit) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.waiting, v)
+	t.owners[v.node] = v
+}
+
+// leave records that the Get of the visit is done
+func (t *tracker) leave(v *visit) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.waiting, v)
+	if t.owners[v.node] == v {
+		delete(t.owners, v.node)
+	}
+	for i := len(t.active) - 1; i >= 0; i-- {
+		if t.active[i] == v {
+			t.active = append(t.active[:i], t.active[i+1:]...)
+			break
+		}
+	}
+	v.done = true
+}
+
+// concurrently records that ApplyAllConcurrently starts running appliers side by side.
+// The returned function must be called when they are all done
+func (t *tracker) concurrently() (done func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s := &section{caller: t.current()}
+	t.concurrent = append(t.concurrent, s)
+	return func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		for i, other := range t.concurrent {
+			if other == s {
+				t.concurrent = append(t.concurrent[:i], t.concurrent[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// own records that the component of the visit registered a pulumi ComponentResource
+func (t *tracker) own(v *visit, resource pulumi.Resource) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if v != nil {
+		t.resources[v.node] = resource
+	}
+}
+
+// parent returns the pulumi ComponentResource of the closest component of the chain of the visit, or nil
+func (t *tracker) parent(v *visit) pulumi.Resource {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for ; v != nil; v = v.parent {
+		if r, ok := t.resources[v.node]; ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// claim reserves the name of a resource of the given type for the component identified by key.
+// It fails when another component already uses the same name for the same type of resource
+func (t *tracker) claim(key any, typ, name string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if owner, ok := t.names[typ+"::"+name]; ok && owner != key {
+		return fmt.Errorf("pgocomp: name collision: more than one component creates a %s named %s", typ, name)
+	}
+	t.names[typ+"::"+name] = key
+	return nil
+}
+
+// failFast records the failure of a component with the FailFast error policy
+func (t *tracker) failFast(name string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.failed == "" {
+		t.failed = name
+	}
+}
+
+// failedFast returns an error when a component with the FailFast error policy failed on this context
+func (t *tracker) failedFast() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.failed == "" {
+		return nil
+	}
+	return fmt.Errorf("pgocomp: not applied because component %s failed (policy %s)", t.failed, ErrorPolicy{Mode: FailFast})
+}
+
+// export calls ctx.Export holding the tracker lock, because pulumi contexts do not protect their exports
+func (t *tracker) export(ctx *pulumi.Context, name string, value pulumi.Input) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	ctx.Export(name, value)
+}
+
+// typeName returns a readable name of the type T
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}